@@ -0,0 +1,122 @@
+package simplelog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultArchiveTemplate is the name template of archived log files if no template is set.
+const defaultArchiveTemplate = "{name}_{ts}"
+
+// archivePath returns the path of a not yet existing archive of the log file.
+// The name of the archive is built from the archive name template, where {name} is replaced by the name of the
+// log file, {ts} by the timestamp and {seq} by a sequence number, starting with 1. If the template has no {seq}
+// placeholder and an archive with this name already exists, a sequence number is added to the name.
+func (f *fileLogger) archivePath(logFileName, ts string) (string, error) {
+	dir := f.archiveDir
+	if dir == "" {
+		dir = filepath.Dir(logFileName)
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	template := f.archiveTemplate
+	if template == "" {
+		template = defaultArchiveTemplate
+	}
+	hasSeq := strings.Contains(template, "{seq}")
+	for seq := 1; ; seq++ {
+		name := strings.NewReplacer("{name}", filepath.Base(logFileName), "{ts}", ts, "{seq}", strconv.Itoa(seq)).Replace(template)
+		if !hasSeq && seq > 1 {
+			name += "_" + strconv.Itoa(seq-1)
+		}
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path, nil
+		}
+	}
+}
+
+// moveFile moves a file to a new path.
+// If the file can't be renamed, e.g. because the new path is on another file system, it is copied and removed.
+func moveFile(oldPath, newPath string) error {
+	if err := os.Rename(oldPath, newPath); err == nil {
+		return nil
+	}
+	src, err := os.Open(oldPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(newPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(newPath)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	return os.Remove(oldPath)
+}
+
+// compressFile compresses a file with gzip and removes it. It returns the path of the compressed file, which is
+// the path of the file with the suffix .gz.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	gzPath := path + ".gz"
+	dst, err := os.OpenFile(gzPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(gzPath)
+		return "", err
+	}
+	src.Close()
+	return gzPath, os.Remove(path)
+}
+
+// validArchiveTemplate returns true, if the archive name template only uses known placeholders and
+// doesn't contain a path separator, false otherwise.
+func validArchiveTemplate(template string) bool {
+	name := strings.NewReplacer("{name}", "", "{ts}", "", "{seq}", "").Replace(template)
+	return !strings.ContainsAny(name, "{}/"+string(filepath.Separator))
+}
+
+// setArchive implements SetArchive for the log service.
+func (s *simpleLogService) setArchive(dir, template string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !validArchiveTemplate(template) {
+		return ErrInvalidArchiveTemplate
+	}
+	return s.configure(setarchive, &archiveRequest{dir: dir, template: template})
+}
+
+// setArchiveHook implements SetArchiveHook for the log service.
+func (s *simpleLogService) setArchiveHook(hook func(archivedPath string)) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setarchivehook, hook)
+}
@@ -0,0 +1,158 @@
+package simplelog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// auditTag separates a line of the log file from its sequence number and HMAC in audit mode.
+const auditTag = " audit="
+
+// auditChain is an io.Writer which appends a sequence number and an HMAC to each line written to the log file,
+// if the audit mode is enabled. The HMAC of a line covers the HMAC of the previous line, the sequence number and
+// the line itself, so that modified, inserted or deleted lines break the chain.
+type auditChain struct {
+	w       io.Writer // the underlying writer
+	enabled bool      // flag to indicate whether the audit mode is enabled
+	key     []byte    // the key of the HMAC
+	seq     uint64    // the sequence number of the last line
+	last    string    // the HMAC of the last line as hex string; empty if no line was written yet
+	buf     []byte    // the lines with their sequence numbers and HMACs
+}
+
+// Write appends the sequence number and the HMAC to each non-empty line of p before it is written.
+// Write implements the io.Writer interface.
+func (a *auditChain) Write(p []byte) (int, error) {
+	if !a.enabled {
+		return a.w.Write(p)
+	}
+	a.buf = a.buf[:0]
+	for len(p) > 0 {
+		line, rest, found := bytes.Cut(p, []byte("\n"))
+		if len(line) > 0 {
+			a.seq++
+			a.last = auditMAC(a.key, a.last, a.seq, line)
+			a.buf = append(a.buf, line...)
+			a.buf = append(a.buf, auditTag...)
+			a.buf = strconv.AppendUint(a.buf, a.seq, 10)
+			a.buf = append(a.buf, ':')
+			a.buf = append(a.buf, a.last...)
+		}
+		if found {
+			a.buf = append(a.buf, '\n')
+		}
+		p = rest
+	}
+	return a.w.Write(a.buf)
+}
+
+// resume continues the chain of the last audited line of the log file, if it has one.
+// Otherwise, a new chain is started.
+func (a *auditChain) resume(logFileName string) error {
+	a.seq, a.last = 0, ""
+	file, err := os.Open(logFileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	// read the end of the log file, doubling the size until it contains the last line
+	for size := int64(4096); ; size *= 2 {
+		if size > info.Size() {
+			size = info.Size()
+		}
+		tail := make([]byte, size)
+		if _, err = file.ReadAt(tail, info.Size()-size); err != nil {
+			return err
+		}
+		tail = bytes.TrimRight(tail, "\n")
+		i := bytes.LastIndexByte(tail, '\n')
+		if i < 0 && size < info.Size() {
+			continue
+		}
+		if _, seq, mac, ok := splitAudit(string(tail[i+1:])); ok {
+			a.seq, a.last = seq, mac
+		}
+		return nil
+	}
+}
+
+// auditMAC returns the HMAC of a line as hex string, which is chained to the HMAC of the previous line.
+func auditMAC(key []byte, last string, seq uint64, line []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(last))
+	h.Write(strconv.AppendUint(nil, seq, 10))
+	h.Write(line)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// splitAudit splits an audited line into the line as it was logged, its sequence number and its HMAC.
+// The returned flag is false, if the line isn't audited.
+func splitAudit(line string) (string, uint64, string, bool) {
+	i := strings.LastIndex(line, auditTag)
+	if i < 0 {
+		return "", 0, "", false
+	}
+	seqText, mac, found := strings.Cut(line[i+len(auditTag):], ":")
+	if !found {
+		return "", 0, "", false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil {
+		return "", 0, "", false
+	}
+	return line[:i], seq, mac, true
+}
+
+// verify implements Verify.
+func verify(logFileName string, key []byte) error {
+	file, err := os.Open(logFileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var seq uint64
+	var last string
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		text, err := reader.ReadString('\n')
+		if text = strings.TrimSuffix(text, "\n"); text != "" {
+			line, lineSeq, mac, ok := splitAudit(text)
+			switch {
+			case !ok && seq == 0:
+				// lines written before the audit mode was enabled
+			case !ok || lineSeq != seq+1 || !hmac.Equal([]byte(mac), []byte(auditMAC(key, last, lineSeq, []byte(line)))):
+				return fmt.Errorf("%w: line %d", ErrAuditViolation, n)
+			default:
+				seq, last = lineSeq, mac
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// setAudit implements SetAudit for the log service.
+func (s *simpleLogService) setAudit(enabled bool, key []byte) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	return s.configure(setaudit, &auditRequest{enabled: enabled, key: key})
+}
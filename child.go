@@ -0,0 +1,146 @@
+package simplelog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Child represents a component of an application, e.g. an HTTP server or a database layer, which writes log
+// messages to the log service it was derived from. The name of the component is placed after the prefix of the
+// log destination and the structured fields of the Child are written with every log message of the Child.
+// A Child is lightweight and can be passed around freely; it is safe for concurrent use.
+type Child struct {
+	service   *simpleLogService
+	component string             // the name of the component, e.g. [http]
+	fields    []any              // the structured fields written with every log message as alternating keys and values
+	settings  *componentSettings // the settings of the named component; nil if the Child isn't returned by GetLogger
+}
+
+// componentSettings are the settings of a named component, which can be changed at runtime.
+type componentSettings struct {
+	level        int32 // minimum level of log records; 0 if all log records are written
+	destinations int32 // log destinations the log records may be written to; 0 if not restricted
+}
+
+// Derive returns a Child of the Child, whose component name is appended to the component name of the Child
+// and whose structured fields are added to the structured fields of the Child.
+// See Derive for details.
+func (c *Child) Derive(component string, keysAndValues ...any) *Child {
+	child := c.service.derive(c.component+" "+component, c.fields, keysAndValues)
+	child.settings = c.settings
+	return child
+}
+
+// Write writes a log message of the Child to a specified destination.
+// See Write for details.
+func (c *Child) Write(destination int, values ...any) error {
+	return c.service.writeChild(c, logMessage{destination: destination}.withValues(values))
+}
+
+// Writef writes a formatted log message of the Child to a specified destination.
+// See Writef for details.
+func (c *Child) Writef(destination int, format string, values ...any) error {
+	if vetPrintf {
+		_ = fmt.Sprintf(format, values...)
+	}
+	return c.service.writeChild(c, logMessage{destination: destination, format: format}.withValues(values))
+}
+
+// WriteKV writes a log message of the Child with additional structured fields to a specified destination.
+// See WriteKV for details.
+func (c *Child) WriteKV(destination int, msg string, keysAndValues ...any) error {
+	return c.service.writeChild(c, logMessage{destination: destination, data: []any{msg}, fields: keysAndValues})
+}
+
+// WriteFields writes a log message of the Child with additional typed structured fields to a specified destination.
+// See WriteFields for details.
+func (c *Child) WriteFields(destination int, msg string, fields ...Field) error {
+	return c.service.writeChild(c, logMessage{destination: destination, data: []any{msg}, typed: append([]Field(nil), fields...)})
+}
+
+// Log writes a log message of the Child with a log level to a specified destination.
+// See Log for details.
+func (c *Child) Log(level int, destination int, values ...any) error {
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	return c.service.writeChild(c, logMessage{destination: destination, level: level}.withValues(values))
+}
+
+// LogKV writes a log message of the Child with a log level and additional structured fields to a specified
+// destination. See Log and WriteKV for details.
+func (c *Child) LogKV(level int, destination int, msg string, keysAndValues ...any) error {
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	return c.service.writeChild(c, logMessage{destination: destination, level: level, data: []any{msg}, fields: keysAndValues})
+}
+
+// derive implements Derive for the log service.
+// The structured fields of the Child consist of the structured fields of the parent and the given ones.
+func (s *simpleLogService) derive(component string, parent, keysAndValues []any) *Child {
+	fields := make([]any, 0, len(parent)+len(keysAndValues))
+	fields = append(fields, parent...)
+	fields = append(fields, keysAndValues...)
+	return &Child{service: s, component: component, fields: fields}
+}
+
+// writeChild writes a log message of a Child with the component name and the structured fields of the Child.
+// Log messages of named components are filtered by the level and the log destinations of the component
+// before they are passed to the log service.
+func (s *simpleLogService) writeChild(c *Child, logMsg logMessage) error {
+	if c.settings != nil {
+		if !s.isActive() {
+			return ErrNotRunning
+		}
+		if !isLogged(logMsg.level, int(atomic.LoadInt32(&c.settings.level))) {
+			return nil
+		}
+		if destinations := int(atomic.LoadInt32(&c.settings.destinations)); destinations != 0 {
+			if logMsg.destination &= destinations; logMsg.destination == 0 {
+				return nil
+			}
+		}
+	}
+	if len(c.fields) > 0 {
+		logMsg.fields = append(append([]any(nil), c.fields...), logMsg.fields...)
+	}
+	if err := checkFields(logMsg.fields); err != nil {
+		return err
+	}
+	logMsg.component = c.component
+	return s.enqueue(logMsg)
+}
+
+// getLogger implements GetLogger for the log service.
+func (s *simpleLogService) getLogger(name string) *Child {
+	s.componentsMu.Lock()
+	defer s.componentsMu.Unlock()
+	if c, ok := s.components[name]; ok {
+		return c
+	}
+	if s.components == nil {
+		s.components = make(map[string]*Child)
+	}
+	c := &Child{service: s, component: "[" + name + "]", settings: new(componentSettings)}
+	s.components[name] = c
+	return c
+}
+
+// setComponentLevel implements SetComponentLevel for the log service.
+func (s *simpleLogService) setComponentLevel(name string, level int) error {
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	atomic.StoreInt32(&s.getLogger(name).settings.level, int32(level))
+	return nil
+}
+
+// setComponentDestinations implements SetComponentDestinations for the log service.
+func (s *simpleLogService) setComponentDestinations(name string, destinations int) error {
+	if destinations != 0 && !s.isDestinations(destinations) {
+		return ErrUnknownDestination
+	}
+	atomic.StoreInt32(&s.getLogger(name).settings.destinations, int32(destinations))
+	return nil
+}
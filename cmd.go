@@ -0,0 +1,79 @@
+package simplelog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// logCmd implements LogCmd for the log service.
+func (s *simpleLogService) logCmd(cmd *exec.Cmd, destination int, prefix string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestinations(destination) {
+		return ErrUnknownDestination
+	}
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return ErrCmdOutputSet
+	}
+	if prefix != "" {
+		prefix += " "
+	}
+
+	// the write ends of the pipes are passed to the command; the log service reads from the read ends
+	var readers, writers []*os.File
+	defer func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
+	for i := 0; i < 2; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			for _, r := range readers {
+				r.Close()
+			}
+			return err
+		}
+		readers = append(readers, r)
+		writers = append(writers, w)
+	}
+	cmd.Stdout, cmd.Stderr = writers[0], writers[1]
+	if err := cmd.Start(); err != nil {
+		cmd.Stdout, cmd.Stderr = nil, nil
+		for _, r := range readers {
+			r.Close()
+		}
+		return err
+	}
+
+	for i, tag := range []string{"[stdout]", "[stderr]"} {
+		done := make(chan struct{})
+		s.cmdsMu.Lock()
+		s.cmds = append(s.cmds, done)
+		s.cmdsMu.Unlock()
+		go func(r *os.File, component string) {
+			defer close(done)
+			defer r.Close()
+			s.readLines(r, destination, component)
+		}(readers[i], prefix+tag)
+	}
+	return nil
+}
+
+// waitCmds waits until the commands started by LogCmd closed their stdout and stderr, but gives up when the
+// context expires. It has to be called before the log service is stopped.
+func (s *simpleLogService) waitCmds(ctx context.Context) {
+	s.cmdsMu.Lock()
+	cmds := s.cmds
+	s.cmds = nil
+	s.cmdsMu.Unlock()
+	for _, done := range cmds {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
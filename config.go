@@ -0,0 +1,137 @@
+package simplelog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// config represents the structure of a configuration file loaded by LoadConfig.
+type config struct {
+	Buffer *int `json:"buffer"` // size of the data channel
+	File   *struct {
+		Name     string `json:"name"`     // name of the log file
+		Append   bool   `json:"append"`   // flag to indicate whether the log file is appended to
+		Rotation string `json:"rotation"` // interval of the time-based rotation, e.g. 24h
+	} `json:"file"`
+	Network *struct {
+		Network string `json:"network"` // name of the network, e.g. tcp or udp
+		Address string `json:"address"` // address of the remote host
+	} `json:"network"`
+	Webhook *struct {
+		URL string `json:"url"` // URL of the webhook
+	} `json:"webhook"`
+	Destinations map[string]struct {
+		Prefix []string `json:"prefix"` // prefix items, e.g. ["#2006-01-02 15:04:05#", "#pid#"]
+		Level  string   `json:"level"`  // minimum level, e.g. INFO
+		Format string   `json:"format"` // format, i.e. text or json
+		Color  *bool    `json:"color"`  // flag to indicate whether log records are colorized
+	} `json:"destinations"` // settings by log destination name, e.g. stdout
+}
+
+// formatNames maps the names of the log record formats used in configuration files to the formats.
+var formatNames = map[string]int{
+	"text": TEXT,
+	"json": JSON,
+	"gelf": GELF,
+}
+
+// loadConfig implements LoadConfig for the log service.
+func (s *simpleLogService) loadConfig(path string) error {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		// only JSON is supported by the standard library
+		return ErrUnknownFormat
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg config
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	if !s.isActive() {
+		bufferSize := 1
+		if cfg.Buffer != nil {
+			bufferSize = *cfg.Buffer
+		}
+		if bufferSize < 0 {
+			return ErrInvalidBufferSize
+		}
+		if err = s.startup(bufferSize); err != nil {
+			return err
+		}
+	} else if cfg.Buffer != nil {
+		if err = s.setBufferSize(*cfg.Buffer); err != nil {
+			return err
+		}
+	}
+
+	if f := cfg.File; f != nil {
+		if err = s.setupLog(f.Name, f.Append); err != nil {
+			return err
+		}
+		if f.Rotation != "" {
+			interval, err := time.ParseDuration(f.Rotation)
+			if err != nil {
+				return ErrInvalidInterval
+			}
+			if err = s.setRotation(interval); err != nil {
+				return err
+			}
+		}
+	}
+	if n := cfg.Network; n != nil {
+		if err = s.setupNetworkLog(n.Network, n.Address); err != nil {
+			return err
+		}
+	}
+	if w := cfg.Webhook; w != nil {
+		if err = s.setupWebhookLog(w.URL); err != nil {
+			return err
+		}
+	}
+
+	for name, settings := range cfg.Destinations {
+		destination, ok := destinationNames[strings.ToLower(name)]
+		if !ok {
+			return ErrUnknownDestination
+		}
+		err = s.forEachDestination(destination, func(d int) error {
+			if settings.Prefix != nil {
+				if err := s.setPrefix(d, settings.Prefix...); err != nil {
+					return err
+				}
+			}
+			if settings.Level != "" {
+				level, ok := levelByName(settings.Level)
+				if !ok {
+					return ErrUnknownLevel
+				}
+				if err := s.setLevel(d, level); err != nil {
+					return err
+				}
+			}
+			if settings.Format != "" {
+				format, ok := formatNames[strings.ToLower(settings.Format)]
+				if !ok {
+					return ErrUnknownFormat
+				}
+				if err := s.setFormat(d, format); err != nil {
+					return err
+				}
+			}
+			if settings.Color != nil {
+				return s.setColor(d, *settings.Color)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
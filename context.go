@@ -0,0 +1,27 @@
+package simplelog
+
+import (
+	"context"
+)
+
+// contextKey is the type of the key under which structured fields are stored in a context.
+type contextKey struct{}
+
+// contextFields returns the structured fields stored in the context ctx.
+func contextFields(ctx context.Context) []any {
+	fields, _ := ctx.Value(contextKey{}).([]any)
+	return fields
+}
+
+// writeCtx implements WriteCtx for the log service.
+func (s *simpleLogService) writeCtx(ctx context.Context, destination int, values ...any) error {
+	fields := contextFields(ctx)
+	if trace := s.traceFields(ctx); trace != nil {
+		// copy the fields of the context before adding the trace context
+		fields = append(fields[:len(fields):len(fields)], trace...)
+	}
+	if err := checkFields(fields); err != nil {
+		return err
+	}
+	return s.enqueue(logMessage{destination: destination, fields: fields}.withValues(values))
+}
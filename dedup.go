@@ -0,0 +1,57 @@
+package simplelog
+
+import (
+	"fmt"
+)
+
+// repeatedMessage is the text of the log record which summarizes suppressed duplicates.
+const repeatedMessage = "last message repeated %d times"
+
+// deduplicator is a data collection to support the suppression of consecutive identical log messages.
+type deduplicator struct {
+	enabled  bool       // flag to indicate whether consecutive identical log messages are suppressed
+	key      string     // the key of the last log message written
+	last     logMessage // the last log message written
+	repeated int        // number of suppressed duplicates of the last log message
+}
+
+// deduplicate returns true, if the log message is identical to the last one and was suppressed, false otherwise.
+// Before a log message different to the suppressed duplicates is written, the duplicates are summarized.
+func (s *simpleLogService) deduplicate(logMsg *logMessage) bool {
+	if !s.dedup.enabled {
+		return false
+	}
+	key := fmt.Sprint(logMsg.destination, logMsg.level, logMsg.text(), logMsg.fields)
+	if key == s.dedup.key && s.dedup.last.destination != 0 {
+		s.dedup.repeated++
+		return true
+	}
+	s.summarizeRepeated()
+	s.dedup.key = key
+	s.dedup.last = *logMsg
+	return false
+}
+
+// summarizeRepeated writes a log record which summarizes the suppressed duplicates of the last log message.
+func (s *simpleLogService) summarizeRepeated() {
+	if s.dedup.repeated == 0 {
+		return
+	}
+	summary := logMessage{
+		destination: s.dedup.last.destination,
+		level:       s.dedup.last.level,
+		data:        []any{fmt.Sprintf(repeatedMessage, s.dedup.repeated)},
+		caller:      s.dedup.last.caller,
+		goid:        s.dedup.last.goid,
+	}
+	s.dedup.repeated = 0
+	s.writeRecord(&summary)
+}
+
+// setDeduplication implements SetDeduplication for the log service.
+func (s *simpleLogService) setDeduplication(enabled bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setdeduplication, enabled)
+}
@@ -0,0 +1,55 @@
+package simplelog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// internalErrorsSize is the number of internal errors kept by the log service.
+const internalErrorsSize = 100
+
+// diagnosticFormat is the format of the diagnostic records written to stderr for internal errors.
+const diagnosticFormat = "simplelog: %s %v\n"
+
+// InternalError represents an error which occurred inside the log service, e.g. a failed write to a log
+// destination or a failed log file rotation.
+type InternalError struct {
+	Time time.Time // point in time the error occurred
+	Err  error     // the error
+}
+
+// diagnose records an internal error and writes a diagnostic record for it directly to stderr, bypassing the log
+// destinations, since the failing log destination may be STDERR itself.
+// Nothing happens if err is nil.
+func (s *simpleLogService) diagnose(err error) {
+	if err == nil {
+		return
+	}
+	s.stats.record(err)
+	t := s.addInternalError(err)
+	fmt.Fprintf(consoleFile(os.Stderr), diagnosticFormat, t.Format(time.RFC3339), err)
+}
+
+// addInternalError adds an error to the last internal errors and returns the point in time it was recorded.
+// If the maximum number of internal errors is reached, the oldest one is removed.
+func (s *simpleLogService) addInternalError(err error) time.Time {
+	t := time.Now()
+	s.internalErrorsMu.Lock()
+	defer s.internalErrorsMu.Unlock()
+	if len(s.internalErrors) == internalErrorsSize {
+		copy(s.internalErrors, s.internalErrors[1:])
+		s.internalErrors = s.internalErrors[:internalErrorsSize-1]
+	}
+	s.internalErrors = append(s.internalErrors, InternalError{Time: t, Err: err})
+	return t
+}
+
+// getInternalErrors implements InternalErrors for the log service.
+// The internal errors are guarded by a mutex instead of being requested from the log service, so that they can
+// be read even if the log service is stuck.
+func (s *simpleLogService) getInternalErrors() []InternalError {
+	s.internalErrorsMu.Lock()
+	defer s.internalErrorsMu.Unlock()
+	return append([]InternalError(nil), s.internalErrors...)
+}
@@ -0,0 +1,102 @@
+package simplelog
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// low disk space modes
+const (
+	ROTATELOG  = 1 << iota // rotate the log file and compress the archived log file when the disk space is low
+	DROPDEBUG              // don't write log records of level DEBUG to the log file while the disk space is low
+	STDOUTONLY             // write the log records of the log file to stdout instead while the disk space is low
+)
+
+// allDiskModes is the combination of all low disk space modes.
+const allDiskModes = ROTATELOG | DROPDEBUG | STDOUTONLY
+
+// diskCheckInterval is the minimum time between two checks of the free disk space.
+const diskCheckInterval = 10 * time.Second
+
+// low disk space notifications
+const (
+	lowDiskMessage       = "free disk space of the log file %s is %d bytes, below the threshold of %d bytes"
+	recoveredDiskMessage = "free disk space of the log file %s is %d bytes again"
+)
+
+// freeSpace returns the number of bytes available on the file system of a path.
+// It is a variable, so that it can be replaced in tests.
+var freeSpace = diskFree
+
+// diskGuard is a data collection to support the detection of low disk space on the file system of the log file.
+type diskGuard struct {
+	minFree   uint64    // the minimum free disk space in bytes; 0 if the disk space isn't checked
+	mode      int       // the combination of low disk space modes
+	low       bool      // flag to indicate whether the disk space is low
+	lastCheck time.Time // point in time of the last check of the free disk space
+}
+
+// degraded returns the log message as it is written while the disk space is low.
+// It returns false, if the log message isn't written at all, true otherwise.
+func (g *diskGuard) degraded(logMsg *logMessage) bool {
+	if !g.low || logMsg.destination&FILE == 0 {
+		return true
+	}
+	if g.mode&DROPDEBUG != 0 && logMsg.level == DEBUG {
+		if logMsg.destination &^= FILE; logMsg.destination == 0 {
+			return false
+		}
+	}
+	if g.mode&STDOUTONLY != 0 && logMsg.destination&FILE != 0 {
+		logMsg.destination = logMsg.destination&^FILE | STDOUT
+	}
+	return true
+}
+
+// checkDiskSpace checks the free disk space of the file system of the log file, at most once per
+// diskCheckInterval unless forced. When the disk space becomes low, a log record of level WARN is written to
+// STDERR and the log file is rotated and compressed, if requested. When the disk space recovers, a log record of
+// level INFO is written to STDERR.
+func (s *simpleLogService) checkDiskSpace(force bool) {
+	g := &s.diskGuard
+	if g.minFree == 0 || s.desc == nil || !force && time.Since(g.lastCheck) < diskCheckInterval {
+		return
+	}
+	g.lastCheck = time.Now()
+	logName := s.desc.Name()
+	free, err := freeSpace(filepath.Dir(logName))
+	if err != nil {
+		s.diagnose(err)
+		return
+	}
+	switch {
+	case free < g.minFree && !g.low:
+		g.low = true
+		warning := logMessage{destination: STDERR, level: WARN, data: []any{fmt.Sprintf(lowDiskMessage, logName, free, g.minFree)}}
+		s.writeRecord(&warning)
+		if g.mode&ROTATELOG != 0 {
+			s.compressArchives = true
+			s.diagnose(s.rotateLogFile(rotationSuffix(s.now(), time.Minute)))
+			s.compressArchives = false
+		}
+	case free >= g.minFree && g.low:
+		g.low = false
+		notice := logMessage{destination: STDERR, level: INFO, data: []any{fmt.Sprintf(recoveredDiskMessage, logName, free)}}
+		s.writeRecord(&notice)
+	}
+}
+
+// setDiskGuard implements SetDiskGuard for the log service.
+func (s *simpleLogService) setDiskGuard(minFree uint64, mode int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	if mode&^allDiskModes != 0 {
+		return ErrUnknownDiskMode
+	}
+	return s.configure(setdiskguard, &diskGuardRequest{minFree: minFree, mode: mode})
+}
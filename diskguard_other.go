@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package simplelog
+
+// diskFree returns the number of bytes available on the file system of a path.
+// The free disk space can't be determined on this platform.
+func diskFree(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
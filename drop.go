@@ -0,0 +1,113 @@
+package simplelog
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// droppedMessage is the text of the log record which reports dropped log messages.
+const droppedMessage = "%d log messages dropped because the data channel was full"
+
+// send sends a log message to the data channel according to the drop policy.
+// Log messages of level ERROR or above are sent to the priority channel instead, as long as it isn't full.
+func (s *simpleLogService) send(logMsg logMessage) {
+	if logMsg.level >= ERROR {
+		select {
+		case s.priorityQueue <- logMsg:
+			return
+		default:
+		}
+	}
+	switch atomic.LoadInt32(&s.dropPolicy) {
+	case DROPNEWEST:
+		select {
+		case s.dataQueue <- logMsg:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	case DROPOLDEST:
+		for {
+			select {
+			case s.dataQueue <- logMsg:
+				return
+			default:
+			}
+			// make room for the log message
+			select {
+			case <-s.dataQueue:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+		}
+	case SPILL:
+		if !s.overflow.pending() {
+			select {
+			case s.dataQueue <- logMsg:
+				return
+			default:
+			}
+		}
+		// keep spilling until the spilled log messages are replayed, to retain the order of log messages
+		if s.overflow.spill(logMsg) != nil {
+			s.dataQueue <- logMsg
+		}
+	default:
+		s.dataQueue <- logMsg
+	}
+}
+
+// sendWithin sends a log message to the data channel, but gives up if the data channel is still full after the
+// timeout of the log message, regardless of the drop policy. With a negative timeout, it gives up immediately.
+// ErrQueueFull is returned if the log message was dropped.
+func (s *simpleLogService) sendWithin(logMsg logMessage) error {
+	if logMsg.level >= ERROR {
+		select {
+		case s.priorityQueue <- logMsg:
+			return nil
+		default:
+		}
+	}
+	select {
+	case s.dataQueue <- logMsg:
+		return nil
+	default:
+	}
+	if logMsg.timeout > 0 {
+		timer := time.NewTimer(logMsg.timeout)
+		defer timer.Stop()
+		select {
+		case s.dataQueue <- logMsg:
+			return nil
+		case <-timer.C:
+		}
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return ErrQueueFull
+}
+
+// reportDrops writes a log record of level WARN to STDERR, if log messages were dropped since the last report.
+func (s *simpleLogService) reportDrops() {
+	dropped := atomic.LoadUint64(&s.dropped)
+	if dropped == s.reportedDrops {
+		return
+	}
+	report := logMessage{destination: STDERR, level: WARN, data: []any{fmt.Sprintf(droppedMessage, dropped-s.reportedDrops)}}
+	s.addInternalError(fmt.Errorf("%w: %d log messages dropped", ErrQueueFull, dropped-s.reportedDrops))
+	s.reportedDrops = dropped
+	s.writeRecord(&report)
+}
+
+// setDropPolicy implements SetDropPolicy for the log service.
+func (s *simpleLogService) setDropPolicy(policy int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	switch policy {
+	case BLOCK, DROPNEWEST, DROPOLDEST, SPILL:
+		atomic.StoreInt32(&s.dropPolicy, int32(policy))
+		return nil
+	default:
+		return ErrUnknownDropPolicy
+	}
+}
@@ -0,0 +1,132 @@
+package simplelog
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// environment variables read by ConfigFromEnv
+const (
+	envFile        = "SIMPLELOG_FILE"        // name of the log file, which is appended to
+	envLevel       = "SIMPLELOG_LEVEL"       // minimum level of log records, e.g. INFO
+	envBuffer      = "SIMPLELOG_BUFFER"      // size of the data channel of the log service
+	envPrefix      = "SIMPLELOG_PREFIX"      // prefix of log records, e.g. "#2006-01-02 15:04:05# #pid#"
+	envDestination = "SIMPLELOG_DESTINATION" // comma-separated log destinations, e.g. stdout,file
+)
+
+// destinationNames maps the names of the built-in log destinations used in environment variables to their bits.
+var destinationNames = map[string]int{
+	"stdout":  STDOUT,
+	"stderr":  STDERR,
+	"file":    FILE,
+	"multi":   MULTI,
+	"network": NETWORK,
+	"webhook": WEBHOOK,
+	"ring":    RING,
+	"journal": JOURNAL,
+}
+
+// configFromEnv implements ConfigFromEnv for the log service.
+func (s *simpleLogService) configFromEnv() (int, error) {
+	bufferSize := 1
+	if v, ok := os.LookupEnv(envBuffer); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, ErrInvalidBufferSize
+		}
+		bufferSize = n
+	}
+	if !s.isActive() {
+		if err := s.startup(bufferSize); err != nil {
+			return 0, err
+		}
+	} else if _, ok := os.LookupEnv(envBuffer); ok {
+		if err := s.setBufferSize(bufferSize); err != nil {
+			return 0, err
+		}
+	}
+
+	destination := STDOUT
+	if v := os.Getenv(envFile); v != "" {
+		if err := s.setupLog(v, true); err != nil {
+			return 0, err
+		}
+		destination = FILE
+	}
+	if v := os.Getenv(envDestination); v != "" {
+		destination = 0
+		for _, name := range strings.Split(v, ",") {
+			bits, ok := destinationNames[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return 0, ErrUnknownDestination
+			}
+			destination |= bits
+		}
+	}
+
+	if v := os.Getenv(envLevel); v != "" {
+		level, ok := levelByName(v)
+		if !ok {
+			return 0, ErrUnknownLevel
+		}
+		if err := s.forEachDestination(destination, func(d int) error { return s.setLevel(d, level) }); err != nil {
+			return 0, err
+		}
+	}
+	if v, ok := os.LookupEnv(envPrefix); ok {
+		prefix := splitPrefix(v)
+		if err := s.forEachDestination(destination, func(d int) error { return s.setPrefix(d, prefix...) }); err != nil {
+			return 0, err
+		}
+	}
+	return destination, nil
+}
+
+// forEachDestination calls f for each log destination bit contained in destination.
+func (s *simpleLogService) forEachDestination(destination int, f func(int) error) error {
+	for d := 1; d <= destination && d <= lastCustomDestination; d <<= 1 {
+		if destination&d == 0 {
+			continue
+		}
+		if err := f(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// levelByName returns the log level of a level name, e.g. INFO. The name is case-insensitive.
+func levelByName(name string) (int, bool) {
+	for level, v := range levelNames {
+		if strings.EqualFold(v, name) {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// splitPrefix splits a prefix into prefix items separated by blanks.
+// Date/time placeholders, which contain blanks themselves, e.g. #2006-01-02 15:04:05#, are kept as one item.
+func splitPrefix(prefix string) []string {
+	var items []string
+	var placeholder []string
+	for _, field := range strings.Fields(prefix) {
+		switch {
+		case placeholder != nil:
+			placeholder = append(placeholder, field)
+			if strings.HasSuffix(field, dateTimeTag) {
+				items = append(items, strings.Join(placeholder, " "))
+				placeholder = nil
+			}
+		case strings.HasPrefix(field, dateTimeTag) && (len(field) == 1 || !strings.HasSuffix(field, dateTimeTag)):
+			placeholder = []string{field}
+		default:
+			items = append(items, field)
+		}
+	}
+	if placeholder != nil {
+		items = append(items, strings.Join(placeholder, " "))
+	}
+	return items
+}
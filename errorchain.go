@@ -0,0 +1,65 @@
+package simplelog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// causePrefix is placed in front of each wrapped error of an error chain.
+const causePrefix = "  caused by "
+
+// multiUnwrapper is implemented by errors which wrap several errors, e.g. the errors returned by errors.Join.
+type multiUnwrapper interface {
+	Unwrap() []error
+}
+
+// appendErrorChain appends each error wrapped by err, with its type, on a line of its own to the builder.
+// The errors wrapped by an error wrapping several errors, e.g. by errors.Join, are indented one level further.
+func appendErrorChain(b *strings.Builder, err error, depth int) {
+	var causes []error
+	if m, ok := err.(multiUnwrapper); ok {
+		causes = m.Unwrap()
+	} else if cause := errors.Unwrap(err); cause != nil {
+		causes = []error{cause}
+	}
+	if len(causes) > 1 {
+		depth++
+	}
+	for _, cause := range causes {
+		appendCause(b, cause, depth)
+		appendErrorChain(b, cause, depth)
+	}
+}
+
+// appendCause appends a wrapped error with its type as line to the builder.
+func appendCause(b *strings.Builder, err error, depth int) {
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(b, "%s%T: %s", causePrefix, err, err.Error())
+}
+
+// errorStack returns the stack trace of the error chain of err, as formatted by the verb %+v of errors
+// implementing fmt.Formatter, e.g. of github.com/pkg/errors; nil if no error of the chain provides a stack trace.
+func errorStack(err error) []byte {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if _, ok := err.(fmt.Formatter); !ok {
+			continue
+		}
+		if verbose := fmt.Sprintf("%+v", err); verbose != err.Error() {
+			return []byte(strings.TrimSuffix(verbose, "\n"))
+		}
+	}
+	return nil
+}
+
+// writeError implements WriteError for the log service.
+func (s *simpleLogService) writeError(destination int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString(err.Error())
+	appendErrorChain(&b, err, 0)
+	return s.enqueue(logMessage{destination: destination, level: ERROR, data: []any{b.String()}, stack: errorStack(err)})
+}
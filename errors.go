@@ -0,0 +1,59 @@
+package simplelog
+
+import (
+	"errors"
+)
+
+// Error represents an error of the log service with its code in the message catalog, e.g. sg000 for ErrNotRunning.
+// Failures of the file system or the network, e.g. while a log file is opened, are wrapped in an Error, which
+// matches its sentinel error by errors.Is and unwraps to the original error, e.g. fs.ErrPermission.
+type Error struct {
+	Code string // the code of the error in the message catalog, e.g. sg000
+	msg  string // the message of the error
+	err  error  // the wrapped error; nil if the Error is a sentinel error
+}
+
+// newError returns a sentinel error with a code and a message of the message catalog.
+func newError(code, msg string) *Error {
+	return &Error{Code: code, msg: msg}
+}
+
+// Error returns the message of the error, followed by the message of the wrapped error, if any.
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.msg + ": " + e.err.Error()
+	}
+	return e.msg
+}
+
+// Unwrap returns the wrapped error; nil if the Error is a sentinel error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is returns true, if the target is an Error with the same code, e.g. the sentinel error an Error wraps a
+// failure in.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// wrapError returns the error err wrapped in the sentinel error e. Errors of the log service itself, e.g.
+// ErrServiceTimeout, and nil are returned unchanged.
+func wrapError(e *Error, err error) error {
+	var target *Error
+	if err == nil || errors.As(err, &target) {
+		return err
+	}
+	return &Error{Code: e.Code, msg: e.msg, err: err}
+}
+
+// errorCode implements ErrorCode.
+func errorCode(err error) string {
+	var target *Error
+	if errors.As(err, &target) {
+		return target.Code
+	}
+	return ""
+}
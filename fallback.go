@@ -0,0 +1,82 @@
+package simplelog
+
+import (
+	"strings"
+)
+
+// ErrorHandler represents a function which is called for each log record which couldn't be written to a log
+// destination. It gets the error and the log record with the failing log destination.
+type ErrorHandler func(err error, rec *Record)
+
+// writeFailed handles a log message which couldn't be written to a log destination.
+// The error is recorded as internal error and passed to the error handler, and the log message is written to the
+// fallback log destinations of the failing log destination, so that it isn't lost. Failed writes to fallback log
+// destinations aren't written to fallback log destinations again, which could otherwise loop.
+func (s *simpleLogService) writeFailed(destination int, err error, logMsg *logMessage) {
+	s.diagnose(err)
+	s.fallBack(destination, err, logMsg)
+}
+
+// writeFailedChunks handles the log records whose writes failed in the worker goroutine of an asynchronous log
+// destination, e.g. of the log file. The error is recorded once as internal error. As the log records are already
+// formatted, each failed line is passed as preformatted log message to the error handler and written to the
+// fallback log destinations.
+func (s *simpleLogService) writeFailedChunks(destination int, a *asyncWriter) {
+	if a == nil {
+		return
+	}
+	chunks, err := a.failures()
+	if err == nil {
+		return
+	}
+	s.diagnose(err)
+	for _, chunk := range chunks {
+		for _, line := range strings.Split(string(chunk), "\n") {
+			if line != "" {
+				logMsg := logMessage{destination: destination, line: line}
+				s.fallBack(destination, err, &logMsg)
+			}
+		}
+	}
+}
+
+// fallBack passes a log message which couldn't be written to a log destination to the error handler and writes it
+// to the fallback log destinations of the failing log destination.
+func (s *simpleLogService) fallBack(destination int, err error, logMsg *logMessage) {
+	if s.errorHandler != nil {
+		rec := newRecord(logMsg)
+		rec.Destination = destination
+		s.errorHandler(err, rec)
+	}
+	fallback, ok := s.fallbacks[destination]
+	if !ok || s.fallingBack {
+		return
+	}
+	fallbackMsg := *logMsg
+	fallbackMsg.destination = fallback
+	s.fallingBack = true
+	s.writeDestinations(&fallbackMsg)
+	s.fallingBack = false
+}
+
+// setErrorHandler implements SetErrorHandler for the log service.
+func (s *simpleLogService) setErrorHandler(handler ErrorHandler) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(seterrorhandler, handler)
+}
+
+// setFallback implements SetFallback for the log service.
+func (s *simpleLogService) setFallback(destination, fallback int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) || fallback != 0 && !s.isDestinations(fallback) {
+		return ErrUnknownDestination
+	}
+	if fallback&destination != 0 {
+		return ErrInvalidFallback
+	}
+	return s.configure(setfallback, &fallbackRequest{destination: destination, fallback: fallback})
+}
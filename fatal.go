@@ -0,0 +1,42 @@
+package simplelog
+
+import (
+	"fmt"
+	"os"
+)
+
+// exit terminates the program; it is a variable to be replaced in tests.
+var exit = os.Exit
+
+// sync writes all log messages in the data channel and the log records buffered by the log destinations.
+// It returns, after all of them have been written.
+func (s *simpleLogService) sync() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(synclog, nil)
+}
+
+// fatal implements Fatal for the log service.
+func (s *simpleLogService) fatal(destination int, values ...any) {
+	s.enqueue(logMessage{destination: destination, level: FATAL}.withValues(values))
+	s.sync()
+	exit(1)
+}
+
+// panicw implements Panicw for the log service.
+func (s *simpleLogService) panicw(destination int, values ...any) {
+	s.enqueue(logMessage{destination: destination, level: FATAL}.withValues(values))
+	s.sync()
+	panic(fmt.Sprint(values...))
+}
+
+// logPanic writes a log record of level FATAL with the recovered panic value and the stack trace of the panicking
+// goroutine and flushes the log synchronously. If repanic is true, it panics again with the panic value.
+func (s *simpleLogService) logPanic(destination int, value any, repanic bool) {
+	s.enqueue(logMessage{destination: destination, level: FATAL, data: []any{"panic:", value}, stack: stackTrace(stackSkip)})
+	s.sync()
+	if repanic {
+		panic(value)
+	}
+}
@@ -0,0 +1,114 @@
+package simplelog
+
+import (
+	"math"
+	"time"
+)
+
+// signed is the constraint of the signed integer types of typed fields.
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// unsigned is the constraint of the unsigned integer types of typed fields.
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// float is the constraint of the floating-point types of typed fields.
+type float interface {
+	~float32 | ~float64
+}
+
+// kinds of typed fields
+const (
+	anyField = iota
+	intField
+	uintField
+	floatField
+	stringField
+	boolField
+	durationField
+	errorField
+)
+
+// Field represents a strongly typed structured field of a log message, which is created by Int, Uint, Float, Str,
+// Bool, Dur, Err or Any and written by WriteFields. Unlike the keys and values of WriteKV, the value of a Field
+// isn't boxed into an interface by the caller; this is done by the log service.
+type Field struct {
+	Key   string // the key of the structured field
+	kind  int    // the kind of the value, e.g. intField
+	num   uint64 // the value of integer, floating-point, boolean and duration fields
+	str   string // the value of string fields
+	iface any    // the value of error fields and fields created by Any
+}
+
+// Int returns a structured field with a signed integer value, e.g. Int("count", n).
+func Int[T signed](key string, value T) Field {
+	return Field{Key: key, kind: intField, num: uint64(value)}
+}
+
+// Uint returns a structured field with an unsigned integer value, e.g. Uint("bytes", n).
+func Uint[T unsigned](key string, value T) Field {
+	return Field{Key: key, kind: uintField, num: uint64(value)}
+}
+
+// Float returns a structured field with a floating-point value, e.g. Float("ratio", 0.5).
+func Float[T float](key string, value T) Field {
+	return Field{Key: key, kind: floatField, num: math.Float64bits(float64(value))}
+}
+
+// Str returns a structured field with a string value, e.g. Str("user", name).
+func Str(key, value string) Field {
+	return Field{Key: key, kind: stringField, str: value}
+}
+
+// Bool returns a structured field with a boolean value, e.g. Bool("cached", ok).
+func Bool(key string, value bool) Field {
+	var num uint64
+	if value {
+		num = 1
+	}
+	return Field{Key: key, kind: boolField, num: num}
+}
+
+// Dur returns a structured field with a duration value, e.g. Dur("latency", time.Since(start)).
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationField, num: uint64(value)}
+}
+
+// Err returns a structured field with an error value, e.g. Err("error", err).
+func Err(key string, err error) Field {
+	return Field{Key: key, kind: errorField, iface: err}
+}
+
+// Any returns a structured field with a value of any type, e.g. Any("headers", h).
+func Any(key string, value any) Field {
+	return Field{Key: key, kind: anyField, iface: value}
+}
+
+// value returns the value of the structured field.
+func (f Field) value() any {
+	switch f.kind {
+	case intField:
+		return int64(f.num)
+	case uintField:
+		return f.num
+	case floatField:
+		return math.Float64frombits(f.num)
+	case stringField:
+		return f.str
+	case boolField:
+		return f.num == 1
+	case durationField:
+		return time.Duration(f.num)
+	default:
+		return f.iface
+	}
+}
+
+// writeFields implements WriteFields for the log service.
+// The typed fields are copied, so that the variadic fields slice of the caller doesn't escape to the heap.
+func (s *simpleLogService) writeFields(destination int, msg string, fields ...Field) error {
+	return s.enqueue(logMessage{destination: destination, data: []any{msg}, typed: append([]Field(nil), fields...)})
+}
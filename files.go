@@ -0,0 +1,142 @@
+package simplelog
+
+import (
+	"os"
+)
+
+// fileLoggers are the additional log files of routing rules and named log files by file name.
+type fileLoggers map[string]*fileLogger
+
+// open returns the fileLogger of a log file, which is opened if it isn't open yet.
+// The log file is appended, if it already exists. Failed writes are retried within the retry window of the
+// main log file.
+func (fl *fileLoggers) open(name string, retryWindow *int64) (*fileLogger, error) {
+	if f, ok := (*fl)[name]; ok {
+		return f, nil
+	}
+	f := &fileLogger{retryWindow: retryWindow}
+	if err := f.setupLogFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, name); err != nil {
+		return nil, wrapError(ErrOpenLogFile, err)
+	}
+	if *fl == nil {
+		*fl = make(fileLoggers)
+	}
+	(*fl)[name] = f
+	return f, nil
+}
+
+// flush writes the buffered log records to the log files.
+func (fl fileLoggers) flush() error {
+	var err error
+	for _, f := range fl {
+		if fileErr := f.flushLogFile(); fileErr != nil {
+			err = fileErr
+		}
+	}
+	return err
+}
+
+// wait waits until the worker goroutines of the log files have written all log records passed to them.
+func (fl fileLoggers) wait() error {
+	var err error
+	for _, f := range fl {
+		if fileErr := f.waitLogFile(); fileErr != nil {
+			err = fileErr
+		}
+	}
+	return err
+}
+
+// reopen reopens the log files, e.g. after they were moved by logrotate.
+func (fl fileLoggers) reopen() error {
+	var err error
+	for name, f := range fl {
+		if fileErr := f.changeLogFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, name); fileErr != nil {
+			err = fileErr
+		}
+	}
+	return err
+}
+
+// writeFailedLogFiles handles the failed writes of the worker goroutines of the log files of the routing rules and
+// of the named log files. The log files of the routing rules are handled like the log file.
+func (s *simpleLogService) writeFailedLogFiles() {
+	for _, f := range s.logFiles {
+		destination := FILE
+		for bit, c := range s.customLoggers {
+			if c.file == f {
+				destination = bit
+			}
+		}
+		s.writeFailedChunks(destination, f.async)
+	}
+}
+
+// release releases the resources of all log files.
+func (fl fileLoggers) release() error {
+	var err error
+	for _, f := range fl {
+		if fileErr := f.releaseFileLogger(false); fileErr != nil {
+			err = fileErr
+		}
+	}
+	return err
+}
+
+// releaseLogFiles releases the additional log files and removes the routing rules and the named log files.
+func (s *simpleLogService) releaseLogFiles() {
+	s.diagnose(s.logFiles.release())
+	for destination, c := range s.customLoggers {
+		if c.file != nil {
+			delete(s.customLoggers, destination)
+			removeBits(&s.customDestinations, destination)
+			s.namedDestinations.Delete(c.name)
+		}
+	}
+	s.routes = nil
+	s.logFiles = nil
+}
+
+// addNamedLogFile opens a named log file and registers it as custom log destination.
+// It returns the log destination bit of the named log file.
+func (s *simpleLogService) addNamedLogFile(name, logName string) (int, error) {
+	_, shared := s.logFiles[logName]
+	f, err := s.logFiles.open(logName, s.fileLogger.retryWindow)
+	if err != nil {
+		return 0, err
+	}
+	destination, err := s.addCustomLogger(name, nil)
+	if err != nil {
+		if !shared {
+			// close the log file again, unless it's used by a routing rule as well
+			f.releaseFileLogger(false)
+			delete(s.logFiles, logName)
+		}
+		return 0, err
+	}
+	s.customLoggers[destination].file = f
+	return destination, nil
+}
+
+// openLogFile implements OpenLogFile for the log service.
+func (s *simpleLogService) openLogFile(name, logName string) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	req := &destinationRequest{name: name, logName: logName}
+	if err := s.configure(openlogfile, req); err != nil {
+		return 0, wrapError(ErrOpenLogFile, err)
+	}
+	destination := req.destination
+	addBits(&s.customDestinations, destination)
+	s.namedDestinations.Store(name, destination)
+	return destination, nil
+}
+
+// named implements Named for the log service.
+func (s *simpleLogService) named(name string) int {
+	if destination, ok := s.namedDestinations.Load(name); ok {
+		return destination.(int)
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package simplelog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Fluent forward protocol settings
+const (
+	fluentBatchSize  = 100             // maximum number of log records sent at once
+	fluentAckTimeout = 1 * time.Second // maximum time to wait for the ack of the Fluent server
+)
+
+// fluentSink is a record sink which sends the log records to a Fluentd or Fluent Bit server using the Fluent
+// forward protocol, i.e. as msgpack over TCP. Each batch of log records is sent in Forward mode and has to be acked
+// by the server; batches which aren't acked are sent again with the next flush.
+type fluentSink struct {
+	address string        // address of the Fluent server
+	tag     string        // the tag of the log records
+	conn    net.Conn      // connection to the Fluent server; nil if not connected
+	reader  *bufio.Reader // reads the acks of the Fluent server
+	entries [][]byte      // the msgpack encoded entries of the log records which are sent next
+}
+
+// newFluentSink connects to a Fluent server and returns a fluentSink sending log records with the tag.
+func newFluentSink(address, tag string) (*fluentSink, error) {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &fluentSink{address: address, tag: tag, conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// writeRecord adds the log message as entry, i.e. the time and a map of the message, the level, the component of a
+// Child, the stack trace and the structured fields, to the batch of log records which are sent next.
+// If the batch is full, it is sent immediately.
+func (f *fluentSink) writeRecord(logMsg *logMessage, _ []string, t time.Time) error {
+	record := map[string]any{"message": logMsg.text()}
+	if name, ok := levelNames[logMsg.level]; ok {
+		record["level"] = name
+	}
+	if logMsg.component != "" {
+		record["component"] = logMsg.component
+	}
+	if len(logMsg.stack) > 0 {
+		record["stack"] = string(logMsg.stack)
+	}
+	for i := 0; i+1 < len(logMsg.fields); i += 2 {
+		record[fmt.Sprint(logMsg.fields[i])] = logMsg.fields[i+1]
+	}
+	entry := appendMsgpackArray(nil, 2)
+	entry = appendMsgpackTime(entry, t)
+	entry = appendMsgpackMap(entry, len(record))
+	for key, value := range record {
+		entry = appendMsgpackString(entry, key)
+		entry = appendMsgpack(entry, value)
+	}
+	if len(f.entries) == maxBacklog {
+		// the Fluent server isn't reachable - drop the oldest log record
+		f.entries = f.entries[1:]
+	}
+	f.entries = append(f.entries, entry)
+	if len(f.entries) >= fluentBatchSize && f.conn != nil {
+		return f.flush()
+	}
+	return nil
+}
+
+// flush sends the batch of log records in Forward mode, i.e. [tag, [entry, ...], {"chunk": id}], and waits for the
+// ack of the Fluent server. If the connection is broken, it is reestablished with the next flush.
+func (f *fluentSink) flush() error {
+	if len(f.entries) == 0 {
+		return nil
+	}
+	if f.conn == nil {
+		conn, err := net.DialTimeout("tcp", f.address, dialTimeout)
+		if err != nil {
+			return err
+		}
+		f.conn, f.reader = conn, bufio.NewReader(conn)
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	chunk := base64.StdEncoding.EncodeToString(id[:])
+	msg := appendMsgpackArray(nil, 3)
+	msg = appendMsgpackString(msg, f.tag)
+	msg = appendMsgpackArray(msg, len(f.entries))
+	for _, entry := range f.entries {
+		msg = append(msg, entry...)
+	}
+	msg = appendMsgpackMap(msg, 1)
+	msg = appendMsgpackString(msg, "chunk")
+	msg = appendMsgpackString(msg, chunk)
+
+	f.conn.SetDeadline(time.Now().Add(fluentAckTimeout))
+	_, err := f.conn.Write(msg)
+	var ack map[string]string
+	if err == nil {
+		ack, err = readMsgpackStringMap(f.reader)
+	}
+	if err == nil && ack["ack"] != chunk {
+		err = ErrNoAck
+	}
+	if err != nil {
+		f.conn.Close()
+		f.conn = nil
+		return err
+	}
+	f.entries = nil
+	return nil
+}
+
+// close sends the batch of log records and closes the connection to the Fluent server.
+func (f *fluentSink) close() error {
+	err := f.flush()
+	if f.conn != nil {
+		if closeErr := f.conn.Close(); err == nil {
+			err = closeErr
+		}
+		f.conn = nil
+	}
+	return err
+}
+
+// registerFluentDestination implements RegisterFluentDestination for the log service.
+func (s *simpleLogService) registerFluentDestination(name, address, tag string) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	sink, err := newFluentSink(address, tag)
+	if err != nil {
+		return 0, wrapError(ErrConnect, err)
+	}
+	return s.registerSink(name, sink)
+}
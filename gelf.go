@@ -0,0 +1,132 @@
+package simplelog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GELF settings
+const (
+	gelfVersion   = "1.1" // the version of the GELF specification
+	gelfChunkSize = 8192  // maximum size of a chunk of a GELF message sent via UDP
+	gelfMaxChunks = 128   // maximum number of chunks of a GELF message
+	gelfHeader    = 12    // size of the header of a chunk: magic bytes, message ID, sequence number and count
+)
+
+// syslogSeverities maps the log levels to the syslog severities used by journald and GELF.
+var syslogSeverities = map[int]int{
+	DEBUG: 7,
+	INFO:  6,
+	WARN:  4,
+	ERROR: 3,
+	FATAL: 2,
+}
+
+// severity returns the syslog severity of a log level; log records without level have the severity of INFO.
+func severity(level int) int {
+	if sev, ok := syslogSeverities[level]; ok {
+		return sev
+	}
+	return syslogSeverities[INFO]
+}
+
+// appendGELF appends the log message as GELF message, a JSON object, to the buffer and returns the extended buffer.
+// The prefix and the component of a Child are sent as additional fields _prefix and _component, and each structured
+// field as additional field with the key prefixed by an underscore.
+func appendGELF(buf []byte, prefix string, logMsg *logMessage, t time.Time) ([]byte, error) {
+	record := map[string]any{
+		"version":       gelfVersion,
+		"host":          hostname,
+		"short_message": logMsg.text(),
+		"timestamp":     json.Number(fmt.Sprintf("%d.%03d", t.Unix(), t.Nanosecond()/int(time.Millisecond))),
+		"level":         severity(logMsg.level),
+	}
+	if len(logMsg.stack) > 0 {
+		record["full_message"] = logMsg.text() + "\n" + string(logMsg.stack)
+	}
+	if prefix != "" {
+		record["_prefix"] = prefix
+	}
+	if logMsg.component != "" {
+		record["_component"] = logMsg.component
+	}
+	addFields := func(asText bool) {
+		for key, value := range jsonFields(logMsg.fields, asText) {
+			record[gelfKey(key)] = value
+		}
+	}
+	addFields(false)
+	data, err := json.Marshal(record)
+	if err != nil {
+		// some field values can't be encoded - use their textual representation instead
+		addFields(true)
+		if data, err = json.Marshal(record); err != nil {
+			return buf, err
+		}
+	}
+	return append(buf, data...), nil
+}
+
+// gelfKey returns the key of a structured field as name of an additional GELF field, which starts with an
+// underscore and consists of letters, digits, underscores, dots and dashes. Other characters are replaced by
+// underscores. The reserved name _id is renamed to _id_.
+func gelfKey(key string) string {
+	name := []byte("_" + key)
+	for i, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '.' && c != '-' {
+			name[i] = '_'
+		}
+	}
+	if string(name) == "_id" {
+		return "_id_"
+	}
+	return string(name)
+}
+
+// gelfFrames returns the frames a GELF message is sent in to Graylog.
+// Via UDP, the GELF message is compressed with gzip and split into chunks, if it doesn't fit into one datagram.
+// Via TCP, the GELF message is terminated by a null byte, since compression isn't supported.
+func gelfFrames(network string, p []byte) ([][]byte, error) {
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	if !strings.HasPrefix(network, "udp") {
+		return [][]byte{append(append([]byte(nil), msg...), 0)}, nil
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(msg)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	data := compressed.Bytes()
+	if len(data) <= gelfChunkSize {
+		return [][]byte{data}, nil
+	}
+	count := (len(data) + gelfChunkSize - gelfHeader - 1) / (gelfChunkSize - gelfHeader)
+	if count > gelfMaxChunks {
+		return nil, ErrMessageTooLarge
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	frames := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		size := len(data)
+		if size > gelfChunkSize-gelfHeader {
+			size = gelfChunkSize - gelfHeader
+		}
+		frame := make([]byte, 0, gelfHeader+size)
+		frame = append(frame, 0x1e, 0x0f)
+		frame = append(frame, id[:]...)
+		frame = append(frame, byte(seq), byte(count))
+		frame = append(frame, data[:size]...)
+		frames = append(frames, frame)
+		data = data[size:]
+	}
+	return frames, nil
+}
@@ -2,61 +2,464 @@ package simplelog
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 )
 
 // general
 const (
-	dateTimeTag = "#"
+	dateTimeTag        = "#"
+	callerTag          = "#caller#" // placeholder for the source file and line of the caller of a simplelog function
+	hostTag            = "#host#"   // placeholder for the host name
+	pidTag             = "#pid#"    // placeholder for the process ID
+	goidTag            = "#goid#"   // placeholder for the goroutine ID of the caller of a simplelog function
+	seqTag             = "#seq#"    // placeholder for the sequence number of the log record
+	uptimeTag          = "#uptime#" // placeholder for the time elapsed since the log service was started
+	callerSkip         = 4          // number of stack frames between runtime.Callers and the caller of a simplelog function
+	stackSkip          = 4          // number of stack frames between debug.Stack and the caller of a simplelog function
+	inlineValues       = 4          // maximum number of values of a log message passed to the log service without allocation
+	priorityBufferSize = 16         // size of the channel for log messages of level ERROR or above
+	tailSize           = 1000       // number of the last log records kept for Tail
+	streamBufferSize   = 256        // size of the channel of a client of the stream handler
 )
 
 // log destinations
 const (
-	STDOUT = 1 << iota     // write the log record to stdout
-	FILE                   // write the log record to the log file
-	MULTI  = STDOUT | FILE // write the log record to stdout and to the log file
+	STDOUT  = 1 << iota     // write the log record to stdout
+	FILE                    // write the log record to the log file
+	NETWORK                 // write the log record to the network log
+	WEBHOOK                 // write the log record to the webhook log
+	STDERR                  // write the log record to stderr
+	MULTI   = STDOUT | FILE // write the log record to stdout and to the log file
+	RING    = 1 << 30       // write the log record to the in-memory ring buffer; placed after the custom log destinations
+	JOURNAL = 1 << 29       // write the log record to the systemd journal; placed after the custom log destinations
 )
 
+// allDestinations is the combination of all built-in log destination bits.
+const allDestinations = STDOUT | FILE | NETWORK | WEBHOOK | STDERR | RING | JOURNAL
+
+// custom log destinations
+const (
+	firstCustomDestination = STDERR << 1  // the log destination bit of the first registered custom log destination
+	lastCustomDestination  = JOURNAL >> 1 // the log destination bit of the last possible custom log destination
+)
+
+// log record formats
+const (
+	TEXT = iota // write the log record as plain text line
+	JSON        // write the log record as JSON object
+	GELF        // write the log record as GELF message for Graylog
+)
+
+// drop policies
+const (
+	BLOCK      = iota // block the writing goroutine while the data channel is full
+	DROPNEWEST        // drop the log message to be written while the data channel is full
+	DROPOLDEST        // drop the oldest log message in the data channel while it is full
+	SPILL             // spill the log message to a temporary overflow file while the data channel is full
+)
+
+// log levels
+const (
+	DEBUG = iota + 1 // fine-grained information for debugging purposes
+	INFO             // general information about the application flow
+	WARN             // potentially harmful situations
+	ERROR            // errors which still allow the application to continue
+	FATAL            // severe errors which will presumably lead the application to abort
+)
+
+// levelColors maps the log levels to the ANSI color codes used for colorized log records.
+var levelColors = map[int]string{
+	DEBUG: "\x1b[90m", // bright black
+	INFO:  "\x1b[32m", // green
+	WARN:  "\x1b[33m", // yellow
+	ERROR: "\x1b[31m", // red
+	FATAL: "\x1b[35m", // magenta
+}
+
+// ANSI color codes
+const (
+	prefixColor = "\x1b[36m" // cyan
+	resetColor  = "\x1b[0m"
+)
+
+// levelNames maps the log levels to their textual representation used in log records.
+var levelNames = map[int]string{
+	DEBUG: "DEBUG",
+	INFO:  "INFO",
+	WARN:  "WARN",
+	ERROR: "ERROR",
+	FATAL: "FATAL",
+}
+
 // log service tasks
 const (
 	initlog = iota
 	switchlog
 	setprefix
+	setlevel
+	setformat
+	setrotation
+	initnetworklog
+	initwebhooklog
+	registerdestination
+	setcolor
+	addhook
+	setfilter
+	setsampling
+	setratelimit
+	setdeduplication
+	synclog
+	setdurability
+	getstats
+	setbuffersize
+	reopenlog
+	setarchive
+	setarchivehook
+	setarchiveuploader
+	setredaction
+	setaudit
+	setlevelprefix
+	settimezone
+	addroute
+	openlogfile
+	initringlog
+	dumpring
+	tailrecords
+	setverbose
+	pauselog
+	seterrorhandler
+	setfallback
+	setdiskguard
+	setfilelock
+	initjournallog
+	registersink
+	resetconsole
+	settagfilter
+	setmaxrecordsize
+	setmultiline
+	setstatssummary
+	setrunheader
+	setrunfooter
+	setheartbeatrecord
 )
 
-// log service attributes
-const (
-	logbuffer       = iota // defines the buffer size of the logMessage channel
-	logfilename            // defines the log file name to be used
-	logflag                // a flag or a combination of flags which specifies how to open the log file
-	filelogprefix          // defines the prefix that is placed in front of each log line in the log file
-	stdoutlogprefix        // defines the prefix that is placed in front of each log line in stdout
+// config requests of the log service tasks, which carry the parameters and results of a config task
+type (
+	logFileRequest struct {
+		flag int    // a flag or a combination of flags which specifies how to open the log file
+		name string // the name of the log file
+	}
+	prefixRequest struct {
+		destination int      // the log destination the prefix is set for
+		level       int      // the level of the log records the prefix is used for; 0 if used for all log records
+		prefix      []string // the prefix that is placed in front of each log line of the log destination
+		items       []string // result: the items of all prefixes of the log destination
+	}
+	settingRequest struct {
+		destination int // the log destination the setting is set for
+		value       int // the value of the setting, e.g. the level or the format of log records
+	}
+	colorRequest struct {
+		destination int  // the log destination the colorization is set for
+		enabled     bool // flag to indicate whether log records are colorized
+	}
+	filterRequest struct {
+		destination int              // the log destination the filters are set for
+		include     []*regexp.Regexp // the filters of which at least one must match the text of log records
+		exclude     []*regexp.Regexp // the filters of which none must match the text of log records
+	}
+	multilineRequest struct {
+		destination int    // the log destination the multi-line mode is set for
+		mode        int    // the multi-line mode, e.g. PREFIXLINES
+		indent      string // the indent marker of the continuation lines in mode INDENTLINES
+	}
+	tagFilterRequest struct {
+		destination int             // the log destination the tag filters are set for
+		include     map[string]bool // the tags of which log records must have at least one
+		exclude     map[string]bool // the tags of which log records must have none
+	}
+	rateLimitRequest struct {
+		destination int // the log destination the rate limit is set for
+		perSecond   int // the number of log records per second; 0 if not rate limited
+		burst       int // the maximum burst of log records
+	}
+	samplingRequest struct {
+		first      int // the number of log messages per sample key which are logged
+		thereafter int // only every n-th log message per sample key is logged thereafter
+	}
+	durabilityRequest struct {
+		enabled  bool // flag to indicate whether the log file is synced to stable storage after each flush
+		syncRate int  // the number of log records after which the log file is flushed and synced
+	}
+	archiveRequest struct {
+		dir      string // the directory archived log files are moved to
+		template string // the name template of archived log files
+	}
+	auditRequest struct {
+		enabled bool   // flag to indicate whether the audit mode of the log file is enabled
+		key     []byte // the key of the HMAC of audited lines
+	}
+	redactionRequest struct {
+		patterns    []*regexp.Regexp // the patterns of sensitive data
+		replacement string           // the text which replaces sensitive data
+	}
+	uploaderRequest struct {
+		uploader    Uploader // the object storage archived log files are uploaded to; nil if not uploaded
+		removeLocal bool     // flag to indicate whether archived log files are removed after they were uploaded
+	}
+	networkRequest struct {
+		network string // the name of the network, e.g. tcp or udp
+		address string // the address of the remote host
+	}
+	destinationRequest struct {
+		name        string     // the name of the custom log destination
+		writer      io.Writer  // the io.Writer of a custom log destination
+		logName     string     // the name of the log file of a named log file
+		sink        recordSink // the record sink of a custom log destination
+		destination int        // result: the log destination bit
+	}
+	fallbackRequest struct {
+		destination int // the log destination bit whose failed writes are written to the fallback log destinations
+		fallback    int // the fallback log destinations; 0 if not used
+	}
+	diskGuardRequest struct {
+		minFree uint64 // the minimum free disk space in bytes; 0 if the disk space isn't checked
+		mode    int    // the combination of low disk space modes
+	}
+	tailRequest struct {
+		n     int      // the number of requested log records
+		lines []string // result: the requested log records
+	}
 )
 
 // a logMessage represents the log message which will be sent to the log service.
 type logMessage struct {
-	destination int   // the log destination bits, e.g. stdout, file, and so on.
-	data        []any // the payload of the log message
+	destination int               // the log destination bits, e.g. stdout, file, and so on.
+	level       int               // the log level of the log message; 0 if the message has no level
+	data        []any             // the payload of the log message
+	line        string            // the preformatted payload of the log message; used instead of data if not empty
+	format      string            // the format specifier of the payload; empty if the payload is formatted like fmt.Sprintln
+	fields      []any             // the structured fields of the log message as alternating keys and values
+	typed       []Field           // the typed structured fields of the log message while it is passed to the log service
+	caller      uintptr           // the program counter of the caller of the simplelog function; 0 if not captured
+	goid        uint64            // the goroutine ID of the caller of the simplelog function; 0 if not captured
+	stack       []byte            // the stack trace of the caller of the simplelog function; nil if not captured
+	component   string            // the name of the component which wrote the log message; empty if not written by a Child
+	tags        []string          // the tags of the log message, e.g. auth; nil if not tagged
+	seq         uint64            // the sequence number of the log record; set by the log service
+	uptime      time.Duration     // the time elapsed since the log service was started; set by the log service
+	timeout     time.Duration     // the maximum wait time while the data channel is full; 0 if the drop policy applies
+	batch       [][]any           // the payloads of a batch of log messages written contiguously; nil if not a batch
+	inline      [inlineValues]any // the payload of the log message while it is passed to the log service, if it is small enough
+	inlined     int               // the number of values stored in inline
+}
+
+// withValues returns the log message with the given values as payload.
+// Up to inlineValues values are stored in the log message itself, so that the variadic values slice of the caller
+// of a simplelog function doesn't escape to the heap. Otherwise, the values are copied.
+func (logMsg logMessage) withValues(values []any) logMessage {
+	if len(values) <= len(logMsg.inline) {
+		logMsg.inlined = copy(logMsg.inline[:], values)
+	} else {
+		logMsg.data = append([]any(nil), values...)
+	}
+	return logMsg
 }
 
+// restore restores the payload of a log message received by the log service from its inline values and appends
+// its typed structured fields to the structured fields.
+// The payload refers to the log message itself and must be copied if it is retained beyond the log message.
+func (logMsg *logMessage) restore() {
+	if logMsg.inlined > 0 {
+		logMsg.data = logMsg.inline[:logMsg.inlined]
+		logMsg.inlined = 0
+	}
+	if logMsg.typed != nil {
+		fields := make([]any, 0, len(logMsg.fields)+2*len(logMsg.typed))
+		fields = append(fields, logMsg.fields...)
+		for _, f := range logMsg.typed {
+			fields = append(fields, f.Key, f.value())
+		}
+		logMsg.fields = fields
+		logMsg.typed = nil
+	}
+}
+
+// unbatch returns the log messages of a batch, which are written to the log destination of the batch.
+// If the log message isn't a batch, the log message itself is returned.
+func (logMsg *logMessage) unbatch() []logMessage {
+	if logMsg.batch == nil {
+		return []logMessage{*logMsg}
+	}
+	msgs := make([]logMessage, len(logMsg.batch))
+	for i, values := range logMsg.batch {
+		msgs[i] = logMessage{destination: logMsg.destination, data: values, caller: logMsg.caller, goid: logMsg.goid}
+	}
+	return msgs
+}
+
+// text returns the payload of the log message formatted as text without a trailing newline.
+// The formatting is done by the log service, so that the caller of the simplelog functions isn't delayed.
+func (logMsg *logMessage) text() string {
+	return string(logMsg.appendText(nil))
+}
+
+// appendText appends the payload of the log message formatted as text without a trailing newline to the buffer
+// and returns the extended buffer. To not allocate a string, the payload is formatted in a pooled buffer.
+func (logMsg *logMessage) appendText(buf []byte) []byte {
+	if logMsg.line != "" {
+		return append(buf, strings.TrimSuffix(logMsg.line, "\n")...)
+	}
+	text := textPool.Get().(*bytes.Buffer)
+	text.Reset()
+	if logMsg.format != "" {
+		fmt.Fprintf(text, logMsg.format, logMsg.data...)
+	} else {
+		fmt.Fprintln(text, logMsg.data...)
+	}
+	buf = append(buf, bytes.TrimSuffix(text.Bytes(), []byte("\n"))...)
+	textPool.Put(text)
+	return buf
+}
+
+// textPool is a pool of buffers used to format the payload of log messages.
+var textPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
 // a configMessage represents the object which will be sent to the log service for configuration purposes.
 type configMessage struct {
-	task int         // refers to log service tasks used to trigger certain config tasks
-	data map[int]any // config data used by the config task
+	task    int // refers to log service tasks used to trigger certain config tasks
+	request any // the config request of the config task, e.g. a *settingRequest; nil if the config task has no parameters
+}
+
+// logSettings is a data collection of the settings which define how log records of a log destination are written.
+type logSettings struct {
+	prefix      []string         // prefix for each log record
+	levelPrefix map[int][]string // prefixes for log records of a certain level, which are used instead of prefix
+	level       int              // minimum level of log records
+	format      int              // format of log records, e.g. TEXT or JSON
+	color       bool             // flag to indicate whether log records are colorized
+	include     []*regexp.Regexp // filters of which at least one must match the text of log records
+	exclude     []*regexp.Regexp // filters of which none must match the text of log records
+	includeTags map[string]bool  // tags of which log records must have at least one
+	multiline   int              // the multi-line mode, i.e. how continuation lines of log records are prefixed
+	indent      string           // the indent marker of continuation lines in multi-line mode INDENTLINES
+	excludeTags map[string]bool  // tags of which log records must have none
+	limiter     *rateLimiter     // rate limiter of log records; nil if the log records aren't rate limited
+}
+
+// prefixFor returns the prefix for log records of the given level.
+func (ls *logSettings) prefixFor(level int) []string {
+	if prefix, ok := ls.levelPrefix[level]; ok {
+		return prefix
+	}
+	return ls.prefix
+}
+
+// prefixItems returns the items of the prefix and of all level prefixes.
+func (ls *logSettings) prefixItems() []string {
+	items := append([]string(nil), ls.prefix...)
+	for _, prefix := range ls.levelPrefix {
+		items = append(items, prefix...)
+	}
+	return items
 }
 
 // stdoutLogger is a data collection to support logging to stdout.
 type stdoutLogger struct {
+	writer *bufio.Writer // buffers the log records written in one pass of the log service
+	async  *asyncWriter  // writes the log records in its own worker goroutine
 	self   *logger
-	prefix []string // prefix for each stdout log record
+	logSettings
+}
+
+// stderrLogger is a data collection to support logging to stderr.
+type stderrLogger struct {
+	writer *bufio.Writer // buffers the log records written in one pass of the log service
+	async  *asyncWriter  // writes the log records in its own worker goroutine
+	self   *logger
+	logSettings
 }
 
 // fileLogger is a data collection to support logging to files.
 type fileLogger struct {
-	writer *bufio.Writer
-	desc   *os.File
+	writer           *bufio.Writer
+	async            *asyncWriter // writes the log records to the log file in its own worker goroutine
+	desc             *os.File     // the opened log file; nil if not opened, e.g. because it couldn't be reopened
+	logName          string       // the name of the log file; kept while the log file isn't opened
+	self             *logger
+	rotation         time.Duration    // interval of the time-based log file rotation; 0 if the log file isn't rotated
+	durable          bool             // flag to indicate whether the log file is synced to stable storage after each flush
+	syncRate         int              // number of log records after which the log file is flushed and synced; 0 if not used
+	unsynced         int              // number of log records written since the log file was synced
+	lastSize         int64            // size of the log file at the last check for external modifications
+	archiveDir       string           // directory archived log files are moved to; empty if archived next to the log file
+	archiveTemplate  string           // name template of archived log files; empty if the default template is used
+	archiveHook      func(string)     // called with the path of each archived log file; nil if not used
+	uploader         *archiveUploader // uploads the archived log files to an object storage; nil if not used
+	audit            auditChain       // chains the lines of the log file by HMACs in audit mode
+	lock             fileLock         // writes whole lines to the log file under an advisory lock, if enabled
+	retryWindow      *int64           // the retry window of failed writes to the log file; accessed atomically
+	compressArchives bool             // flag to indicate whether archived log files are compressed with gzip
+	header           string           // the run header written before the first log record of the log file; empty if not used
+	logSettings
+}
+
+// networkLogger is a data collection to support logging to a remote host.
+type networkLogger struct {
+	conn          net.Conn  // connection to the remote host; nil if not connected
+	network       string    // name of the network, e.g. tcp or udp
+	address       string    // address of the remote host
+	lastReconnect time.Time // point in time of the last reconnect attempt
+	backlog       [][]byte  // log records buffered while the remote host isn't reachable
+	retryWindow   *int64    // the retry window of failed writes to the remote host; accessed atomically
+	self          *logger
+	logSettings
+}
+
+// webhookLogger is a data collection to support logging to an HTTP webhook.
+type webhookLogger struct {
+	url     string                 // URL the log records are posted to
+	batch   []json.RawMessage      // log records collected for the next post
+	batches chan []json.RawMessage // to hand over batches of log records to the sender goroutine
+	sent    chan struct{}          // to signal that the sender goroutine has posted all batches
+	self    *logger
+	logSettings
+}
+
+// ringLogger is a data collection to support logging to an in-memory ring buffer.
+type ringLogger struct {
+	ring      [][]byte // the log records in the ring buffer; nil if the ring log isn't setup
+	ringNext  int      // index of the ring buffer slot for the next log record
+	ringCount int      // number of log records in the ring buffer
+	self      *logger
+	logSettings
+}
+
+// journalLogger is a data collection to support logging to the systemd journal.
+type journalLogger struct {
+	conn       *net.UnixConn // connection to the socket of journald; nil if not connected
+	identifier string        // the syslog identifier of the log records
+	buf        []byte        // the datagram of the last log record
+	logSettings
+}
+
+// customLogger is a data collection to support logging to a custom log destination.
+type customLogger struct {
+	name   string      // name of the custom log destination
+	writer io.Writer   // io.Writer the log records are written to
+	file   *fileLogger // the log file of a named log file; nil if the log records are written to writer
+	sink   recordSink  // receives the log records as structured data; nil if the log records are written to writer
 	self   *logger
-	prefix []string // prefix for each file log record
+	logSettings
 }
 
 // logWriter interface includes definitions of the following method signatures:
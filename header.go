@@ -0,0 +1,88 @@
+package simplelog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// run header placeholders
+const (
+	cmdTag     = "#cmd#"     // placeholder for the command line of the process
+	versionTag = "#version#" // placeholder for the version of the main module of the program
+)
+
+// DefaultRunHeader is a run header with the start time of the log service, the version of the program, the process
+// ID and the command line, e.g. "=== 2023-01-02 15:04:05 run started: version v1.2.3, pid 4242, ./app -v ===".
+const DefaultRunHeader = "=== #2006-01-02 15:04:05# run started: version " + versionTag + ", pid " + pidTag + ", " + cmdTag + " ==="
+
+// runFooter is the format of the run footer written to the log file when the log service is stopped.
+const runFooter = "=== run ended: %d log records written, %d dropped, %d rate limited, uptime %v ==="
+
+// headerPlaceholder matches the placeholders of a run header.
+var headerPlaceholder = regexp.MustCompile(`#[^#\n]+#`)
+
+// expandHeader returns the run header with its placeholders replaced.
+// Placeholders other than the host, pid, cmd and version placeholders are date/time layouts, which are replaced by
+// the start time of the log service.
+func (s *simpleLogService) expandHeader(header string) string {
+	started := s.started
+	if s.location != nil {
+		started = started.In(s.location)
+	}
+	return headerPlaceholder.ReplaceAllStringFunc(header, func(tag string) string {
+		switch tag {
+		case hostTag:
+			return hostname
+		case pidTag:
+			return pid
+		case cmdTag:
+			return strings.Join(os.Args, " ")
+		case versionTag:
+			return buildVersion()
+		default:
+			return started.Format(strings.Trim(tag, dateTimeTag))
+		}
+	})
+}
+
+// buildVersion returns the version of the main module of the program, e.g. v1.2.3 or (devel), as recorded in the
+// build information of the binary; "???" if it isn't recorded.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "???"
+}
+
+// writeFooter writes the run footer with the number of log records written to the log file, the number of dropped
+// and rate limited log records and the uptime of the log service to the log file, if enabled.
+// The run footer isn't counted as log record.
+func (s *simpleLogService) writeFooter() {
+	if !s.runFooter || s.desc == nil {
+		return
+	}
+	stats := s.snapshot()
+	footer := fmt.Sprintf(runFooter, stats.Written[FILE], stats.Dropped, stats.RateLimited, time.Since(s.started).Round(time.Millisecond))
+	simpleLogger(&s.fileLogger)
+	s.fileLogger.audit.Write([]byte(footer + "\n"))
+}
+
+// setRunFooter implements SetRunFooter for the log service.
+func (s *simpleLogService) setRunFooter(enabled bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setrunfooter, enabled)
+}
+
+// setRunHeader implements SetRunHeader for the log service.
+func (s *simpleLogService) setRunHeader(header string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setrunheader, header)
+}
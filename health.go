@@ -0,0 +1,116 @@
+package simplelog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// heartbeatInterval is the interval of the heartbeats of the log service.
+const heartbeatInterval = 1 * time.Second
+
+// heartbeatTimeout is the maximum time since the last heartbeat of a healthy log service.
+var heartbeatTimeout = 5 * heartbeatInterval
+
+// beat records a heartbeat of the log service.
+func (s *simpleLogService) beat() {
+	atomic.StoreInt64(&s.heartbeat, time.Now().UnixNano())
+}
+
+// lastHeartbeat implements LastHeartbeat for the log service.
+func (s *simpleLogService) lastHeartbeat() time.Time {
+	if nanos := atomic.LoadInt64(&s.heartbeat); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// healthy implements Healthy for the log service.
+func (s *simpleLogService) healthy() bool {
+	return s.isActive() && time.Since(s.lastHeartbeat()) <= heartbeatTimeout
+}
+
+// watchdogInterval is the interval in which the watchdog checks the heartbeat of the log service.
+var watchdogInterval = heartbeatInterval
+
+// WatchdogCallback is a function which is called by the watchdog if the log service missed heartbeats.
+// It gets the number of missed heartbeats and the point in time of the last heartbeat.
+type WatchdogCallback func(missed int, last time.Time)
+
+// watchdog checks the heartbeat of the log service until the log service is stopped and calls the watchdog
+// callback once per stall, i.e. if the last heartbeat is older than the heartbeat timeout.
+// It runs in a dedicated goroutine, since the goroutine of the log service may be the one which is stuck.
+func (s *simpleLogService) watchdog(stopped <-chan struct{}, interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var alerted time.Time // the last heartbeat the watchdog callback was called for
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C:
+			last := s.lastHeartbeat()
+			elapsed := time.Since(last)
+			if elapsed <= timeout || last.Equal(alerted) {
+				continue
+			}
+			alerted = last
+			s.watchdogMu.Lock()
+			callback := s.watchdogCallback
+			s.watchdogMu.Unlock()
+			if callback != nil {
+				callback(int(elapsed/heartbeatInterval), last)
+			}
+		}
+	}
+}
+
+// setWatchdogCallback implements SetWatchdogCallback for the log service.
+// The watchdog callback isn't set by the log service, since it is needed in particular if the log service is stuck.
+func (s *simpleLogService) setWatchdogCallback(callback WatchdogCallback) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	s.watchdogMu.Lock()
+	s.watchdogCallback = callback
+	s.watchdogMu.Unlock()
+	return nil
+}
+
+// defaultHeartbeatText is the text of heartbeat records if no text is specified.
+const defaultHeartbeatText = "still alive"
+
+// heartbeatRecord represents the log record which is written periodically while no other log records are written
+// to its log destination, so that log-based monitoring can distinguish a quiet application from a dead one.
+type heartbeatRecord struct {
+	destination int           // the log destination the heartbeat records are written to
+	interval    time.Duration // the interval of the heartbeat records; 0 if no heartbeat records are written
+	text        string        // the text of the heartbeat records
+	idle        bool          // flag to indicate whether no log record was written to the log destination since the last check
+}
+
+// writeHeartbeatRecord writes a heartbeat record, if no log record was written to its log destination since the
+// last check.
+func (s *simpleLogService) writeHeartbeatRecord() {
+	if s.heartbeatRecord.idle {
+		heartbeat := logMessage{destination: s.heartbeatRecord.destination, level: INFO, data: []any{s.heartbeatRecord.text}}
+		s.writeRecord(&heartbeat)
+	}
+	s.heartbeatRecord.idle = true
+}
+
+// setHeartbeatRecord implements SetHeartbeatRecord for the log service.
+func (s *simpleLogService) setHeartbeatRecord(destination int, interval time.Duration, text string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if interval < 0 {
+		return ErrInvalidInterval
+	}
+	if interval > 0 && !s.isDestinations(destination) {
+		return ErrUnknownDestination
+	}
+	if text == "" {
+		text = defaultHeartbeatText
+	}
+	return s.configure(setheartbeatrecord, &heartbeatRecord{destination: destination, interval: interval, text: text})
+}
@@ -0,0 +1,67 @@
+package simplelog
+
+// Record represents a log record which is passed to hooks before it is written to its log destinations.
+type Record struct {
+	Destination int      // the log destination bits, e.g. STDOUT | FILE
+	Level       int      // the log level; 0 if the log record has no level
+	Values      []any    // the values that are logged
+	Format      string   // the format specifier of the values; empty if the values are formatted like fmt.Sprintln
+	Fields      []any    // the structured fields as alternating keys and values
+	Tags        []string // the tags, e.g. auth; nil if the log record isn't tagged
+}
+
+// Text returns the values of the log record formatted as text, as they are written to the log destinations.
+func (r *Record) Text() string {
+	logMsg := logMessage{data: r.Values, format: r.Format}
+	return logMsg.text()
+}
+
+// Hook represents a function which is called for each log record before it is written.
+// A hook can return the log record as it is, a modified log record or nil to drop the log record.
+type Hook func(*Record) *Record
+
+// newRecord returns the log message as Record.
+func newRecord(logMsg *logMessage) *Record {
+	values := append([]any(nil), logMsg.data...) // the receiver may retain the values beyond the log message
+	if logMsg.line != "" {
+		values = []any{logMsg.line}
+	}
+	return &Record{
+		Destination: logMsg.destination,
+		Level:       logMsg.level,
+		Values:      values,
+		Format:      logMsg.format,
+		Fields:      logMsg.fields,
+		Tags:        logMsg.tags,
+	}
+}
+
+// runHooks passes the log message as Record through all hooks and applies the result to the log message.
+// It returns false, if a hook dropped the log message, true otherwise.
+func (s *simpleLogService) runHooks(logMsg *logMessage) bool {
+	if len(s.hooks) == 0 {
+		return true
+	}
+	rec := newRecord(logMsg)
+	for _, hook := range s.hooks {
+		if rec = hook(rec); rec == nil {
+			return false
+		}
+	}
+	logMsg.destination = rec.Destination
+	logMsg.level = rec.Level
+	logMsg.data = rec.Values
+	logMsg.line = ""
+	logMsg.format = rec.Format
+	logMsg.fields = rec.Fields
+	logMsg.tags = rec.Tags
+	return true
+}
+
+// addHook implements AddHook for the log service.
+func (s *simpleLogService) addHook(hook Hook) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(addhook, hook)
+}
@@ -0,0 +1,744 @@
+package simplelog
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+// Logger represents an independent log service.
+// Each Logger has its own log message buffer, log file and prefixes, which makes it possible
+// to run multiple log services in one process, e.g. one per library. A Logger is created by New
+// and can be used simultaneously from multiple goroutines.
+type Logger struct {
+	service *simpleLogService // the log service of the Logger
+}
+
+// Option represents a configuration option which is applied when a Logger is created by New.
+type Option func(*options) error
+
+// options is a data collection of all configuration values which can be set by options.
+type options struct {
+	bufferSize int                  // number of log messages which can be buffered before the log service blocks
+	settings   map[int]*logSettings // settings of the log destinations, e.g. prefix, level and format
+	rotation   time.Duration        // interval of the time-based log file rotation
+}
+
+// destinationSettings returns the settings of a single log destination, e.g. STDOUT or FILE.
+// ErrUnknownDestination is returned if the destination is unknown or a combination of log destinations.
+func (o *options) destinationSettings(destination int) (*logSettings, error) {
+	switch destination {
+	case STDOUT, STDERR, FILE, NETWORK, WEBHOOK, RING, JOURNAL:
+	default:
+		return nil, ErrUnknownDestination
+	}
+	if o.settings[destination] == nil {
+		o.settings[destination] = new(logSettings)
+	}
+	return o.settings[destination], nil
+}
+
+// WithBufferSize sets the number of log messages which can be buffered before the log service blocks.
+func WithBufferSize(bufferSize int) Option {
+	return func(o *options) error {
+		o.bufferSize = bufferSize
+		return nil
+	}
+}
+
+// WithPrefix sets the prefix for log records of the given destination, e.g. STDOUT or FILE.
+// See SetPrefix for the supported date and time placeholders.
+func WithPrefix(destination int, prefix ...string) Option {
+	return func(o *options) error {
+		settings, err := o.destinationSettings(destination)
+		if err != nil {
+			return err
+		}
+		settings.prefix = prefix
+		return nil
+	}
+}
+
+// WithLevel sets the minimum log level for log records of the given destination, e.g. STDOUT or FILE.
+func WithLevel(destination int, level int) Option {
+	return func(o *options) error {
+		if _, ok := levelNames[level]; !ok {
+			return ErrUnknownLevel
+		}
+		settings, err := o.destinationSettings(destination)
+		if err != nil {
+			return err
+		}
+		settings.level = level
+		return nil
+	}
+}
+
+// WithFormat sets the format of log records of the given destination, e.g. STDOUT or FILE.
+func WithFormat(destination int, format int) Option {
+	return func(o *options) error {
+		if format != TEXT && format != JSON && format != GELF {
+			return ErrUnknownFormat
+		}
+		settings, err := o.destinationSettings(destination)
+		if err != nil {
+			return err
+		}
+		settings.format = format
+		return nil
+	}
+}
+
+// WithColor enables or disables colorized log records of the given destination, e.g. STDOUT or STDERR.
+// See SetColor for details.
+func WithColor(destination int, enabled bool) Option {
+	return func(o *options) error {
+		if destination != STDOUT && destination != STDERR {
+			return ErrUnknownDestination
+		}
+		settings, err := o.destinationSettings(destination)
+		if err != nil {
+			return err
+		}
+		settings.color = enabled
+		return nil
+	}
+}
+
+// WithRotation sets the interval of the time-based log file rotation.
+// See SetRotation for details.
+func WithRotation(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval < 0 {
+			return ErrInvalidInterval
+		}
+		o.rotation = interval
+		return nil
+	}
+}
+
+// New creates a new Logger and starts its log service.
+// The log service runs in its own goroutine and has to be stopped by calling Shutdown.
+// The opts parameter specifies options to configure the Logger, e.g. WithBufferSize.
+// An error is returned if an option is invalid.
+func New(opts ...Option) (*Logger, error) {
+	o := options{bufferSize: 1, settings: make(map[int]*logSettings)}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	service := new(simpleLogService)
+	for destination, settings := range o.settings {
+		*service.settings(destination) = *settings
+		service.setPrefixPlaceholders(destination, settings.prefix)
+	}
+	service.rotation = o.rotation
+	if err := service.startup(o.bufferSize); err != nil {
+		return nil, err
+	}
+
+	return &Logger{service: service}, nil
+}
+
+// SetPrefix sets the prefix for log records of the Logger.
+// See SetPrefix for details.
+func (l *Logger) SetPrefix(destination int, prefix ...string) error {
+	return l.service.setPrefix(destination, prefix...)
+}
+
+// SetPrefixForLevel sets the prefix for log records of a certain level of the Logger.
+// See SetPrefixForLevel for details.
+func (l *Logger) SetPrefixForLevel(destination, level int, prefix ...string) error {
+	return l.service.setPrefixForLevel(destination, level, prefix...)
+}
+
+// SetLevel sets the minimum log level for log records of the Logger written by Log.
+// See SetLevel for details.
+func (l *Logger) SetLevel(destination int, level int) error {
+	return l.service.setLevel(destination, level)
+}
+
+// SetFormat sets the format of log records of the Logger.
+// See SetFormat for details.
+func (l *Logger) SetFormat(destination int, format int) error {
+	return l.service.setFormat(destination, format)
+}
+
+// SetRotation sets the interval of the time-based log file rotation of the Logger.
+// See SetRotation for details.
+func (l *Logger) SetRotation(interval time.Duration) error {
+	return l.service.setRotation(interval)
+}
+
+// SetAudit enables or disables the tamper-evident audit mode of the log file of the Logger.
+// See SetAudit for details.
+func (l *Logger) SetAudit(enabled bool, key []byte) error {
+	return l.service.setAudit(enabled, key)
+}
+
+// AddRoute adds a routing rule, which writes matching log records of the Logger to a separate log file.
+// See AddRoute for details.
+func (l *Logger) AddRoute(route Route) error {
+	return l.service.addRoute(route)
+}
+
+// SetArchive sets where and under which name archived log files of the Logger are stored.
+// See SetArchive for details.
+func (l *Logger) SetArchive(dir, template string) error {
+	return l.service.setArchive(dir, template)
+}
+
+// SetArchiveHook sets a function which is called each time a log file of the Logger was archived.
+// See SetArchiveHook for details.
+func (l *Logger) SetArchiveHook(hook func(archivedPath string)) error {
+	return l.service.setArchiveHook(hook)
+}
+
+// SetArchiveUploader sets an object storage which archived log files of the Logger are uploaded to.
+// See SetArchiveUploader for details.
+func (l *Logger) SetArchiveUploader(u Uploader, removeLocal bool) error {
+	return l.service.setArchiveUploader(u, removeLocal)
+}
+
+// SetTimeZone sets the time zone of the timestamps of log records of the Logger.
+// See SetTimeZone for details.
+func (l *Logger) SetTimeZone(loc *time.Location) error {
+	return l.service.setTimeZone(loc)
+}
+
+// SetDurability sets the durable mode of the log file of the Logger.
+// See SetDurability for details.
+func (l *Logger) SetDurability(enabled bool, records int) error {
+	return l.service.setDurability(enabled, records)
+}
+
+// SetColor enables or disables colorized log records of the Logger.
+// See SetColor for details.
+func (l *Logger) SetColor(destination int, enabled bool) error {
+	return l.service.setColor(destination, enabled)
+}
+
+// SetFilter sets regular expression filters for log records of a log destination of the Logger.
+// See SetFilter for details.
+func (l *Logger) SetFilter(destination int, include, exclude []string) error {
+	return l.service.setFilter(destination, include, exclude)
+}
+
+// SetMultiline sets how the continuation lines of multi-line log records of a log destination of the Logger are
+// written. See SetMultiline for details.
+func (l *Logger) SetMultiline(destination, mode int, indent string) error {
+	return l.service.setMultiline(destination, mode, indent)
+}
+
+// SetMaxRecordSize sets the maximum size of the text of log messages of the Logger.
+// See SetMaxRecordSize for details.
+func (l *Logger) SetMaxRecordSize(size int) error {
+	return l.service.setMaxRecordSize(size)
+}
+
+// SetTagFilter sets tag filters for log records of a log destination of the Logger.
+// See SetTagFilter for details.
+func (l *Logger) SetTagFilter(destination int, include, exclude []string) error {
+	return l.service.setTagFilter(destination, include, exclude)
+}
+
+// SetSampling sets the sampling of log messages of the Logger.
+// See SetSampling for details.
+func (l *Logger) SetSampling(first, thereafter int) error {
+	return l.service.setSampling(first, thereafter)
+}
+
+// SetRateLimit limits the number of log records written to a log destination of the Logger.
+// See SetRateLimit for details.
+func (l *Logger) SetRateLimit(destination, perSecond, burst int) error {
+	return l.service.setRateLimit(destination, perSecond, burst)
+}
+
+// SetRedaction sets patterns of sensitive data which are replaced in log records of the Logger.
+// See SetRedaction for details.
+func (l *Logger) SetRedaction(patterns []string, replacement string) error {
+	return l.service.setRedaction(patterns, replacement)
+}
+
+// SetDeduplication enables or disables the suppression of consecutive identical log messages of the Logger.
+// See SetDeduplication for details.
+func (l *Logger) SetDeduplication(enabled bool) error {
+	return l.service.setDeduplication(enabled)
+}
+
+// SetDropPolicy sets the policy applied to log messages of the Logger while its data channel is full.
+// See SetDropPolicy for details.
+func (l *Logger) SetDropPolicy(policy int) error {
+	return l.service.setDropPolicy(policy)
+}
+
+// AddHook adds a hook which is called for each log record of the Logger before it is written.
+// See AddHook for details.
+func (l *Logger) AddHook(hook Hook) error {
+	return l.service.addHook(hook)
+}
+
+// Shutdown stops the log service of the Logger including post-processing and cleanup.
+// See Shutdown for details.
+func (l *Logger) Shutdown(archivelog bool) error {
+	return l.service.shutdown(archivelog)
+}
+
+// SetBufferSize changes the size of the data channel of the log service of the Logger.
+// See SetBufferSize for details.
+func (l *Logger) SetBufferSize(bufferSize int) error {
+	return l.service.setBufferSize(bufferSize)
+}
+
+// ConfigFromEnv configures the log service of the Logger from environment variables.
+// See ConfigFromEnv for details.
+func (l *Logger) ConfigFromEnv() (int, error) {
+	return l.service.configFromEnv()
+}
+
+// LoadConfig configures the log service of the Logger from a JSON configuration file.
+// See LoadConfig for details.
+func (l *Logger) LoadConfig(path string) error {
+	return l.service.loadConfig(path)
+}
+
+// ShutdownContext stops the log service of the Logger, but gives up when the context expires.
+// See ShutdownContext for details.
+func (l *Logger) ShutdownContext(ctx context.Context, archivelog bool) (int, error) {
+	return l.service.shutdownContext(ctx, archivelog)
+}
+
+// SetRunHeader sets the run header written as first line to the log file of the Logger.
+// See SetRunHeader for details.
+func (l *Logger) SetRunHeader(header string) error {
+	return l.service.setRunHeader(header)
+}
+
+// SetRunFooter enables or disables the run footer written as last line to the log file of the Logger.
+// See SetRunFooter for details.
+func (l *Logger) SetRunFooter(enabled bool) error {
+	return l.service.setRunFooter(enabled)
+}
+
+// SetupLog opens and initially creates the log file of the Logger.
+// See SetupLog for details.
+func (l *Logger) SetupLog(logName string, appendlog bool) error {
+	return l.service.setupLog(logName, appendlog)
+}
+
+// SetupNetworkLog connects the Logger to a remote host which receives the network log.
+// See SetupNetworkLog for details.
+func (l *Logger) SetupNetworkLog(network, address string) error {
+	return l.service.setupNetworkLog(network, address)
+}
+
+// SetupWebhookLog sets the URL of an HTTP webhook which receives the webhook log of the Logger.
+// See SetupWebhookLog for details.
+func (l *Logger) SetupWebhookLog(url string) error {
+	return l.service.setupWebhookLog(url)
+}
+
+// SetupJournalLog connects the JOURNAL destination of the Logger to the systemd journal.
+// See SetupJournalLog for details.
+func (l *Logger) SetupJournalLog() error {
+	return l.service.setupJournalLog()
+}
+
+// SetupRingLog sets up the ring log of the Logger.
+// See SetupRingLog for details.
+func (l *Logger) SetupRingLog(size int) error {
+	return l.service.setupRingLog(size)
+}
+
+// DumpRing writes the log records buffered by the ring log of the Logger to its log file.
+// See DumpRing for details.
+func (l *Logger) DumpRing() error {
+	return l.service.dumpRingLog()
+}
+
+// Tail returns the last n log records written by the Logger.
+// See Tail for details.
+func (l *Logger) Tail(n int) []string {
+	return l.service.tailRecords(n)
+}
+
+// Subscribe returns a channel, which receives each log record written by the Logger.
+// See Subscribe for details.
+func (l *Logger) Subscribe(bufferSize int) (<-chan string, func(), error) {
+	return l.service.subscribe(bufferSize)
+}
+
+// StreamHandler returns an HTTP handler, which streams the log records written by the Logger to its clients.
+// See StreamHandler for details.
+func (l *Logger) StreamHandler() http.Handler {
+	return l.service.streamHandler()
+}
+
+// RegisterDestination registers a custom log destination of the Logger, which writes log records to an io.Writer.
+// See RegisterDestination for details.
+func (l *Logger) RegisterDestination(name string, w io.Writer) (int, error) {
+	return l.service.registerDestination(name, w)
+}
+
+// RegisterFluentDestination registers a custom log destination of the Logger, which sends the log records to a
+// Fluent server. See RegisterFluentDestination for details.
+func (l *Logger) RegisterFluentDestination(name, address, tag string) (int, error) {
+	return l.service.registerFluentDestination(name, address, tag)
+}
+
+// RegisterOTLPDestination registers a custom log destination of the Logger, which exports the log records to an
+// OpenTelemetry collector. See RegisterOTLPDestination for details.
+func (l *Logger) RegisterOTLPDestination(name, endpoint, serviceName string) (int, error) {
+	return l.service.registerOTLPDestination(name, endpoint, serviceName)
+}
+
+// SetTraceExtractor sets a function which extracts the trace context from the context passed to WriteCtx of the
+// Logger. See SetTraceExtractor for details.
+func (l *Logger) SetTraceExtractor(extractor TraceExtractor) error {
+	return l.service.setTraceExtractor(extractor)
+}
+
+// RegisterPipeDestination registers a custom log destination of the Logger, which writes the log records to a Unix
+// domain socket or a named pipe. See RegisterPipeDestination for details.
+func (l *Logger) RegisterPipeDestination(name, path string) (int, error) {
+	return l.service.registerPipeDestination(name, path)
+}
+
+// RegisterKafkaDestination registers a custom log destination of the Logger, which produces the log records to a
+// Kafka topic. See RegisterKafkaDestination for details.
+func (l *Logger) RegisterKafkaDestination(name string, p Producer, topic, keyField string, onError DeliveryErrorCallback) (int, error) {
+	return l.service.registerKafkaDestination(name, p, topic, keyField, onError)
+}
+
+// OpenLogFile opens a named log file of the Logger and registers it as custom log destination.
+// See OpenLogFile for details.
+func (l *Logger) OpenLogFile(name, logName string) (int, error) {
+	return l.service.openLogFile(name, logName)
+}
+
+// Named returns the log destination bit of a named log file or a custom log destination of the Logger.
+// See Named for details.
+func (l *Logger) Named(name string) int {
+	return l.service.named(name)
+}
+
+// SwitchLog closes the current log file of the Logger and a new log file with the specified name is created and used.
+// See SwitchLog for details.
+func (l *Logger) SwitchLog(newLogName string) error {
+	return l.service.switchLog(newLogName)
+}
+
+// SwitchLogAppend closes the current log file of the Logger and switches to the log file with the specified name,
+// which is appended to. See SwitchLogAppend for details.
+func (l *Logger) SwitchLogAppend(logName string) error {
+	return l.service.switchLogAppend(logName)
+}
+
+// Reopen closes and reopens the log file of the Logger.
+// See Reopen for details.
+func (l *Logger) Reopen() error {
+	return l.service.reopen()
+}
+
+// ReopenOnSignal installs a signal handler, which reopens the log file of the Logger on SIGHUP.
+// See ReopenOnSignal for details.
+func (l *Logger) ReopenOnSignal() error {
+	return l.service.reopenOnSignal()
+}
+
+// Pause pauses writing log records of the Logger.
+// See Pause for details.
+func (l *Logger) Pause() error {
+	return l.service.pause()
+}
+
+// Resume resumes writing log records of the Logger.
+// See Resume for details.
+func (l *Logger) Resume() error {
+	return l.service.resume()
+}
+
+// SetVerbose sets the minimum level of all log destinations of the Logger to DEBUG or restores their levels.
+// See SetVerbose for details.
+func (l *Logger) SetVerbose(verbose bool) error {
+	return l.service.setVerbose(verbose)
+}
+
+// VerboseOnSignal installs a signal handler, which toggles the verbosity of the Logger on SIGUSR1 and SIGUSR2.
+// See VerboseOnSignal for details.
+func (l *Logger) VerboseOnSignal() error {
+	return l.service.verboseOnSignal()
+}
+
+// Write writes a log message to a specified destination of the Logger.
+// See Write for details.
+func (l *Logger) Write(destination int, values ...any) error {
+	return l.service.write(destination, values...)
+}
+
+// ConditionalWrite writes or doesn't write a log message to a specified destination of the Logger based on a condition.
+// See ConditionalWrite for details.
+func (l *Logger) ConditionalWrite(condition bool, destination int, values ...any) error {
+	return l.service.conditionalWrite(condition, destination, values...)
+}
+
+// Writef writes a log message formatted according to a format specifier to a specified destination of the Logger.
+// See Writef for details.
+func (l *Logger) Writef(destination int, format string, values ...any) error {
+	return l.service.writef(destination, format, values...)
+}
+
+// WriteIfError writes a log message of level ERROR, followed by an error, to a specified destination of the Logger,
+// if the error isn't nil. See WriteIfError for details.
+func (l *Logger) WriteIfError(destination int, err error, values ...any) error {
+	return l.service.writeIfError(destination, err, values...)
+}
+
+// WriteUnless writes a log message to a specified destination of the Logger, unless a condition is true.
+// See WriteUnless for details.
+func (l *Logger) WriteUnless(condition bool, destination int, values ...any) error {
+	return l.service.conditionalWrite(!condition, destination, values...)
+}
+
+// ConditionalWritef writes or doesn't write a formatted log message to a specified destination of the Logger
+// based on a condition.
+// See ConditionalWritef for details.
+func (l *Logger) ConditionalWritef(condition bool, destination int, format string, values ...any) error {
+	return l.service.conditionalWritef(condition, destination, format, values...)
+}
+
+// WriteString writes a preformatted line to a specified destination of the Logger.
+// See WriteString for details.
+func (l *Logger) WriteString(destination int, line string) error {
+	return l.service.writeString(destination, line)
+}
+
+// WriteBytes writes a preformatted line to a specified destination of the Logger.
+// See WriteBytes for details.
+func (l *Logger) WriteBytes(destination int, line []byte) error {
+	return l.service.writeString(destination, string(line))
+}
+
+// WriteBatch writes a batch of log messages contiguously to a specified destination of the Logger.
+// See WriteBatch for details.
+func (l *Logger) WriteBatch(destination int, records [][]any) error {
+	return l.service.writeBatch(destination, records)
+}
+
+// TryWrite writes a log message to a specified destination of the Logger without waiting for a full data channel.
+// See TryWrite for details.
+func (l *Logger) TryWrite(destination int, values ...any) bool {
+	return l.service.tryWrite(destination, values...)
+}
+
+// WriteTimeout writes a log message to a specified destination of the Logger, waiting at most the timeout.
+// See WriteTimeout for details.
+func (l *Logger) WriteTimeout(timeout time.Duration, destination int, values ...any) error {
+	return l.service.writeTimeout(timeout, destination, values...)
+}
+
+// WriteSync writes a log message to a specified destination of the Logger and waits until it is flushed.
+// See WriteSync for details.
+func (l *Logger) WriteSync(destination int, values ...any) error {
+	return l.service.writeSync(destination, values...)
+}
+
+// WriteError writes an error with its chain of wrapped errors as log message of level ERROR to a specified
+// destination of the Logger. See WriteError for details.
+func (l *Logger) WriteError(destination int, err error) error {
+	return l.service.writeError(destination, err)
+}
+
+// WriteWithStack writes a log message followed by the stack trace of the calling goroutine to a specified
+// destination of the Logger. See WriteWithStack for details.
+func (l *Logger) WriteWithStack(destination int, values ...any) error {
+	return l.service.writeWithStack(destination, values...)
+}
+
+// StartTimer starts a Timer, which writes the duration of an operation to the Logger.
+// See StartTimer for details.
+func (l *Logger) StartTimer(name string) *Timer {
+	return l.service.startTimer(name)
+}
+
+// Derive returns a Child for a component of the application, which writes to the Logger.
+// See Derive for details.
+func (l *Logger) Derive(component string, keysAndValues ...any) *Child {
+	return l.service.derive(component, nil, keysAndValues)
+}
+
+// GetLogger returns the Child of the named component, which writes to the Logger.
+// See GetLogger for details.
+func (l *Logger) GetLogger(name string) *Child {
+	return l.service.getLogger(name)
+}
+
+// SetComponentLevel sets the minimum level of log records written by the named component of the Logger.
+// See SetComponentLevel for details.
+func (l *Logger) SetComponentLevel(name string, level int) error {
+	return l.service.setComponentLevel(name, level)
+}
+
+// SetComponentDestinations restricts the log destinations of the named component of the Logger.
+// See SetComponentDestinations for details.
+func (l *Logger) SetComponentDestinations(name string, destinations int) error {
+	return l.service.setComponentDestinations(name, destinations)
+}
+
+// WriteKV writes a log message with structured fields to a specified destination of the Logger.
+// See WriteKV for details.
+func (l *Logger) WriteKV(destination int, msg string, keysAndValues ...any) error {
+	return l.service.writeKV(destination, msg, keysAndValues...)
+}
+
+// WriteFields writes a log message with typed structured fields to a specified destination of the Logger.
+// See WriteFields for details.
+func (l *Logger) WriteFields(destination int, msg string, fields ...Field) error {
+	return l.service.writeFields(destination, msg, fields...)
+}
+
+// WriteTagged writes a log message with tags to a specified destination of the Logger.
+// See WriteTagged for details.
+func (l *Logger) WriteTagged(destination int, tags []string, values ...any) error {
+	return l.service.writeTagged(destination, tags, values...)
+}
+
+// WriteCtx writes a log message with the structured fields carried by the context ctx to a specified
+// destination of the Logger.
+// See WriteCtx for details.
+func (l *Logger) WriteCtx(ctx context.Context, destination int, values ...any) error {
+	return l.service.writeCtx(ctx, destination, values...)
+}
+
+// Log writes a log message with a log level to a specified destination of the Logger.
+// See Log for details.
+func (l *Logger) Log(level int, destination int, values ...any) error {
+	return l.service.log(level, destination, values...)
+}
+
+// Fatal writes a log record of level FATAL to a log destination of the Logger and terminates the program.
+// See Fatal for details.
+func (l *Logger) Fatal(destination int, values ...any) {
+	l.service.fatal(destination, values...)
+}
+
+// Panicw writes a log record of level FATAL to a log destination of the Logger and panics.
+// See Panicw for details.
+func (l *Logger) Panicw(destination int, values ...any) {
+	l.service.panicw(destination, values...)
+}
+
+// RecoverAndLog recovers a panic and writes it to a log destination of the Logger.
+// See RecoverAndLog for details.
+func (l *Logger) RecoverAndLog(destination int, repanic bool) {
+	if r := recover(); r != nil {
+		l.service.logPanic(destination, r, repanic)
+	}
+}
+
+// Healthy returns true, if the log service of the Logger is running and isn't stuck.
+// See Healthy for details.
+func (l *Logger) Healthy() bool {
+	return l.service.healthy()
+}
+
+// LastHeartbeat returns the point in time of the last heartbeat of the log service of the Logger.
+// See LastHeartbeat for details.
+func (l *Logger) LastHeartbeat() time.Time {
+	return l.service.lastHeartbeat()
+}
+
+// SetHeartbeatRecord sets a heartbeat record, which is written to a log destination of the Logger while no other
+// log records are written to it. See SetHeartbeatRecord for details.
+func (l *Logger) SetHeartbeatRecord(destination int, interval time.Duration, text string) error {
+	return l.service.setHeartbeatRecord(destination, interval, text)
+}
+
+// SetWatchdogCallback sets a function which is called if the log service of the Logger missed heartbeats.
+// See SetWatchdogCallback for details.
+func (l *Logger) SetWatchdogCallback(callback WatchdogCallback) error {
+	return l.service.setWatchdogCallback(callback)
+}
+
+// InternalErrors returns the last internal errors of the log service of the Logger.
+// See InternalErrors for details.
+func (l *Logger) InternalErrors() []InternalError {
+	return l.service.getInternalErrors()
+}
+
+// SetErrorHandler sets a function which is called for each log record which couldn't be written by the Logger.
+// See SetErrorHandler for details.
+func (l *Logger) SetErrorHandler(handler ErrorHandler) error {
+	return l.service.setErrorHandler(handler)
+}
+
+// SetFallback sets the fallback log destinations of a log destination of the Logger.
+// See SetFallback for details.
+func (l *Logger) SetFallback(destination, fallback int) error {
+	return l.service.setFallback(destination, fallback)
+}
+
+// SetRetry sets the time window in which transient write failures of the Logger are retried.
+// See SetRetry for details.
+func (l *Logger) SetRetry(window time.Duration) error {
+	return l.service.setRetry(window)
+}
+
+// SetDiskGuard sets the minimum free disk space of the file system of the log file of the Logger and the low
+// disk space mode. See SetDiskGuard for details.
+func (l *Logger) SetDiskGuard(minFree uint64, mode int) error {
+	return l.service.setDiskGuard(minFree, mode)
+}
+
+// SetFileLock enables or disables the locking of the log file of the Logger.
+// See SetFileLock for details.
+func (l *Logger) SetFileLock(enabled bool) error {
+	return l.service.setFileLock(enabled)
+}
+
+// SetStatsSummary sets the periodic summary of the metrics of the log service of the Logger.
+// See SetStatsSummary for details.
+func (l *Logger) SetStatsSummary(destination int, interval time.Duration) error {
+	return l.service.setStatsSummary(destination, interval)
+}
+
+// Stats returns the metrics of the log service of the Logger.
+// See Stats for details.
+func (l *Logger) Stats() (ServiceStats, error) {
+	return l.service.getStats()
+}
+
+// Writer returns an io.Writer which writes data as log messages to a specified destination of the Logger.
+// See Writer for details.
+func (l *Logger) Writer(destination int) io.Writer {
+	return newDestinationWriter(l.service, destination)
+}
+
+// CaptureStdLog redirects the output of the standard library log package to a specified destination of the
+// Logger. See CaptureStdLog for details.
+func (l *Logger) CaptureStdLog(destination int) error {
+	return l.service.captureStdLog(destination)
+}
+
+// CaptureOutput redirects the file descriptors of stdout and stderr of the process to a specified destination of
+// the Logger. See CaptureOutput for details.
+func (l *Logger) CaptureOutput(destination int) error {
+	return l.service.captureOutput(destination)
+}
+
+// LogCmd starts a command, whose stdout and stderr are written to a specified destination of the Logger.
+// See LogCmd for details.
+func (l *Logger) LogCmd(cmd *exec.Cmd, destination int, prefix string) error {
+	return l.service.logCmd(cmd, destination, prefix)
+}
+
+// StdLogger returns a *log.Logger of the standard library which writes its output as log messages to a specified
+// destination of the Logger. See StdLogger for details.
+func (l *Logger) StdLogger(destination int, prefix string) *log.Logger {
+	return newStdLogger(l.service, destination, prefix)
+}
@@ -0,0 +1,108 @@
+package simplelog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// journalSocket is the path of the socket of the native journald protocol.
+// It is a variable, so that it can be replaced in tests.
+var journalSocket = "/run/systemd/journal/socket"
+
+// setupJournal connects to the socket of journald.
+// The syslog identifier of the log records is the name of the executable.
+func (j *journalLogger) setupJournal(path string) error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	j.releaseJournalLogger()
+	j.conn = conn
+	j.identifier = filepath.Base(os.Args[0])
+	return nil
+}
+
+// releaseJournalLogger releases all journalLogger resources.
+func (j *journalLogger) releaseJournalLogger() error {
+	var err error
+	if j.conn != nil {
+		err = j.conn.Close()
+	}
+	j.conn = nil
+	return err
+}
+
+// send sends a log message as one datagram of the native journald protocol to journald.
+// Besides the MESSAGE, the PRIORITY and the SYSLOG_IDENTIFIER, the component of a Child is sent as COMPONENT
+// and each structured field as a journal field with the upper-cased key. It returns the size of the datagram.
+func (j *journalLogger) send(logMsg *logMessage) (int, error) {
+	j.buf = j.buf[:0]
+	j.buf = appendJournalField(j.buf, "MESSAGE", logMsg.text())
+	j.buf = appendJournalField(j.buf, "PRIORITY", strconv.Itoa(severity(logMsg.level)))
+	j.buf = appendJournalField(j.buf, "SYSLOG_IDENTIFIER", j.identifier)
+	if logMsg.component != "" {
+		j.buf = appendJournalField(j.buf, "COMPONENT", logMsg.component)
+	}
+	for i := 0; i+1 < len(logMsg.fields); i += 2 {
+		if key := journalKey(fmt.Sprint(logMsg.fields[i])); key != "" {
+			j.buf = appendJournalField(j.buf, key, fmt.Sprint(logMsg.fields[i+1]))
+		}
+	}
+	return j.conn.Write(j.buf)
+}
+
+// appendJournalField appends a field in the format of the native journald protocol to the buffer and returns the
+// extended buffer. Values with line breaks are appended with their size as binary little-endian number.
+func appendJournalField(buf []byte, key, value string) []byte {
+	buf = append(buf, key...)
+	if !strings.Contains(value, "\n") {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf = append(buf, size[:]...)
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+// journalKey returns the key of a structured field as journal field name, which consists of upper-case letters,
+// digits and underscores and doesn't start with an underscore or a digit. Other characters are replaced by
+// underscores. An empty string is returned, if the key can't be used as journal field name.
+func journalKey(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	return strings.TrimLeft(string(name), "_0123456789")
+}
+
+// writeJournal writes a log message to journald and counts the written log record.
+func (s *simpleLogService) writeJournal(logMsg *logMessage) {
+	n, err := s.journalLogger.send(logMsg)
+	s.stats.count(JOURNAL, n)
+	if err != nil {
+		s.writeFailed(JOURNAL, err, logMsg)
+	}
+}
+
+// setupJournalLog implements SetupJournalLog for the log service.
+func (s *simpleLogService) setupJournalLog() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if err := s.configure(initjournallog, journalSocket); err != nil {
+		return wrapError(ErrConnect, err)
+	}
+	s.setJournalLog(true)
+	return nil
+}
@@ -0,0 +1,130 @@
+package simplelog
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Kafka destination settings
+const (
+	kafkaBatchSize = 100              // maximum number of log records produced at once
+	kafkaQueueSize = 16               // maximum number of batches waiting to be produced
+	kafkaTimeout   = 10 * time.Second // maximum time to wait until a batch is produced
+)
+
+// ProducerMessage represents a log record which is produced to a Kafka topic.
+type ProducerMessage struct {
+	Key   []byte    // the partition key; nil if the log record has no key field
+	Value []byte    // the log record as JSON object, as written in JSON format
+	Time  time.Time // the point in time the log record was written
+}
+
+// Producer represents a Kafka producer, which log records are produced to as event stream.
+// Implementations wrap the producer of the respective Kafka client library.
+type Producer interface {
+	// Produce sends the messages to the topic and returns when they were delivered.
+	Produce(ctx context.Context, topic string, messages []ProducerMessage) error
+}
+
+// DeliveryErrorCallback represents a function which is called with the error and the messages of a batch of log
+// records which couldn't be delivered to the Kafka topic.
+type DeliveryErrorCallback func(err error, messages []ProducerMessage)
+
+// kafkaSink is a record sink which produces the log records in batches to a Kafka topic.
+// The batches are produced in a dedicated goroutine, so that a slow Kafka cluster doesn't stall the log service.
+type kafkaSink struct {
+	keyField string                 // the key of the structured field used as partition key; empty if not used
+	onError  DeliveryErrorCallback  // called with the batches which couldn't be delivered; nil if not set
+	batch    []ProducerMessage      // the log records which are produced next
+	batches  chan []ProducerMessage // the batches handed over to the producer goroutine
+	done     chan struct{}          // closed when the producer goroutine has exited
+}
+
+// newKafkaSink returns a kafkaSink and starts its producer goroutine.
+func newKafkaSink(p Producer, topic, keyField string, onError DeliveryErrorCallback) *kafkaSink {
+	k := &kafkaSink{
+		keyField: keyField,
+		onError:  onError,
+		batches:  make(chan []ProducerMessage, kafkaQueueSize),
+		done:     make(chan struct{}),
+	}
+	go produceBatches(p, topic, onError, k.batches, k.done)
+	return k
+}
+
+// writeRecord adds the log message as JSON object to the batch of log records which are produced next.
+// The value of the key field, if the log message has one, is used as partition key.
+func (k *kafkaSink) writeRecord(logMsg *logMessage, _ []string, t time.Time) error {
+	data, err := marshalJSONRecord(logMsg, "", t)
+	if err != nil {
+		return err
+	}
+	msg := ProducerMessage{Value: data, Time: t}
+	for i := 0; k.keyField != "" && i+1 < len(logMsg.fields); i += 2 {
+		if fmt.Sprint(logMsg.fields[i]) == k.keyField {
+			msg.Key = []byte(fmt.Sprint(logMsg.fields[i+1]))
+		}
+	}
+	k.batch = append(k.batch, msg)
+	if len(k.batch) >= kafkaBatchSize {
+		return k.flush()
+	}
+	return nil
+}
+
+// flush hands over the collected log records to the producer goroutine.
+// If too many batches are waiting to be produced, the batch is dropped instead of stalling the log service and
+// passed to the delivery error callback.
+func (k *kafkaSink) flush() error {
+	if len(k.batch) == 0 {
+		return nil
+	}
+	batch := k.batch
+	k.batch = nil
+	select {
+	case k.batches <- batch:
+		return nil
+	default:
+		err := fmt.Errorf("%w: %d log records dropped", ErrSinkQueueFull, len(batch))
+		if k.onError != nil {
+			k.onError(err, batch)
+		}
+		return err
+	}
+}
+
+// close produces the pending log records and stops the producer goroutine.
+func (k *kafkaSink) close() error {
+	if len(k.batch) > 0 {
+		// wait until the producer goroutine takes the last batch
+		k.batches <- k.batch
+		k.batch = nil
+	}
+	close(k.batches)
+	<-k.done
+	return nil
+}
+
+// produceBatches produces each received batch of log records to the topic.
+// This function is kicked off in a dedicated goroutine. If a batch can't be delivered, the delivery error
+// callback is called with the batch.
+func produceBatches(p Producer, topic string, onError DeliveryErrorCallback, batches <-chan []ProducerMessage, done chan<- struct{}) {
+	defer close(done)
+	for batch := range batches {
+		ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+		err := p.Produce(ctx, topic, batch)
+		cancel()
+		if err != nil && onError != nil {
+			onError(err, batch)
+		}
+	}
+}
+
+// registerKafkaDestination implements RegisterKafkaDestination for the log service.
+func (s *simpleLogService) registerKafkaDestination(name string, p Producer, topic, keyField string, onError DeliveryErrorCallback) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	return s.registerSink(name, newKafkaSink(p, topic, keyField, onError))
+}
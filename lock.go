@@ -0,0 +1,63 @@
+package simplelog
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// fileLock is an io.Writer which writes whole lines to the log file while holding an advisory lock on it, if
+// locking is enabled. Thereby, several processes, e.g. forked workers, can append to the same log file without
+// interleaving partial lines. An incomplete last line is kept until the rest of the line is written.
+type fileLock struct {
+	file    *os.File // the log file
+	enabled bool     // flag to indicate whether the log file is locked while lines are written
+	pending []byte   // the incomplete last line written before
+}
+
+// Write writes the complete lines of p, preceded by the pending incomplete line, at the end of the log file while
+// holding the lock. If the write fails, the pending incomplete line is kept, so that p can be written again.
+// Write implements the io.Writer interface.
+func (l *fileLock) Write(p []byte) (int, error) {
+	if !l.enabled {
+		return l.file.Write(p)
+	}
+	i := bytes.LastIndexByte(p, '\n')
+	if i < 0 {
+		l.pending = append(l.pending, p...)
+		return len(p), nil
+	}
+	lines := append(l.pending[:len(l.pending):len(l.pending)], p[:i+1]...)
+	if err := l.writeLocked(lines); err != nil {
+		return 0, err
+	}
+	l.pending = append(l.pending[:0], p[i+1:]...)
+	return len(p), nil
+}
+
+// writeLocked writes the lines at the end of the log file while holding the lock.
+// Other processes may have appended to the log file since the last write, hence the end is looked up again.
+func (l *fileLock) writeLocked(lines []byte) error {
+	if err := lockFile(l.file); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = l.file.Write(lines)
+	}
+	if unlockErr := unlockFile(l.file); err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+// setFileLock implements SetFileLock for the log service.
+func (s *simpleLogService) setFileLock(enabled bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	return s.configure(setfilelock, enabled)
+}
@@ -0,0 +1,17 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package simplelog
+
+import "os"
+
+// lockFile acquires an exclusive advisory lock on a file.
+// Advisory locks aren't supported on this platform.
+func lockFile(f *os.File) error {
+	return ErrNotSupported
+}
+
+// unlockFile releases the advisory lock on a file.
+// Advisory locks aren't supported on this platform.
+func unlockFile(f *os.File) error {
+	return ErrNotSupported
+}
@@ -1,16 +1,38 @@
 package simplelog
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // logger represents an object that generates lines of output to an io.Writer.
 type logger struct {
-	destination io.Writer // log destination, e.g. stdout or bufio.Writer
-	lineBuf     []byte    // buffer for one line of log data
+	destination io.Writer   // log destination, e.g. stdout or bufio.Writer
+	lineBuf     []byte      // buffer for one line of log data
+	contBuf     []byte      // buffer to prefix the continuation lines of multi-line log records
+	terminal    bool        // flag to indicate whether the log destination is a terminal
+	timestamps  []timestamp // the formatted timestamps of the date/time placeholders of the prefix
+}
+
+// maxTimestamps is the maximum number of formatted timestamps cached by a logger.
+const maxTimestamps = 8
+
+// timestamp is a formatted timestamp, which is reused for log records within the precision of its layout.
+type timestamp struct {
+	layout    string        // the layout of the timestamp
+	precision time.Duration // the precision of the layout, e.g. time.Millisecond for "15:04:05.000"
+	at        time.Time     // the point in time the timestamp was formatted for, truncated to the precision
+	text      []byte        // the formatted timestamp
 }
 
 // newLogger instantiates a new logger.
@@ -19,41 +41,312 @@ func newLogger(destination io.Writer) *logger {
 	return &logger{destination: destination}
 }
 
+// newTerminalLogger instantiates a new logger for a log destination which might be a terminal, e.g. stdout.
+// The log records are written to w, which buffers the output to the file f.
+// If the file f is a terminal, the logger supports colorized log records.
+func newTerminalLogger(f *os.File, w io.Writer) *logger {
+	l := newLogger(w)
+	if info, err := f.Stat(); err == nil {
+		l.terminal = info.Mode()&os.ModeCharDevice != 0
+	}
+	return l
+}
+
+// jsonRecord represents the structure of a log record written in JSON format.
+type jsonRecord struct {
+	Timestamp string         `json:"timestamp"`
+	Prefix    string         `json:"prefix,omitempty"`
+	Component string         `json:"component,omitempty"`
+	Tags      []string       `json:"tags,omitempty"`
+	Level     string         `json:"level,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	Stack     string         `json:"stack,omitempty"`
+}
+
+// marshalJSONRecord returns the log message as JSON object, as written in JSON format.
+// Field values which can't be encoded are written with their textual representation.
+func marshalJSONRecord(logMsg *logMessage, prefix string, t time.Time) ([]byte, error) {
+	record := jsonRecord{
+		Timestamp: t.Format(time.RFC3339Nano),
+		Prefix:    prefix,
+		Component: logMsg.component,
+		Tags:      logMsg.tags,
+		Level:     levelNames[logMsg.level],
+		Message:   logMsg.text(),
+		Fields:    jsonFields(logMsg.fields, false),
+		Stack:     string(logMsg.stack),
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		// some field values can't be encoded - use their textual representation instead
+		record.Fields = jsonFields(logMsg.fields, true)
+		data, err = json.Marshal(record)
+	}
+	return data, err
+}
+
 // write writes the output for a logging event.
 // Thereby one logging event corresponds to one line of output at the used log destination.
-func (l *logger) write(logMsg *logMessage) error {
-	var prefix []string
+// The settings parameter specifies the settings of the log destination, e.g. the prefix which is placed in
+// front of the log record and the format of the log record.
+// The time t is used for the timestamp and the date and time placeholders of the prefix.
+// It returns the number of bytes written.
+func (l *logger) write(settings *logSettings, logMsg *logMessage, t time.Time) (int, error) {
 	l.lineBuf = l.lineBuf[:0] // reset log record
+	prefix := settings.prefixFor(logMsg.level)
 
-	switch logMsg.destination {
-	case STDOUT:
-		prefix = s.stdoutLogger.prefix
-	case FILE:
-		prefix = s.fileLogger.prefix
-	}
+	switch settings.format {
+	case JSON:
+		data, err := marshalJSONRecord(logMsg, string(l.appendPrefix(nil, prefix, t, logMsg)), t)
+		if err != nil {
+			return 0, err
+		}
+		l.lineBuf = append(l.lineBuf, data...)
+		l.lineBuf = append(l.lineBuf, '\n')
+	case GELF:
+		var err error
+		if l.lineBuf, err = appendGELF(l.lineBuf, string(l.appendPrefix(nil, prefix, t, logMsg)), logMsg, t); err != nil {
+			return 0, err
+		}
+		l.lineBuf = append(l.lineBuf, '\n')
+	default:
+		// colorize log records only if they are written to a terminal
+		colored := settings.color && l.terminal
 
-	if len(prefix) > 0 {
-		// build log prefix
-		for _, v := range prefix {
-			if strings.HasPrefix(v, dateTimeTag) && strings.HasSuffix(v, dateTimeTag) {
-				// date/time placeholders found - replace with real date/time values
-				t := time.Now()
-				l.lineBuf = append(l.lineBuf, t.Format(strings.Trim(v, dateTimeTag))...)
-			} else {
-				// no date/time placeholders found
-				l.lineBuf = append(l.lineBuf, v...)
+		if len(prefix) > 0 {
+			if colored {
+				l.lineBuf = append(l.lineBuf, prefixColor...)
+			}
+			l.lineBuf = l.appendPrefix(l.lineBuf, prefix, t, logMsg)
+			if colored {
+				l.lineBuf = append(l.lineBuf, resetColor...)
 			}
 			l.lineBuf = append(l.lineBuf, ' ')
 		}
+
+		if logMsg.component != "" {
+			// add the component name of a Child after the prefix
+			l.lineBuf = append(l.lineBuf, logMsg.component...)
+			l.lineBuf = append(l.lineBuf, ' ')
+		}
+
+		if name, ok := levelNames[logMsg.level]; ok {
+			// add the log level to the log record
+			if colored {
+				l.lineBuf = append(l.lineBuf, levelColors[logMsg.level]...)
+			}
+			l.lineBuf = append(l.lineBuf, name...)
+			if colored {
+				l.lineBuf = append(l.lineBuf, resetColor...)
+			}
+			l.lineBuf = append(l.lineBuf, ' ')
+		}
+
+		// append payload to the log record
+		head := len(l.lineBuf)
+		l.lineBuf = logMsg.appendText(l.lineBuf)
+		l.lineBuf = appendFields(l.lineBuf, logMsg.fields)
+		l.lineBuf = append(l.lineBuf, '\n')
+		if len(logMsg.stack) > 0 {
+			// append the stack trace on the lines following the log record
+			l.lineBuf = append(l.lineBuf, logMsg.stack...)
+			l.lineBuf = append(l.lineBuf, '\n')
+		}
+		if settings.multiline != RAWLINES {
+			l.appendContinuation(settings, head)
+		}
 	}
 
-	// append payload to the log record
-	l.lineBuf = append(l.lineBuf, fmt.Sprintln(logMsg.data...)...)
 	// write log record to the log destination
-	_, err := l.destination.Write(l.lineBuf)
-	if err != nil {
-		panic(err)
+	return l.destination.Write(l.lineBuf)
+}
+
+// appendPrefix appends the prefix items, separated by blanks, to the buffer and returns the extended buffer.
+// Prefix items delimited by date/time tags are replaced by the given time formatted accordingly.
+// The caller, host, pid, goid, seq and uptime placeholders are replaced by the respective values of the log message
+// and the process.
+func (l *logger) appendPrefix(buf []byte, prefix []string, t time.Time, logMsg *logMessage) []byte {
+	for i, v := range prefix {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		switch {
+		case v == callerTag:
+			// caller placeholder found - resolve the program counter of the caller to file and line
+			buf = appendCaller(buf, logMsg.caller)
+		case v == hostTag:
+			buf = append(buf, hostname...)
+		case v == pidTag:
+			buf = append(buf, pid...)
+		case v == goidTag:
+			buf = strconv.AppendUint(buf, logMsg.goid, 10)
+		case v == seqTag:
+			buf = strconv.AppendUint(buf, logMsg.seq, 10)
+		case v == uptimeTag:
+			// seconds with microsecond precision, e.g. 12.345678
+			buf = strconv.AppendFloat(buf, logMsg.uptime.Seconds(), 'f', 6, 64)
+		case strings.HasPrefix(v, dateTimeTag) && strings.HasSuffix(v, dateTimeTag):
+			// date/time placeholders found - replace with real date/time values
+			buf = l.appendTimestamp(buf, strings.Trim(v, dateTimeTag), t)
+		default:
+			// no placeholders found
+			buf = append(buf, v...)
+		}
 	}
+	return buf
+}
 
-	return err
+// appendTimestamp appends the time t formatted according to the layout to the buffer and returns the extended
+// buffer. As formatting is expensive, the formatted time is cached and only formatted again if the time changed
+// within the precision of the layout, e.g. once per second for "15:04:05" or once per millisecond for "15:04:05.000".
+func (l *logger) appendTimestamp(buf []byte, layout string, t time.Time) []byte {
+	var ts *timestamp
+	for i := range l.timestamps {
+		if l.timestamps[i].layout == layout {
+			ts = &l.timestamps[i]
+			break
+		}
+	}
+	if ts == nil {
+		if len(l.timestamps) == maxTimestamps {
+			// the prefix was changed several times - forget the timestamps of the former prefixes
+			l.timestamps = l.timestamps[:0]
+		}
+		l.timestamps = append(l.timestamps, timestamp{layout: layout, precision: layoutPrecision(layout)})
+		ts = &l.timestamps[len(l.timestamps)-1]
+	}
+	if at := t.Truncate(ts.precision); !at.Equal(ts.at) || at.Location() != ts.at.Location() {
+		ts.at = at
+		ts.text = t.AppendFormat(ts.text[:0], layout)
+	}
+	return append(buf, ts.text...)
+}
+
+// layoutPrecision returns the precision of a time layout, which is given by the number of digits of the
+// fractional seconds, e.g. time.Millisecond for "15:04:05.000". Layouts without fractional seconds have a
+// precision of one second.
+func layoutPrecision(layout string) time.Duration {
+	for i := 0; i+1 < len(layout); i++ {
+		if (layout[i] == '.' || layout[i] == ',') && (layout[i+1] == '0' || layout[i+1] == '9') {
+			digits := 1
+			for i+1+digits < len(layout) && layout[i+1+digits] == layout[i+1] {
+				digits++
+			}
+			if digits > 9 {
+				digits = 9
+			}
+			precision := time.Second
+			for ; digits > 0; digits-- {
+				precision /= 10
+			}
+			return precision
+		}
+	}
+	return time.Second
+}
+
+// appendFields appends the structured fields as key=value pairs, separated by blanks, to the buffer and
+// returns the extended buffer. Values which are empty or contain blanks, quotes or equal signs are quoted.
+func appendFields(buf []byte, fields []any) []byte {
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf = append(buf, ' ')
+		buf = append(buf, fmt.Sprint(fields[i])...)
+		buf = append(buf, '=')
+		value := fmt.Sprint(fields[i+1])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			buf = strconv.AppendQuote(buf, value)
+		} else {
+			buf = append(buf, value...)
+		}
+	}
+	return buf
+}
+
+// jsonFields returns the structured fields as map to be encoded in JSON format.
+// Errors and values implementing fmt.Stringer are represented by their text. If asText is true,
+// all values are represented by their text, which is used if a value can't be encoded otherwise.
+func jsonFields(fields []any, asText bool) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		switch v := fields[i+1].(type) {
+		case error:
+			m[key] = v.Error()
+		case fmt.Stringer:
+			m[key] = v.String()
+		default:
+			if asText {
+				m[key] = fmt.Sprint(v)
+			} else {
+				m[key] = v
+			}
+		}
+	}
+	return m
+}
+
+// appendCaller appends the source file name and line of the program counter pc, e.g. main.go:42,
+// to the buffer and returns the extended buffer. If the program counter can't be resolved, ??? is appended.
+func appendCaller(buf []byte, pc uintptr) []byte {
+	if pc == 0 {
+		return append(buf, "???"...)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return append(buf, "???"...)
+	}
+	buf = append(buf, filepath.Base(frame.File)...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(frame.Line), 10)
+}
+
+// process information used by the host and pid placeholders
+var (
+	hostname        string    // the host name
+	pid             string    // the process ID
+	processInfoOnce sync.Once // to resolve the process information only once
+)
+
+// resolveProcessInfo resolves the process information used by the host and pid placeholders.
+// As the process information doesn't change, it is resolved only once when the first log service is started.
+func resolveProcessInfo() {
+	processInfoOnce.Do(func() {
+		var err error
+		if hostname, err = os.Hostname(); err != nil {
+			hostname = "???"
+		}
+		pid = strconv.Itoa(os.Getpid())
+	})
+}
+
+// goroutineID returns the ID of the calling goroutine.
+// The ID is parsed from the header of the goroutine's stack trace, e.g. "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	field := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(field, ' '); i > 0 {
+		field = field[:i]
+	}
+	id, _ := strconv.ParseUint(string(field), 10, 64)
+	return id
+}
+
+// stackTrace returns the stack trace of the calling goroutine without a trailing newline.
+// The skip parameter specifies the number of stack frames to omit, starting with the one of debug.Stack.
+func stackTrace(skip int) []byte {
+	stack := bytes.TrimSuffix(debug.Stack(), []byte("\n"))
+	header, frames, _ := bytes.Cut(stack, []byte("\n"))
+	// each stack frame consists of a line with the function and a line with the source file
+	for i := 0; i < 2*skip && len(frames) > 0; i++ {
+		_, frames, _ = bytes.Cut(frames, []byte("\n"))
+	}
+	trace := make([]byte, 0, len(header)+1+len(frames))
+	trace = append(trace, header...)
+	trace = append(trace, '\n')
+	return append(trace, frames...)
 }
@@ -0,0 +1,10 @@
+module github.com/sabitor/simplelog/logrsink
+
+go 1.18
+
+require (
+	github.com/go-logr/logr v1.2.4
+	github.com/sabitor/simplelog v0.0.0
+)
+
+replace github.com/sabitor/simplelog => ../
@@ -0,0 +1,109 @@
+// Package logrsink provides a logr.LogSink which writes the log records of a logr.Logger to a simplelog log
+// service, so that libraries logging via logr, e.g. controller-runtime or the Kubernetes client libraries, write
+// to the same log destinations, with the same prefixes, as the application.
+//
+// The package is a module of its own, so that the simplelog module itself doesn't depend on logr.
+package logrsink
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/sabitor/simplelog"
+)
+
+// Sink is a logr.LogSink which writes log records by a simplelog.Child to a log destination.
+// The V-level 0 of logr is written as INFO, higher V-levels are written as DEBUG and errors as ERROR with the
+// error as structured field. The name of a logr.Logger, e.g. controller/reconciler, is written as component
+// name in brackets and its values as structured fields.
+type Sink struct {
+	parent      *simplelog.Child // the Child the sink was created with
+	child       *simplelog.Child // the parent extended by the name of the sink
+	destination int              // the log destination the log records are written to
+	verbosity   int              // the highest V-level which is written
+	name        string           // the name of the logr.Logger; empty if not set
+	values      []any            // the values of the logr.Logger as alternating keys and values
+}
+
+// New returns a logr.Logger which writes its log records by the Child c to a log destination, e.g.
+// New(simplelog.Derive("[k8s]"), simplelog.FILE, 2) for the default log service.
+// The c parameter specifies the Child, e.g. returned by simplelog.Derive or the Derive method of a
+// simplelog.Logger. The destination parameter specifies the log destination, the log records are written to.
+// The verbosity parameter specifies the highest V-level which is written; log records of higher V-levels are
+// discarded before they are formatted.
+func New(c *simplelog.Child, destination, verbosity int) logr.Logger {
+	return logr.New(&Sink{parent: c, child: c, destination: destination, verbosity: verbosity})
+}
+
+// Init receives the runtime information of the logr.Logger. As the caller of a log record is determined by the
+// log service, Init does nothing.
+func (s *Sink) Init(info logr.RuntimeInfo) {}
+
+// Enabled returns true, if log records of the V-level are written, false otherwise.
+func (s *Sink) Enabled(level int) bool {
+	return level <= s.verbosity
+}
+
+// Info writes a log record of a V-level with the given message and structured fields.
+func (s *Sink) Info(level int, msg string, keysAndValues ...any) {
+	if level > 0 {
+		s.child.LogKV(simplelog.DEBUG, s.destination, msg, s.fields(keysAndValues)...)
+	} else {
+		s.child.LogKV(simplelog.INFO, s.destination, msg, s.fields(keysAndValues)...)
+	}
+}
+
+// Error writes a log record of an error with the given message and structured fields.
+func (s *Sink) Error(err error, msg string, keysAndValues ...any) {
+	s.child.LogKV(simplelog.ERROR, s.destination, msg, append(s.fields(keysAndValues), "error", err)...)
+}
+
+// WithValues returns a Sink, which writes the given structured fields with every log record.
+func (s *Sink) WithValues(keysAndValues ...any) logr.LogSink {
+	values := make([]any, 0, len(s.values)+len(keysAndValues)+1)
+	values = append(values, s.values...)
+	return s.derive(s.name, fields(values, keysAndValues))
+}
+
+// fields returns the values of the Sink followed by the given keys and values as structured fields.
+func (s *Sink) fields(keysAndValues []any) []any {
+	dst := make([]any, 0, len(s.values)+len(keysAndValues)+3)
+	return fields(append(dst, s.values...), keysAndValues)
+}
+
+// fields appends the keys and values of logr to the structured fields of simplelog, whose keys must be strings.
+// Other keys, which logr doesn't forbid, are appended with their textual representation and a key without value
+// with the value <no-value>, so that the log record isn't rejected with simplelog.ErrInvalidFields.
+func fields(dst []any, keysAndValues []any) []any {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		var value any = "<no-value>"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		dst = append(dst, key, value)
+	}
+	return dst
+}
+
+// WithName returns a Sink, whose name is extended by the given name, separated by a slash.
+func (s *Sink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "/" + name
+	}
+	return s.derive(name, s.values)
+}
+
+// derive returns a copy of the Sink with the given name and values.
+func (s *Sink) derive(name string, values []any) *Sink {
+	sink := *s
+	sink.name = name
+	sink.values = values
+	if name != "" {
+		sink.child = s.parent.Derive("[" + name + "]")
+	}
+	return &sink
+}
@@ -0,0 +1,32 @@
+package logrsink
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sabitor/simplelog"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := simplelog.New()
+	destination, _ := l.RegisterDestination("buffer", &buf)
+	l.SetLevel(destination, simplelog.DEBUG)
+
+	log := New(l.Derive("[k8s]"), destination, 1).WithName("controller").WithValues("namespace", "default")
+	log.WithName("reconciler").Error(errors.New("conflict"), "update failed")
+	log.Info("reconciling", "pod", "web-0")
+	log.V(1).Info("cache synced")
+	log.V(2).Info("discarded")
+	log.Info("invalid keys", 42, "answer", "odd")
+	l.Shutdown(false)
+
+	expected := "[k8s] [controller/reconciler] ERROR update failed namespace=default error=conflict\n" +
+		"[k8s] [controller] INFO reconciling namespace=default pod=web-0\n" +
+		"[k8s] [controller] DEBUG cache synced namespace=default\n" +
+		"[k8s] [controller] INFO invalid keys namespace=default 42=answer odd=<no-value>\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
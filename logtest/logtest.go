@@ -0,0 +1,127 @@
+// Package logtest provides helpers to test code which logs via simplelog.
+// A Sink captures the log records addressed to log destinations, e.g. STDOUT or FILE, in memory instead of
+// writing them, so tests can make assertions about the log records without hijacking os.Stdout.
+//
+// Log records are written asynchronously by the log service. Therefore, make assertions only after the
+// log records have been written, e.g. by writing them with WriteSync or after the log service was stopped.
+package logtest
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sabitor/simplelog"
+)
+
+// AddHookFunc represents a function which adds a hook to a log service, e.g. simplelog.AddHook for the
+// default log service or the AddHook method of a simplelog.Logger.
+type AddHookFunc func(simplelog.Hook) error
+
+// Sink is a memory sink which captures log records.
+type Sink struct {
+	mu          sync.Mutex
+	destination int                // the log destinations captured by the sink
+	records     []simplelog.Record // the captured log records
+	detached    bool               // flag to indicate whether the sink stopped capturing log records
+}
+
+// Capture swaps in a memory sink for the given log destinations of a log service. Log records addressed to them
+// are captured by the sink and no longer written to them; log records addressed to other log destinations are
+// still written. The sink stops capturing log records when the test finishes.
+// Capture fails the test if the hook capturing the log records can't be added, e.g. if the log service is not running.
+func Capture(t testing.TB, addHook AddHookFunc, destination int) *Sink {
+	t.Helper()
+	sink := &Sink{destination: destination}
+	if err := addHook(sink.hook); err != nil {
+		t.Fatal("Expected to capture log records but got", err)
+	}
+	t.Cleanup(func() {
+		sink.mu.Lock()
+		sink.detached = true
+		sink.mu.Unlock()
+	})
+	return sink
+}
+
+// hook captures the log records addressed to the log destinations of the sink.
+func (s *Sink) hook(rec *simplelog.Record) *simplelog.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detached || rec.Destination&s.destination == 0 {
+		return rec
+	}
+	captured := *rec
+	captured.Destination &= s.destination
+	s.records = append(s.records, captured)
+	rec.Destination &^= s.destination
+	return rec
+}
+
+// Records returns the captured log records.
+func (s *Sink) Records() []simplelog.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]simplelog.Record(nil), s.records...)
+}
+
+// Texts returns the texts of the captured log records.
+func (s *Sink) Texts() []string {
+	var texts []string
+	for _, rec := range s.Records() {
+		texts = append(texts, rec.Text())
+	}
+	return texts
+}
+
+// Reset discards the captured log records.
+func (s *Sink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = nil
+}
+
+// CountByLevel returns the number of captured log records of the given level.
+func (s *Sink) CountByLevel(level int) int {
+	n := 0
+	for _, rec := range s.Records() {
+		if rec.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// Contains reports whether the text of a captured log record contains substr.
+// If not, the test is marked as failed.
+func (s *Sink) Contains(t testing.TB, substr string) bool {
+	t.Helper()
+	texts := s.Texts()
+	for _, text := range texts {
+		if strings.Contains(text, substr) {
+			return true
+		}
+	}
+	t.Errorf("Expected a log record containing %q but got %q", substr, texts)
+	return false
+}
+
+// MatchesRegexp reports whether the text of a captured log record matches the regular expression pattern.
+// If not or if pattern is invalid, the test is marked as failed.
+func (s *Sink) MatchesRegexp(t testing.TB, pattern string) bool {
+	t.Helper()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Error("Expected a valid regular expression but got", err)
+		return false
+	}
+	texts := s.Texts()
+	for _, text := range texts {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	t.Errorf("Expected a log record matching %q but got %q", pattern, texts)
+	return false
+}
@@ -0,0 +1,31 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/sabitor/simplelog"
+)
+
+func TestCapture(t *testing.T) {
+	logger, _ := simplelog.New()
+	defer logger.Shutdown(false)
+
+	sink := Capture(t, logger.AddHook, simplelog.STDOUT)
+	logger.Log(simplelog.ERROR, simplelog.STDOUT, "The answer to all questions is", 42)
+	logger.Log(simplelog.INFO, simplelog.STDOUT, "Don't panic")
+	logger.WriteSync(simplelog.STDOUT, "So long, and thanks for all the fish")
+
+	if n := len(sink.Records()); n != 3 {
+		t.Error("Expected captured log records", 3, "but got", n)
+	}
+	if n := sink.CountByLevel(simplelog.ERROR); n != 1 {
+		t.Error("Expected captured ERROR log records", 1, "but got", n)
+	}
+	sink.Contains(t, "answer to all questions")
+	sink.MatchesRegexp(t, `^So long.*fish$`)
+
+	sink.Reset()
+	if n := len(sink.Records()); n != 0 {
+		t.Error("Expected captured log records", 0, "but got", n)
+	}
+}
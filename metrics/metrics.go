@@ -0,0 +1,84 @@
+// Package metrics publishes the metrics of a simplelog log service via expvar and
+// in the Prometheus text exposition format, so dashboards can track the health of the logger.
+// The metrics are retrieved by a StatsFunc, e.g. simplelog.Stats for the default log service
+// or the Stats method of a simplelog.Logger.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/sabitor/simplelog"
+)
+
+// StatsFunc represents a function which returns the metrics of a log service.
+type StatsFunc func() (simplelog.ServiceStats, error)
+
+// Publish publishes the metrics of a log service as expvar variable with the given name.
+// Like expvar.Publish, Publish panics if the name is already in use.
+func Publish(name string, stats StatsFunc) {
+	expvar.Publish(name, expvar.Func(func() any {
+		st, err := stats()
+		if err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+		written := make(map[string]uint64, len(st.Written))
+		for destination, n := range st.Written {
+			written[fmt.Sprint(destination)] = n
+		}
+		vars := map[string]any{
+			"written":       written,
+			"bytes_written": st.BytesWritten,
+			"dropped":       st.Dropped,
+			"rate_limited":  st.RateLimited,
+			"queue_depth":   st.QueueDepth,
+			"flushes":       st.Flushes,
+			"rotations":     st.Rotations,
+			"write_seconds": st.WriteTime.Seconds(),
+			"last_error":    "",
+		}
+		if st.LastError != nil {
+			vars["last_error"] = st.LastError.Error()
+		}
+		return vars
+	}))
+}
+
+// Handler returns an http.Handler which serves the metrics of a log service in the Prometheus text exposition
+// format. It can be scraped by Prometheus like a handler of the promhttp package. Applications using the
+// Prometheus client library register the Collector of the promcollector module instead.
+func Handler(stats StatsFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st, err := stats()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		destinations := make([]int, 0, len(st.Written))
+		for destination := range st.Written {
+			destinations = append(destinations, destination)
+		}
+		sort.Ints(destinations)
+		fmt.Fprintln(w, "# HELP simplelog_records_written_total Number of log records written per log destination.")
+		fmt.Fprintln(w, "# TYPE simplelog_records_written_total counter")
+		for _, destination := range destinations {
+			fmt.Fprintf(w, "simplelog_records_written_total{destination=\"%d\"} %d\n", destination, st.Written[destination])
+		}
+		writeMetric(w, "simplelog_bytes_written_total", "counter", "Number of bytes written to all log destinations.", st.BytesWritten)
+		writeMetric(w, "simplelog_dropped_total", "counter", "Number of log messages dropped because the data channel was full.", st.Dropped)
+		writeMetric(w, "simplelog_rate_limited_total", "counter", "Number of log records dropped by rate limits.", st.RateLimited)
+		writeMetric(w, "simplelog_queue_depth", "gauge", "Number of log messages in the data channel.", st.QueueDepth)
+		writeMetric(w, "simplelog_flushes_total", "counter", "Number of flushes of buffered log records.", st.Flushes)
+		writeMetric(w, "simplelog_rotations_total", "counter", "Number of log file rotations.", st.Rotations)
+		writeMetric(w, "simplelog_write_seconds_total", "counter", "Total time spent writing log records.", st.WriteTime.Seconds())
+	})
+}
+
+// writeMetric writes a single metric without labels in the Prometheus text exposition format.
+func writeMetric(w http.ResponseWriter, name, kind, help string, value any) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+}
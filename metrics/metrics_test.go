@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sabitor/simplelog"
+)
+
+func testStats() (simplelog.ServiceStats, error) {
+	return simplelog.ServiceStats{
+		Written:      map[int]uint64{simplelog.STDOUT: 2, simplelog.FILE: 1},
+		BytesWritten: 42,
+		WriteTime:    time.Second,
+	}, nil
+}
+
+func TestPublish(t *testing.T) {
+	if expvar.Get("simplelog_test") == nil {
+		// expvar variables can't be published twice, e.g. if the test is run repeatedly
+		Publish("simplelog_test", testStats)
+	}
+
+	var vars map[string]any
+	if err := json.Unmarshal([]byte(expvar.Get("simplelog_test").String()), &vars); err != nil {
+		t.Error("Expected expvar variable as JSON but got", err)
+	} else if vars["bytes_written"] != float64(42) {
+		t.Error("Expected bytes written", 42, "but got", vars["bytes_written"])
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(testStats).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	output := rec.Body.String()
+	for _, expected := range []string{
+		"simplelog_records_written_total{destination=\"1\"} 2\n",
+		"simplelog_records_written_total{destination=\"2\"} 1\n",
+		"simplelog_bytes_written_total 42\n",
+		"simplelog_write_seconds_total 1\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Error("Expected metrics contain:", expected, "- but they don't:", output)
+		}
+	}
+
+	logger, _ := simplelog.New()
+	logger.Shutdown(false)
+	rec = httptest.NewRecorder()
+	Handler(logger.Stats).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 503 {
+		t.Error("Expected status", 503, "for a stopped log service but got", rec.Code)
+	}
+}
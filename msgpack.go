@@ -0,0 +1,192 @@
+package simplelog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// errMsgpack is returned if a msgpack value can't be decoded.
+var errMsgpack = errors.New("unsupported msgpack value")
+
+// appendMsgpackArray appends the header of a msgpack array with n elements to the buffer.
+func appendMsgpackArray(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return appendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+// appendMsgpackMap appends the header of a msgpack map with n key/value pairs to the buffer.
+func appendMsgpackMap(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return appendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackString appends a msgpack string to the buffer.
+func appendMsgpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendMsgpackTime appends a point in time as msgpack extension type 0, the EventTime of the Fluent protocol.
+func appendMsgpackTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, 0x00)
+	buf = appendUint32(buf, uint32(t.Unix()))
+	return appendUint32(buf, uint32(t.Nanosecond()))
+}
+
+// appendMsgpack appends a value as msgpack value to the buffer.
+// Values without a msgpack representation are appended as string in their textual representation.
+func appendMsgpack(buf []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	case int8:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	case int16:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	case int32:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	case int64:
+		return appendUint64(append(buf, 0xd3), uint64(v))
+	case uint:
+		return appendUint64(append(buf, 0xcf), uint64(v))
+	case uint8:
+		return appendUint64(append(buf, 0xcf), uint64(v))
+	case uint16:
+		return appendUint64(append(buf, 0xcf), uint64(v))
+	case uint32:
+		return appendUint64(append(buf, 0xcf), uint64(v))
+	case uint64:
+		return appendUint64(append(buf, 0xcf), v)
+	case float32:
+		return appendUint64(append(buf, 0xcb), math.Float64bits(float64(v)))
+	case float64:
+		return appendUint64(append(buf, 0xcb), math.Float64bits(v))
+	case string:
+		return appendMsgpackString(buf, v)
+	case error:
+		return appendMsgpackString(buf, v.Error())
+	case fmt.Stringer:
+		return appendMsgpackString(buf, v.String())
+	default:
+		return appendMsgpackString(buf, fmt.Sprint(v))
+	}
+}
+
+// appendUint16 appends a big-endian 16-bit number to the buffer.
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+// appendUint32 appends a big-endian 32-bit number to the buffer.
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendUint64 appends a big-endian 64-bit number to the buffer.
+func appendUint64(buf []byte, v uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(v>>32)), uint32(v))
+}
+
+// readMsgpackStringMap reads a msgpack map with string keys and string values, e.g. the ack of a Fluent server.
+// errMsgpack is returned if the value isn't such a map.
+func readMsgpackStringMap(r *bufio.Reader) (map[string]string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case b&0xf0 == 0x80:
+		n = int(b & 0x0f)
+	case b == 0xde:
+		n, err = readMsgpackSize(r, 2)
+	case b == 0xdf:
+		n, err = readMsgpackSize(r, 4)
+	default:
+		return nil, errMsgpack
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		if m[key], err = readMsgpackString(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// readMsgpackString reads a msgpack string.
+// errMsgpack is returned if the value isn't a string.
+func readMsgpackString(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9:
+		n, err = readMsgpackSize(r, 1)
+	case b == 0xda:
+		n, err = readMsgpackSize(r, 2)
+	case b == 0xdb:
+		n, err = readMsgpackSize(r, 4)
+	default:
+		return "", errMsgpack
+	}
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	return string(data), err
+}
+
+// readMsgpackSize reads a big-endian size of 1, 2 or 4 bytes.
+func readMsgpackSize(r *bufio.Reader, bytes int) (int, error) {
+	var data [4]byte
+	if _, err := io.ReadFull(r, data[4-bytes:]); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint32(data[:])), nil
+}
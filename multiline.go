@@ -0,0 +1,53 @@
+package simplelog
+
+import (
+	"bytes"
+)
+
+// multi-line modes
+const (
+	RAWLINES    = iota // write the continuation lines of multi-line log records as they are
+	PREFIXLINES        // prefix each continuation line with the prefix, the component and the level of the log record
+	INDENTLINES        // prefix each continuation line with an indent marker
+)
+
+// appendContinuation prefixes each continuation line of the log record, which starts at the beginning of the
+// buffer and whose payload starts at head, according to the multi-line mode of the log destination.
+// The log record is rebuilt in the continuation buffer of the logger, which is swapped with the line buffer.
+func (l *logger) appendContinuation(settings *logSettings, head int) {
+	body := l.lineBuf[head : len(l.lineBuf)-1]
+	if bytes.IndexByte(body, '\n') < 0 {
+		return
+	}
+	marker := []byte(settings.indent)
+	if settings.multiline == PREFIXLINES {
+		marker = l.lineBuf[:head]
+	}
+	buf := append(l.contBuf[:0], l.lineBuf[:head]...)
+	for {
+		i := bytes.IndexByte(body, '\n')
+		if i < 0 {
+			break
+		}
+		buf = append(buf, body[:i+1]...)
+		buf = append(buf, marker...)
+		body = body[i+1:]
+	}
+	buf = append(buf, body...)
+	buf = append(buf, '\n')
+	l.lineBuf, l.contBuf = buf, l.lineBuf
+}
+
+// setMultiline implements SetMultiline for the log service.
+func (s *simpleLogService) setMultiline(destination, mode int, indent string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	if mode != RAWLINES && mode != PREFIXLINES && mode != INDENTLINES {
+		return ErrUnknownMultilineMode
+	}
+	return s.configure(setmultiline, &multilineRequest{destination: destination, mode: mode, indent: indent})
+}
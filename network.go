@@ -0,0 +1,122 @@
+package simplelog
+
+import (
+	"net"
+	"time"
+)
+
+// network log settings
+const (
+	dialTimeout       = 1 * time.Second // maximum time to wait for a connection to the remote host
+	reconnectInterval = 1 * time.Second // minimum time between two reconnect attempts
+	maxBacklog        = 1000            // maximum number of log records buffered while the remote host isn't reachable
+)
+
+// instance denotes the logWriter interface implementation by the networkLogger type.
+func (n *networkLogger) instance() *logger {
+	if n.self == nil {
+		n.self = newLogger(n)
+	}
+	return n.self
+}
+
+// setupConnection connects to the remote host which receives the network log.
+func (n *networkLogger) setupConnection(network, address string) error {
+	conn, err := net.DialTimeout(network, address, dialTimeout)
+	if err != nil {
+		return err
+	}
+	n.releaseNetworkLogger()
+	n.conn = conn
+	n.network = network
+	n.address = address
+	return nil
+}
+
+// releaseNetworkLogger releases all networkLogger resources.
+// Before the connection is closed, a last attempt is made to send buffered log records to the remote host.
+func (n *networkLogger) releaseNetworkLogger() error {
+	var err error
+	n.sendBacklog()
+	if n.conn != nil {
+		err = n.conn.Close()
+	}
+	n.conn = nil
+	n.backlog = nil
+	n.self = nil
+	return err
+}
+
+// Write sends a log record to the remote host.
+// Log records in GELF format are sent in the frames expected by Graylog. An error is only returned, if a log
+// record in GELF format can't be framed.
+// Write implements the io.Writer interface.
+func (n *networkLogger) Write(p []byte) (int, error) {
+	if n.format != GELF {
+		n.send(p)
+		return len(p), nil
+	}
+	frames, err := gelfFrames(n.network, p)
+	if err != nil {
+		return 0, err
+	}
+	for _, frame := range frames {
+		n.send(frame)
+	}
+	return len(p), nil
+}
+
+// send sends a frame to the remote host.
+// If the remote host isn't reachable, the frame is buffered and sent as soon as the connection has been
+// reestablished. If the buffer is full, the oldest frame is dropped.
+func (n *networkLogger) send(p []byte) {
+	if n.sendBacklog() {
+		if _, err := writeRetry(n.conn, p, n.retryWindow); err == nil {
+			return
+		}
+		n.disconnect()
+	}
+	if len(n.backlog) == maxBacklog {
+		n.backlog = n.backlog[1:]
+	}
+	n.backlog = append(n.backlog, append([]byte(nil), p...))
+}
+
+// sendBacklog sends the buffered log records to the remote host, reconnecting first if necessary.
+// It returns true, if the connection to the remote host is established and all buffered log records were sent,
+// false otherwise.
+func (n *networkLogger) sendBacklog() bool {
+	if n.conn == nil && !n.reconnect() {
+		return false
+	}
+	for len(n.backlog) > 0 {
+		if _, err := n.conn.Write(n.backlog[0]); err != nil {
+			n.disconnect()
+			return false
+		}
+		n.backlog = n.backlog[1:]
+	}
+	return true
+}
+
+// reconnect tries to reestablish the connection to the remote host.
+// To not stall the log service, reconnect attempts are made at most once per reconnectInterval.
+func (n *networkLogger) reconnect() bool {
+	if n.address == "" || time.Since(n.lastReconnect) < reconnectInterval {
+		return false
+	}
+	n.lastReconnect = time.Now()
+	conn, err := net.DialTimeout(n.network, n.address, dialTimeout)
+	if err != nil {
+		return false
+	}
+	n.conn = conn
+	return true
+}
+
+// disconnect closes the broken connection to the remote host.
+func (n *networkLogger) disconnect() {
+	n.conn.Close()
+	n.conn = nil
+	n.lastReconnect = time.Now()
+}
@@ -0,0 +1,278 @@
+package simplelog
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP destination settings
+const (
+	otlpBatchSize = 100 // maximum number of log records exported at once
+	otlpQueueSize = 16  // maximum number of batches waiting to be exported
+)
+
+// keys of the structured fields carrying the trace context of a log record
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// otlpSeverities maps the log levels to the severity numbers of the OpenTelemetry log data model.
+var otlpSeverities = map[int]int{
+	DEBUG: 5,
+	INFO:  9,
+	WARN:  13,
+	ERROR: 17,
+	FATAL: 21,
+}
+
+// TraceExtractor represents a function which extracts the IDs of the active trace and span, as hex strings, from a
+// context, e.g. from the span context of an OpenTelemetry tracer. Empty IDs are not written.
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+// otlpAttribute represents a key/value pair of an OTLP log record, e.g. a structured field.
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpLogRecord represents a log record as defined by the JSON encoding of the OTLP logs data model.
+type otlpLogRecord struct {
+	TimeUnixNano   string          `json:"timeUnixNano"`
+	SeverityNumber int             `json:"severityNumber,omitempty"`
+	SeverityText   string          `json:"severityText,omitempty"`
+	Body           map[string]any  `json:"body"`
+	Attributes     []otlpAttribute `json:"attributes,omitempty"`
+	TraceID        string          `json:"traceId,omitempty"`
+	SpanID         string          `json:"spanId,omitempty"`
+}
+
+// otlpSink is a record sink which exports the log records in batches to an OpenTelemetry collector using OTLP over
+// HTTP with JSON encoding. The batches are exported in a dedicated goroutine, so that a slow collector doesn't stall
+// the log service.
+type otlpSink struct {
+	batch   []otlpLogRecord      // the log records which are exported next
+	batches chan []otlpLogRecord // the batches handed over to the exporter goroutine
+	done    chan struct{}        // closed when the exporter goroutine has exited
+}
+
+// newOTLPSink returns an otlpSink and starts its exporter goroutine.
+func newOTLPSink(endpoint, serviceName string) *otlpSink {
+	o := &otlpSink{
+		batches: make(chan []otlpLogRecord, otlpQueueSize),
+		done:    make(chan struct{}),
+	}
+	go exportBatches(endpoint, serviceName, o.batches, o.done)
+	return o
+}
+
+// writeRecord adds the log message as OTLP log record to the batch of log records which are exported next.
+// The level is mapped to the severity, the text to the body and the prefix, the component, the stack trace and the
+// structured fields to attributes. The trace_id and span_id fields are mapped to the trace context of the log record.
+func (o *otlpSink) writeRecord(logMsg *logMessage, prefix []string, t time.Time) error {
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(t.UnixNano(), 10),
+		SeverityNumber: otlpSeverities[logMsg.level],
+		SeverityText:   levelNames[logMsg.level],
+		Body:           otlpValue(logMsg.text()),
+		Attributes:     otlpPrefixAttributes(prefix, logMsg),
+	}
+	if logMsg.component != "" {
+		record.Attributes = append(record.Attributes, otlpAttribute{"component", otlpValue(logMsg.component)})
+	}
+	if len(logMsg.stack) > 0 {
+		record.Attributes = append(record.Attributes, otlpAttribute{"exception.stacktrace", otlpValue(string(logMsg.stack))})
+	}
+	for i := 0; i+1 < len(logMsg.fields); i += 2 {
+		key, value := fmt.Sprint(logMsg.fields[i]), logMsg.fields[i+1]
+		switch id := fmt.Sprint(value); {
+		case key == traceIDKey && isHexID(id, 16):
+			record.TraceID = strings.ToLower(id)
+		case key == spanIDKey && isHexID(id, 8):
+			record.SpanID = strings.ToLower(id)
+		default:
+			record.Attributes = append(record.Attributes, otlpAttribute{key, otlpValue(value)})
+		}
+	}
+	o.batch = append(o.batch, record)
+	if len(o.batch) >= otlpBatchSize {
+		return o.flush()
+	}
+	return nil
+}
+
+// flush hands over the collected log records to the exporter goroutine.
+// If too many batches are waiting to be exported, the batch is dropped instead of stalling the log service.
+func (o *otlpSink) flush() error {
+	if len(o.batch) == 0 {
+		return nil
+	}
+	batch := o.batch
+	o.batch = nil
+	select {
+	case o.batches <- batch:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d log records dropped", ErrSinkQueueFull, len(batch))
+	}
+}
+
+// close exports the pending log records and stops the exporter goroutine.
+func (o *otlpSink) close() error {
+	if len(o.batch) > 0 {
+		// wait until the exporter goroutine takes the last batch
+		o.batches <- o.batch
+		o.batch = nil
+	}
+	close(o.batches)
+	<-o.done
+	return nil
+}
+
+// exportBatches posts each received batch of log records as OTLP logs request to the endpoint of the collector.
+// This function is kicked off in a dedicated goroutine. Failed posts are retried with exponential backoff; if all
+// retries fail, the batch is dropped.
+func exportBatches(endpoint, serviceName string, batches <-chan []otlpLogRecord, done chan<- struct{}) {
+	defer close(done)
+	client := &http.Client{Timeout: webhookTimeout}
+	resource := map[string]any{
+		"attributes": []otlpAttribute{{"service.name", otlpValue(serviceName)}},
+	}
+	scope := map[string]any{"name": "simplelog"}
+	for batch := range batches {
+		request := map[string]any{
+			"resourceLogs": []any{map[string]any{
+				"resource":  resource,
+				"scopeLogs": []any{map[string]any{"scope": scope, "logRecords": batch}},
+			}},
+		}
+		data, err := json.Marshal(request)
+		if err != nil {
+			continue
+		}
+		postRetry(client, endpoint, data)
+	}
+}
+
+// otlpValue returns the value as OTLP any value, i.e. as JSON object with the key of the value type.
+// Values without an OTLP counterpart are written by their textual representation.
+func otlpValue(value any) map[string]any {
+	switch v := value.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		// 64-bit integers are encoded as decimal strings
+		return map[string]any{"intValue": fmt.Sprint(v)}
+	case float32:
+		return map[string]any{"doubleValue": float64(v)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	case time.Duration:
+		return map[string]any{"stringValue": v.String()}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// otlpPrefixAttributes returns the prefix items of a log record as OTLP attributes. The placeholders are mapped
+// to the respective OpenTelemetry semantic conventions, e.g. the host placeholder to host.name, while the plain
+// prefix items are joined to the log.prefix attribute. Date/time placeholders are skipped, as each OTLP log record
+// has its own timestamp.
+func otlpPrefixAttributes(prefix []string, logMsg *logMessage) []otlpAttribute {
+	var attributes []otlpAttribute
+	var text []string
+	for _, v := range prefix {
+		switch {
+		case v == callerTag:
+			if logMsg.caller == 0 {
+				continue
+			}
+			frame, _ := runtime.CallersFrames([]uintptr{logMsg.caller}).Next()
+			if frame.File != "" {
+				attributes = append(attributes,
+					otlpAttribute{"code.function", otlpValue(frame.Function)},
+					otlpAttribute{"code.filepath", otlpValue(frame.File)},
+					otlpAttribute{"code.lineno", otlpValue(frame.Line)})
+			}
+		case v == hostTag:
+			attributes = append(attributes, otlpAttribute{"host.name", otlpValue(hostname)})
+		case v == pidTag:
+			attributes = append(attributes, otlpAttribute{"process.pid", map[string]any{"intValue": pid}})
+		case v == goidTag:
+			attributes = append(attributes, otlpAttribute{"thread.id", otlpValue(logMsg.goid)})
+		case v == seqTag:
+			attributes = append(attributes, otlpAttribute{"log.record.seq", otlpValue(logMsg.seq)})
+		case v == uptimeTag:
+			attributes = append(attributes, otlpAttribute{"process.uptime", otlpValue(logMsg.uptime.Seconds())})
+		case strings.HasPrefix(v, dateTimeTag) && strings.HasSuffix(v, dateTimeTag):
+			// the time of the log record is written as timeUnixNano
+		default:
+			text = append(text, v)
+		}
+	}
+	if len(text) > 0 {
+		attributes = append(attributes, otlpAttribute{"log.prefix", otlpValue(strings.Join(text, " "))})
+	}
+	return attributes
+}
+
+// isHexID returns true, if id is the hex encoding of a trace or span ID of size bytes, which aren't all zero.
+func isHexID(id string, size int) bool {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != size {
+		return false
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// registerOTLPDestination implements RegisterOTLPDestination for the log service.
+func (s *simpleLogService) registerOTLPDestination(name, endpoint, serviceName string) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	return s.registerSink(name, newOTLPSink(endpoint, serviceName))
+}
+
+// setTraceExtractor implements SetTraceExtractor for the log service.
+func (s *simpleLogService) setTraceExtractor(extractor TraceExtractor) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	s.traceMu.Lock()
+	s.traceExtractor = extractor
+	s.traceMu.Unlock()
+	return nil
+}
+
+// traceFields returns the trace_id and span_id fields extracted from the context ctx by the trace extractor.
+func (s *simpleLogService) traceFields(ctx context.Context) []any {
+	s.traceMu.Lock()
+	extractor := s.traceExtractor
+	s.traceMu.Unlock()
+	if extractor == nil {
+		return nil
+	}
+	var fields []any
+	traceID, spanID := extractor(ctx)
+	if traceID != "" {
+		fields = append(fields, traceIDKey, traceID)
+	}
+	if spanID != "" {
+		fields = append(fields, spanIDKey, spanID)
+	}
+	return fields
+}
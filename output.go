@@ -0,0 +1,187 @@
+package simplelog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// captureTimeout is the maximum time to wait for the remaining output of a captured stream when the capture is
+// released, e.g. if a child process still holds the stream open.
+const captureTimeout = 1 * time.Second
+
+// capturedStream is the file descriptor of stdout or stderr, which is redirected to a pipe whose output is written
+// as log records.
+type capturedStream struct {
+	file     *os.File      // the file of the redirected file descriptor, i.e. os.Stdout or os.Stderr
+	fd       int           // the redirected file descriptor
+	tag      string        // the component name of the log records, e.g. [stdout]
+	original *os.File      // a duplicate of the original file descriptor, which the console log destinations write to
+	reader   *os.File      // the read end of the pipe
+	writer   *os.File      // the write end of the pipe; nil as soon as the file descriptor has been redirected
+	done     chan struct{} // closed when the output of the pipe has been read completely
+}
+
+// As file descriptors belong to the process, stdout and stderr are captured by at most one log service at a time.
+var (
+	outputMu        sync.Mutex        // synchronizes the access to the capture of stdout and stderr
+	outputCapturer  *simpleLogService // the log service capturing stdout and stderr; nil if not captured
+	capturedStreams []*capturedStream // the captured streams
+)
+
+// consoleFile returns the file the log records of a console log destination are written to, i.e. f or, while f
+// is captured, the duplicate of its original file descriptor. So the log records of the console log destinations
+// aren't captured again.
+func consoleFile(f *os.File) *os.File {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	for _, c := range capturedStreams {
+		if c.file == f {
+			return c.original
+		}
+	}
+	return f
+}
+
+// newCapturedStream duplicates the file descriptor of the file f and opens the pipe it is redirected to.
+func newCapturedStream(f *os.File, fd int, tag string) (*capturedStream, error) {
+	original, err := dupFd(fd)
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		os.NewFile(uintptr(original), f.Name()).Close()
+		return nil, err
+	}
+	return &capturedStream{
+		file:     f,
+		fd:       fd,
+		tag:      tag,
+		original: os.NewFile(uintptr(original), f.Name()),
+		reader:   r,
+		writer:   w,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// close closes the files of the captured stream.
+func (c *capturedStream) close() {
+	c.original.Close()
+	c.reader.Close()
+	if c.writer != nil {
+		c.writer.Close()
+	}
+}
+
+// readLines writes each line read from r as log record with the component name to the log destination, until r
+// returns EOF or an error.
+func (s *simpleLogService) readLines(r io.Reader, destination int, component string) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			s.enqueue(logMessage{destination: destination, component: component, data: []any{strings.TrimSuffix(line, "\n")}})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readOutput writes each line of the output of a captured stream as log record to the log destination.
+// This function is kicked off in a dedicated goroutine per captured stream.
+func (s *simpleLogService) readOutput(c *capturedStream, destination int) {
+	defer close(c.done)
+	s.readLines(c.reader, destination, c.tag)
+}
+
+// captureOutput implements CaptureOutput for the log service.
+func (s *simpleLogService) captureOutput(destination int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestinations(destination) {
+		return ErrUnknownDestination
+	}
+	outputMu.Lock()
+	if outputCapturer != nil {
+		outputMu.Unlock()
+		return ErrOutputCaptured
+	}
+	outputCapturer = s
+	outputMu.Unlock()
+
+	var streams []*capturedStream
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		fd, tag := 1, "[stdout]"
+		if f == os.Stderr {
+			fd, tag = 2, "[stderr]"
+		}
+		c, err := newCapturedStream(f, fd, tag)
+		if err != nil {
+			for _, c := range streams {
+				c.close()
+			}
+			outputMu.Lock()
+			outputCapturer = nil
+			outputMu.Unlock()
+			return err
+		}
+		streams = append(streams, c)
+	}
+
+	// let the console log destinations write to the original file descriptors before they are redirected
+	outputMu.Lock()
+	capturedStreams = streams
+	outputMu.Unlock()
+	s.configure(resetconsole, nil)
+
+	for _, c := range streams {
+		if err := redirectFd(int(c.writer.Fd()), c.fd); err != nil {
+			s.releaseOutput()
+			return err
+		}
+		c.writer.Close()
+		c.writer = nil
+		go s.readOutput(c, destination)
+	}
+	return nil
+}
+
+// releaseOutput restores stdout and stderr, if they are captured by the log service, and writes their remaining
+// output. It has to be called before the log service is stopped.
+func (s *simpleLogService) releaseOutput() {
+	outputMu.Lock()
+	if outputCapturer != s {
+		outputMu.Unlock()
+		return
+	}
+	streams := capturedStreams
+	outputMu.Unlock()
+
+	timeout := time.After(captureTimeout)
+	for _, c := range streams {
+		if c.writer != nil {
+			// the file descriptor wasn't redirected
+			continue
+		}
+		s.diagnose(redirectFd(int(c.original.Fd()), c.fd))
+		select {
+		case <-c.done:
+		case <-timeout:
+		}
+	}
+
+	outputMu.Lock()
+	capturedStreams = nil
+	outputCapturer = nil
+	outputMu.Unlock()
+	s.configure(resetconsole, nil)
+	for _, c := range streams {
+		c.close()
+	}
+}
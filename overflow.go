@@ -0,0 +1,151 @@
+package simplelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// overflowRecord represents a log message spilled to the overflow file.
+// The payload and the values of the structured fields are spilled as text.
+type overflowRecord struct {
+	Destination int      `json:"d"`
+	Level       int      `json:"l,omitempty"`
+	Line        string   `json:"m"`
+	Component   string   `json:"n,omitempty"`
+	Tags        []string `json:"t,omitempty"`
+	Fields      []string `json:"f,omitempty"`
+	Caller      uintptr  `json:"c,omitempty"`
+	Goid        uint64   `json:"g,omitempty"`
+	Stack       []byte   `json:"s,omitempty"`
+}
+
+// overflowBuffer is a data collection to support spilling log messages to a temporary overflow file,
+// while the data channel is full.
+type overflowBuffer struct {
+	mu      sync.Mutex
+	file    *os.File // the overflow file; nil if no log message has been spilled yet
+	spilled int32    // 1 if log messages have been spilled and not yet replayed, 0 otherwise; accessed atomically
+}
+
+// pending returns true, if log messages have been spilled and not yet replayed, false otherwise.
+func (o *overflowBuffer) pending() bool {
+	return atomic.LoadInt32(&o.spilled) == 1
+}
+
+// spill appends a log message to the overflow file.
+// The log messages of a batch are appended contiguously.
+// An error is returned if the overflow file can't be created or written.
+func (o *overflowBuffer) spill(logMsg logMessage) error {
+	logMsg.restore()
+	var data []byte
+	for _, m := range logMsg.unbatch() {
+		rec := overflowRecord{
+			Destination: m.destination,
+			Level:       m.level,
+			Line:        m.text(),
+			Component:   m.component,
+			Tags:        m.tags,
+			Caller:      m.caller,
+			Goid:        m.goid,
+			Stack:       m.stack,
+		}
+		for i, v := range m.fields {
+			if i%2 == 0 {
+				rec.Fields = append(rec.Fields, v.(string))
+			} else {
+				rec.Fields = append(rec.Fields, fmt.Sprint(v))
+			}
+		}
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var err error
+	if o.file == nil {
+		if o.file, err = os.CreateTemp("", "simplelog-overflow-*"); err != nil {
+			return err
+		}
+	}
+	if _, err = o.file.Write(data); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&o.spilled, 1)
+	return nil
+}
+
+// replay reads the spilled log messages and empties the overflow file.
+func (o *overflowBuffer) replay() ([]logMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		return nil, nil
+	}
+	defer func() {
+		o.file.Truncate(0)
+		o.file.Seek(0, io.SeekStart)
+		atomic.StoreInt32(&o.spilled, 0)
+	}()
+	if _, err := o.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var msgs []logMessage
+	scanner := bufio.NewScanner(o.file)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var rec overflowRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return msgs, err
+		}
+		logMsg := logMessage{
+			destination: rec.Destination,
+			level:       rec.Level,
+			line:        rec.Line,
+			component:   rec.Component,
+			tags:        rec.Tags,
+			caller:      rec.Caller,
+			goid:        rec.Goid,
+			stack:       rec.Stack,
+		}
+		for _, v := range rec.Fields {
+			logMsg.fields = append(logMsg.fields, v)
+		}
+		msgs = append(msgs, logMsg)
+	}
+	return msgs, scanner.Err()
+}
+
+// release removes the overflow file.
+func (o *overflowBuffer) release() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		return nil
+	}
+	o.file.Close()
+	err := os.Remove(o.file.Name())
+	o.file = nil
+	atomic.StoreInt32(&o.spilled, 0)
+	return err
+}
+
+// replayOverflow writes the log messages spilled to the overflow file.
+func (s *simpleLogService) replayOverflow() {
+	if !s.overflow.pending() || s.paused {
+		return
+	}
+	msgs, err := s.overflow.replay()
+	s.diagnose(err)
+	for i := range msgs {
+		s.writeMessage(&msgs[i])
+	}
+}
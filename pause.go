@@ -0,0 +1,28 @@
+package simplelog
+
+// setPaused pauses or resumes writing log records.
+// While the log service is paused, log messages are spilled to the overflow file; they are replayed in order
+// when it is resumed.
+func (s *simpleLogService) setPaused(paused bool) {
+	s.paused = paused
+	if !paused {
+		s.replayOverflow()
+		s.flushConsole()
+	}
+}
+
+// pause implements Pause for the log service.
+func (s *simpleLogService) pause() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(pauselog, true)
+}
+
+// resume implements Resume for the log service.
+func (s *simpleLogService) resume() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(pauselog, false)
+}
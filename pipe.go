@@ -0,0 +1,161 @@
+package simplelog
+
+import (
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// pipeConn is the connection to the reader of a Unix domain socket or a named pipe.
+type pipeConn interface {
+	io.WriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+// pipeWriter writes log records to a Unix domain socket or a named pipe (FIFO), e.g. of a sidecar log collector.
+// If the reader isn't available, e.g. while it restarts, the log records are buffered and written as soon as the
+// reader is available again. If the buffer is full, the oldest log records are dropped.
+type pipeWriter struct {
+	path          string    // path of the Unix domain socket or the named pipe
+	fifo          bool      // flag to indicate whether path is a named pipe
+	conn          pipeConn  // connection to the reader; nil if not connected
+	lastReconnect time.Time // point in time of the last reconnect attempt
+	backlog       [][]byte  // log records buffered while the reader isn't available
+}
+
+// newPipeWriter returns a pipeWriter for the Unix domain socket or named pipe at path and connects to its reader.
+// If the reader isn't available yet, the log records are buffered until it is.
+func newPipeWriter(path string) (*pipeWriter, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	mode := info.Mode()
+	if mode&(os.ModeSocket|os.ModeNamedPipe) == 0 {
+		return nil, ErrNoPipe
+	}
+	pw := &pipeWriter{path: path, fifo: mode&os.ModeNamedPipe != 0}
+	pw.reconnect()
+	return pw, nil
+}
+
+// Write writes a log record to the reader.
+// If the reader isn't available, the log record is buffered and written as soon as the reader is available
+// again. If the buffer is full, the oldest log record is dropped.
+// Write implements the io.Writer interface.
+func (pw *pipeWriter) Write(p []byte) (int, error) {
+	if pw.writeBacklog() {
+		if err := pw.write(p); err == nil {
+			return len(p), nil
+		}
+		pw.disconnect()
+	}
+	if len(pw.backlog) == maxBacklog {
+		pw.backlog = pw.backlog[1:]
+	}
+	pw.backlog = append(pw.backlog, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// write writes a log record to the reader. To not stall the log service if the reader doesn't read, the write
+// times out after dialTimeout.
+func (pw *pipeWriter) write(p []byte) error {
+	pw.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+	_, err := pw.conn.Write(p)
+	return err
+}
+
+// writeBacklog writes the buffered log records to the reader, reconnecting first if necessary.
+// It returns true, if the reader is connected and all buffered log records were written, false otherwise.
+func (pw *pipeWriter) writeBacklog() bool {
+	if pw.conn == nil && !pw.reconnect() {
+		return false
+	}
+	for len(pw.backlog) > 0 {
+		if err := pw.write(pw.backlog[0]); err != nil {
+			pw.disconnect()
+			return false
+		}
+		pw.backlog = pw.backlog[1:]
+	}
+	return true
+}
+
+// reconnect tries to connect to the reader, e.g. after it was restarted.
+// Named pipes are opened non-blocking, which fails as long as no reader has opened the named pipe. Unix domain
+// sockets are connected as stream socket or, if the reader listens on a datagram socket, as datagram socket.
+// To not stall the log service, reconnect attempts are made at most once per reconnectInterval.
+func (pw *pipeWriter) reconnect() bool {
+	if time.Since(pw.lastReconnect) < reconnectInterval {
+		return false
+	}
+	pw.lastReconnect = time.Now()
+	if pw.fifo {
+		f, err := os.OpenFile(pw.path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			return false
+		}
+		pw.conn = f
+		return true
+	}
+	conn, err := net.DialTimeout("unix", pw.path, dialTimeout)
+	if err != nil {
+		if conn, err = net.DialTimeout("unixgram", pw.path, dialTimeout); err != nil {
+			return false
+		}
+	}
+	pw.conn = conn.(pipeConn)
+	return true
+}
+
+// disconnect closes the broken connection to the reader.
+func (pw *pipeWriter) disconnect() {
+	pw.conn.Close()
+	pw.conn = nil
+	pw.lastReconnect = time.Now()
+}
+
+// Close makes a last attempt to write the buffered log records and closes the connection to the reader.
+// Close implements the io.Closer interface.
+func (pw *pipeWriter) Close() error {
+	pw.lastReconnect = time.Time{}
+	pw.writeBacklog()
+	if pw.conn == nil {
+		return nil
+	}
+	err := pw.conn.Close()
+	pw.conn = nil
+	return err
+}
+
+// releasePipes closes the pipe writers of the custom log destinations registered by RegisterPipeDestination and
+// removes these log destinations.
+func (s *simpleLogService) releasePipes() {
+	for destination, c := range s.customLoggers {
+		if pw, ok := c.writer.(*pipeWriter); ok {
+			s.diagnose(pw.Close())
+			delete(s.customLoggers, destination)
+			removeBits(&s.customDestinations, destination)
+			s.namedDestinations.Delete(c.name)
+		}
+	}
+}
+
+// registerPipeDestination implements RegisterPipeDestination for the log service.
+func (s *simpleLogService) registerPipeDestination(name, path string) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	pw, err := newPipeWriter(path)
+	if err != nil {
+		return 0, wrapError(ErrConnect, err)
+	}
+	destination, err := s.registerDestination(name, pw)
+	if err != nil {
+		pw.Close()
+		return 0, err
+	}
+	return destination, nil
+}
@@ -0,0 +1,74 @@
+// Package promcollector provides a prometheus.Collector for the metrics of a simplelog log service, which is
+// registered like any other collector of the Prometheus client library, e.g. to be served by promhttp.
+// The metrics are the same as the ones served by the Handler of the metrics package of simplelog.
+//
+// The package is a module of its own, so that the simplelog module itself doesn't depend on the Prometheus client
+// library.
+package promcollector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sabitor/simplelog/metrics"
+)
+
+// Collector is a prometheus.Collector, which collects the metrics of a log service whenever it is scraped.
+type Collector struct {
+	stats        metrics.StatsFunc // retrieves the metrics of the log service
+	written      *prometheus.Desc  // number of log records written per log destination
+	bytesWritten *prometheus.Desc  // number of bytes written to all log destinations
+	dropped      *prometheus.Desc  // number of log messages dropped because the data channel was full
+	rateLimited  *prometheus.Desc  // number of log records dropped by rate limits
+	queueDepth   *prometheus.Desc  // number of log messages in the data channel
+	flushes      *prometheus.Desc  // number of flushes of buffered log records
+	rotations    *prometheus.Desc  // number of log file rotations
+	writeSeconds *prometheus.Desc  // total time spent writing log records
+}
+
+// New returns a Collector of the metrics of a log service, e.g.
+// prometheus.MustRegister(promcollector.New(simplelog.Stats)) for the default log service.
+// The stats parameter specifies the function retrieving the metrics, e.g. simplelog.Stats or the Stats method of a
+// simplelog.Logger.
+func New(stats metrics.StatsFunc) *Collector {
+	return &Collector{
+		stats:        stats,
+		written:      prometheus.NewDesc("simplelog_records_written_total", "Number of log records written per log destination.", []string{"destination"}, nil),
+		bytesWritten: prometheus.NewDesc("simplelog_bytes_written_total", "Number of bytes written to all log destinations.", nil, nil),
+		dropped:      prometheus.NewDesc("simplelog_dropped_total", "Number of log messages dropped because the data channel was full.", nil, nil),
+		rateLimited:  prometheus.NewDesc("simplelog_rate_limited_total", "Number of log records dropped by rate limits.", nil, nil),
+		queueDepth:   prometheus.NewDesc("simplelog_queue_depth", "Number of log messages in the data channel.", nil, nil),
+		flushes:      prometheus.NewDesc("simplelog_flushes_total", "Number of flushes of buffered log records.", nil, nil),
+		rotations:    prometheus.NewDesc("simplelog_rotations_total", "Number of log file rotations.", nil, nil),
+		writeSeconds: prometheus.NewDesc("simplelog_write_seconds_total", "Total time spent writing log records.", nil, nil),
+	}
+}
+
+// Describe sends the descriptors of the metrics of the log service.
+// Describe implements the prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.written, c.bytesWritten, c.dropped, c.rateLimited, c.queueDepth, c.flushes, c.rotations, c.writeSeconds} {
+		ch <- desc
+	}
+}
+
+// Collect sends the current metrics of the log service. If they can't be retrieved, e.g. because the log service
+// isn't running, an invalid metric with the error is sent, which lets the scrape report the error.
+// Collect implements the prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	st, err := c.stats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.written, err)
+		return
+	}
+	for destination, n := range st.Written {
+		ch <- prometheus.MustNewConstMetric(c.written, prometheus.CounterValue, float64(n), strconv.Itoa(destination))
+	}
+	ch <- prometheus.MustNewConstMetric(c.bytesWritten, prometheus.CounterValue, float64(st.BytesWritten))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(st.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.rateLimited, prometheus.CounterValue, float64(st.RateLimited))
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(st.QueueDepth))
+	ch <- prometheus.MustNewConstMetric(c.flushes, prometheus.CounterValue, float64(st.Flushes))
+	ch <- prometheus.MustNewConstMetric(c.rotations, prometheus.CounterValue, float64(st.Rotations))
+	ch <- prometheus.MustNewConstMetric(c.writeSeconds, prometheus.CounterValue, st.WriteTime.Seconds())
+}
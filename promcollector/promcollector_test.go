@@ -0,0 +1,50 @@
+package promcollector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sabitor/simplelog"
+)
+
+func testStats() (simplelog.ServiceStats, error) {
+	return simplelog.ServiceStats{
+		Written:      map[int]uint64{simplelog.STDOUT: 2, simplelog.FILE: 1},
+		BytesWritten: 42,
+		WriteTime:    time.Second,
+	}, nil
+}
+
+func TestCollector(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(New(testStats)); err != nil {
+		t.Fatal("Expected to register the collector - but got:", err)
+	}
+
+	expected := `# HELP simplelog_records_written_total Number of log records written per log destination.
+# TYPE simplelog_records_written_total counter
+simplelog_records_written_total{destination="1"} 2
+simplelog_records_written_total{destination="2"} 1
+# HELP simplelog_bytes_written_total Number of bytes written to all log destinations.
+# TYPE simplelog_bytes_written_total counter
+simplelog_bytes_written_total 42
+# HELP simplelog_write_seconds_total Total time spent writing log records.
+# TYPE simplelog_write_seconds_total counter
+simplelog_write_seconds_total 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "simplelog_records_written_total", "simplelog_bytes_written_total", "simplelog_write_seconds_total")
+	if err != nil {
+		t.Error("Expected metrics:", expected, "- but got:", err)
+	}
+}
+
+func TestCollectorNotRunning(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(New(simplelog.Stats))
+	if _, err := registry.Gather(); err == nil {
+		t.Error("Expected error", simplelog.ErrNotRunning, "but got", err)
+	}
+}
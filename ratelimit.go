@@ -0,0 +1,54 @@
+package simplelog
+
+import (
+	"time"
+)
+
+// rateLimiter is a token bucket to limit the number of log records written to a log destination.
+type rateLimiter struct {
+	rate    float64   // number of tokens added per second
+	burst   float64   // maximum number of tokens in the bucket
+	tokens  float64   // number of tokens currently in the bucket
+	last    time.Time // time the bucket was last refilled
+	dropped uint64    // number of log records dropped because the rate limit was exceeded
+}
+
+// newRateLimiter returns a full token bucket which allows perSecond log records per second
+// and bursts of up to burst log records.
+func newRateLimiter(perSecond, burst int) *rateLimiter {
+	return &rateLimiter{rate: float64(perSecond), burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow returns true, if a token is available to write a log record, false if the log record is dropped.
+// A nil rateLimiter allows all log records.
+func (rl *rateLimiter) allow() bool {
+	if rl == nil {
+		return true
+	}
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	if rl.tokens < 1 {
+		rl.dropped++
+		return false
+	}
+	rl.tokens--
+	return true
+}
+
+// setRateLimit implements SetRateLimit for the log service.
+func (s *simpleLogService) setRateLimit(destination, perSecond, burst int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	if perSecond < 0 || burst < 0 || (perSecond > 0 && burst == 0) {
+		return ErrInvalidRateLimit
+	}
+	return s.configure(setratelimit, &rateLimitRequest{destination: destination, perSecond: perSecond, burst: burst})
+}
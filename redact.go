@@ -0,0 +1,68 @@
+package simplelog
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// redactor is a data collection to support the redaction of sensitive data in log messages.
+type redactor struct {
+	patterns    []*regexp.Regexp // the patterns of sensitive data; redaction is disabled if empty
+	replacement string           // the text which replaces sensitive data
+}
+
+// redact replaces sensitive data in the payload and the structured field values of the log message.
+// If the payload contains sensitive data, it is replaced by the redacted text.
+func (r *redactor) redact(logMsg *logMessage) {
+	if len(r.patterns) == 0 {
+		return
+	}
+	if text := logMsg.text(); r.matches(text) {
+		logMsg.line = r.replace(text)
+		logMsg.data = nil
+		logMsg.format = ""
+	}
+	copied := false
+	for i := 1; i < len(logMsg.fields); i += 2 {
+		value := fmt.Sprint(logMsg.fields[i])
+		if !r.matches(value) {
+			continue
+		}
+		if !copied {
+			// the structured fields may be shared with the caller, hence they are copied before they are modified
+			logMsg.fields = append([]any(nil), logMsg.fields...)
+			copied = true
+		}
+		logMsg.fields[i] = r.replace(value)
+	}
+}
+
+// matches returns true, if the text contains sensitive data, false otherwise.
+func (r *redactor) matches(text string) bool {
+	for _, re := range r.patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns the text with all sensitive data replaced.
+func (r *redactor) replace(text string) string {
+	for _, re := range r.patterns {
+		text = re.ReplaceAllLiteralString(text, r.replacement)
+	}
+	return text
+}
+
+// setRedaction implements SetRedaction for the log service.
+func (s *simpleLogService) setRedaction(patterns []string, replacement string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	res, err := compileFilters(patterns)
+	if err != nil {
+		return err
+	}
+	return s.configure(setredaction, &redactionRequest{patterns: res, replacement: replacement})
+}
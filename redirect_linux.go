@@ -0,0 +1,14 @@
+package simplelog
+
+import "syscall"
+
+// dupFd returns a duplicate of the file descriptor fd.
+func dupFd(fd int) (int, error) {
+	return syscall.Dup(fd)
+}
+
+// redirectFd makes the file descriptor newfd refer to the same file as oldfd.
+// Dup3 is used, as Dup2 isn't available on all Linux architectures.
+func redirectFd(oldfd, newfd int) error {
+	return syscall.Dup3(oldfd, newfd, 0)
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package simplelog
+
+// dupFd returns a duplicate of the file descriptor fd.
+// File descriptors can't be duplicated on this platform.
+func dupFd(fd int) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// redirectFd makes the file descriptor newfd refer to the same file as oldfd.
+// File descriptors can't be redirected on this platform.
+func redirectFd(oldfd, newfd int) error {
+	return ErrNotSupported
+}
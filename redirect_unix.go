@@ -0,0 +1,15 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package simplelog
+
+import "syscall"
+
+// dupFd returns a duplicate of the file descriptor fd.
+func dupFd(fd int) (int, error) {
+	return syscall.Dup(fd)
+}
+
+// redirectFd makes the file descriptor newfd refer to the same file as oldfd.
+func redirectFd(oldfd, newfd int) error {
+	return syscall.Dup2(oldfd, newfd)
+}
@@ -0,0 +1,63 @@
+package simplelog
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// retry backoff settings
+const (
+	minRetryBackoff = 10 * time.Millisecond // wait time before the first retry of a failed write
+	maxRetryBackoff = 1 * time.Second       // maximum wait time between two retries of a failed write
+)
+
+// isTransient returns true, if the error of a write may disappear when the write is retried, e.g. if the disk is
+// full or the write timed out, false otherwise.
+func isTransient(err error) bool {
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EPIPE)
+}
+
+// writeRetry writes p to w. Transient errors are retried with exponential backoff as long as the retry window,
+// which is read atomically, isn't exceeded. Only the part of p which wasn't written yet is retried, so that the
+// order of the log records is kept. If window is nil or 0, failed writes aren't retried.
+func writeRetry(w io.Writer, p []byte, window *int64) (int, error) {
+	var deadline time.Time
+	backoff := minRetryBackoff
+	written := 0
+	for {
+		n, err := w.Write(p[written:])
+		written += n
+		if err == nil || window == nil || !isTransient(err) {
+			return written, err
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(time.Duration(atomic.LoadInt64(window)))
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return written, err
+		}
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// setRetry implements SetRetry for the log service.
+func (s *simpleLogService) setRetry(window time.Duration) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if window < 0 {
+		return ErrInvalidRetryWindow
+	}
+	atomic.StoreInt64(&s.retryWindow, int64(window))
+	return nil
+}
@@ -0,0 +1,122 @@
+package simplelog
+
+import (
+	"strings"
+)
+
+// instance denotes the logWriter interface implementation by the ringLogger type.
+func (r *ringLogger) instance() *logger {
+	if r.self == nil {
+		r.self = newLogger(r)
+	}
+	return r.self
+}
+
+// Write stores a copy of the log record in the ring buffer, replacing the oldest log record if it is full.
+// Write implements the io.Writer interface.
+func (r *ringLogger) Write(p []byte) (int, error) {
+	r.ring[r.ringNext] = append(r.ring[r.ringNext][:0], p...)
+	r.ringNext = (r.ringNext + 1) % len(r.ring)
+	if r.ringCount < len(r.ring) {
+		r.ringCount++
+	}
+	return len(p), nil
+}
+
+// setupRing sets up an empty ring buffer for the given number of log records.
+func (r *ringLogger) setupRing(size int) {
+	r.ring = make([][]byte, size)
+	r.ringNext = 0
+	r.ringCount = 0
+}
+
+// last returns the last n log records of the ring buffer, oldest first.
+// If the ring buffer contains less than n log records, all log records are returned.
+func (r *ringLogger) last(n int) [][]byte {
+	if n > r.ringCount {
+		n = r.ringCount
+	}
+	if n <= 0 {
+		return nil
+	}
+	first := r.ringNext - n
+	if first < 0 {
+		first += len(r.ring)
+	}
+	records := make([][]byte, n)
+	for i := range records {
+		records[i] = r.ring[(first+i)%len(r.ring)]
+	}
+	return records
+}
+
+// lines returns the last n log records of the ring buffer, oldest first, as lines without line break.
+func (r *ringLogger) lines(n int) []string {
+	records := r.last(n)
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = strings.TrimSuffix(string(record), "\n")
+	}
+	return lines
+}
+
+// dumpRing writes the log records of the ring buffer, oldest first, to the log file and empties the ring buffer.
+func (s *simpleLogService) dumpRing() error {
+	if s.desc == nil {
+		return ErrNoLogFile
+	}
+	w := simpleLogger(&s.fileLogger).destination
+	for _, record := range s.ringLogger.last(s.ringCount) {
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	s.ringCount = 0
+	return nil
+}
+
+// writeTail keeps the log record in the tail buffer, formatted with the settings of the log file.
+func (s *simpleLogService) writeTail(logMsg *logMessage) {
+	simpleLogger(&s.tail).write(&s.fileLogger.logSettings, logMsg, s.now())
+}
+
+// tailRecords implements Tail for the log service.
+func (s *simpleLogService) tailRecords(n int) []string {
+	if !s.isActive() || n <= 0 {
+		return nil
+	}
+	req := &tailRequest{n: n}
+	if err := s.configure(tailrecords, req); err != nil {
+		return nil
+	}
+	return req.lines
+}
+
+// setupRingLog implements SetupRingLog for the log service.
+func (s *simpleLogService) setupRingLog(size int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if size <= 0 {
+		return ErrInvalidBufferSize
+	}
+	if err := s.configure(initringlog, size); err != nil {
+		return err
+	}
+	s.setRingLog(true)
+	return nil
+}
+
+// dumpRingLog implements DumpRing for the log service.
+func (s *simpleLogService) dumpRingLog() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasRingLog() {
+		return ErrNoRingLog
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	return s.configure(dumpring, nil)
+}
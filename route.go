@@ -0,0 +1,97 @@
+package simplelog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Route represents a routing rule, which writes the log records matching all its conditions to a separate log
+// file in addition to their log destinations, e.g. errors to error.log or the log records of the HTTP component
+// to access.log.
+type Route struct {
+	Component string // the component name of a Child, e.g. [http]; empty if the log records of all components match
+	Level     int    // the minimum level of matching log records; 0 if log records of all levels and without level match
+	Pattern   string // a regular expression matching the text of log records; empty if all texts match
+	File      string // the name of the log file the matching log records are written to
+}
+
+// route is a compiled routing rule.
+type route struct {
+	component string         // the component name of matching log records; empty if not used
+	level     int            // the minimum level of matching log records; 0 if not used
+	pattern   *regexp.Regexp // the regular expression matching the text of log records; nil if not used
+	file      string         // the name of the log file the matching log records are written to
+}
+
+// matches returns true, if the log message matches all conditions of the routing rule, false otherwise.
+// The component of a derived Child, e.g. [http] [auth], matches the component of its parent, e.g. [http].
+func (r *route) matches(logMsg *logMessage) bool {
+	if r.component != "" && logMsg.component != r.component && !strings.HasPrefix(logMsg.component, r.component+" ") {
+		return false
+	}
+	if r.level > 0 && logMsg.level < r.level {
+		return false
+	}
+	return r.pattern == nil || r.pattern.MatchString(logMsg.text())
+}
+
+// writeRoutes writes a log message to the log files of all routing rules it matches.
+// If several matching routing rules refer to the same log file, the log message is written only once.
+func (s *simpleLogService) writeRoutes(logMsg *logMessage) {
+	for i := range s.routes {
+		r := &s.routes[i]
+		if !r.matches(logMsg) || s.routedBefore(i, logMsg) {
+			continue
+		}
+		s.writeRoute(r.file, logMsg)
+	}
+}
+
+// writeRoute writes a log message to the log file of a routing rule with the settings of the log file.
+// The log record is counted per log file of the routing rules, not as log record of the log file. Failed writes
+// are handled like the ones of the log file.
+func (s *simpleLogService) writeRoute(file string, logMsg *logMessage) {
+	f := s.logFiles[file]
+	start := s.now()
+	n, err := simpleLogger(f).write(&s.fileLogger.logSettings, logMsg, start)
+	s.stats.WriteTime += time.Since(start)
+	s.stats.countRoute(file, n)
+	if err != nil {
+		s.writeFailed(FILE, err, logMsg)
+	}
+	s.writeFailedChunks(FILE, f.async)
+}
+
+// routedBefore returns true, if the log message matches a routing rule before the i-th one which refers to the
+// same log file, false otherwise.
+func (s *simpleLogService) routedBefore(i int, logMsg *logMessage) bool {
+	for j := 0; j < i; j++ {
+		if s.routes[j].file == s.routes[i].file && s.routes[j].matches(logMsg) {
+			return true
+		}
+	}
+	return false
+}
+
+// addRoute implements AddRoute for the log service.
+func (s *simpleLogService) addRoute(rt Route) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if rt.File == "" {
+		return ErrInvalidRoute
+	}
+	if _, ok := levelNames[rt.Level]; !ok && rt.Level != 0 {
+		return ErrUnknownLevel
+	}
+	r := route{component: rt.Component, level: rt.Level, file: rt.File}
+	if rt.Pattern != "" {
+		re, err := regexp.Compile(rt.Pattern)
+		if err != nil {
+			return err
+		}
+		r.pattern = re
+	}
+	return s.configure(addroute, r)
+}
@@ -0,0 +1,65 @@
+package simplelog
+
+import (
+	"fmt"
+)
+
+// sampler is a data collection to support sampling of log messages.
+// Of log messages with the same sample key, the first ones are logged and thereafter only every M-th one,
+// counted per second.
+type sampler struct {
+	first      int            // number of log messages per sample key which are logged; 0 if sampling is disabled
+	thereafter int            // after the first log messages, only every thereafter-th one is logged
+	counts     map[string]int // number of log messages per sample key in the current second
+}
+
+// sample returns true, if the log message should be logged, false if it is dropped by sampling.
+func (sm *sampler) sample(logMsg *logMessage) bool {
+	if sm.first == 0 {
+		return true
+	}
+	if sm.counts == nil {
+		sm.counts = make(map[string]int)
+	}
+	key := sampleKey(logMsg)
+	sm.counts[key]++
+	n := sm.counts[key]
+	if n <= sm.first {
+		return true
+	}
+	return sm.thereafter > 0 && (n-sm.first)%sm.thereafter == 0
+}
+
+// reset resets the counted log messages per sample key.
+func (sm *sampler) reset() {
+	sm.counts = nil
+}
+
+// sampleKey returns the sample key of a log message, which is the format specifier of formatted log messages
+// and the text of the first value otherwise.
+func sampleKey(logMsg *logMessage) string {
+	if logMsg.format != "" {
+		return logMsg.format
+	}
+	if logMsg.line != "" {
+		return logMsg.line
+	}
+	if len(logMsg.data) == 0 {
+		return ""
+	}
+	if v, ok := logMsg.data[0].(string); ok {
+		return v
+	}
+	return fmt.Sprint(logMsg.data[0])
+}
+
+// setSampling implements SetSampling for the log service.
+func (s *simpleLogService) setSampling(first, thereafter int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if first < 0 || thereafter < 0 {
+		return ErrInvalidSampling
+	}
+	return s.configure(setsampling, &samplingRequest{first: first, thereafter: thereafter})
+}
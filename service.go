@@ -2,55 +2,252 @@ package simplelog
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	s = new(simpleLogService) // create instance of a simplelog service
+	s = new(simpleLogService) // create instance of the default simplelog service used by the package level functions
+
+	// configTimeout is the maximum time to wait for the log service to accept or answer a config request;
+	// it is a variable to be replaced in tests.
+	configTimeout = time.Minute
 )
 
 // simpleLogService represents an object used to handle workflows triggered by the simplelog exported functions.
 type simpleLogService struct {
-	active                bool               // flag to indicate whether the log service is up and running
-	stdoutLogger                             // the stdout logger instance
-	fileLogger                               // the file logger instance
-	dataQueue             chan logMessage    // to receive log data from the caller; this channel is buffered
-	configService         chan configMessage // to receive config service requests from the caller
-	configServiceResponse chan error         // to send an error response to the caller to continue the workflow
-	stopService           chan bool          // to receive a stop service request from the caller
-	stopServiceResponse   chan struct{}      // to send a signal to the caller to continue the workflow
+	dropped               uint64                // number of dropped log messages; first field to be 64-bit aligned for atomic access
+	reportedDrops         uint64                // number of dropped log messages already reported
+	heartbeat             int64                 // point in time of the last heartbeat as Unix time in nanoseconds; accessed atomically
+	retryWindow           int64                 // the time transient write failures are retried in nanoseconds; accessed atomically
+	dropPolicy            int32                 // the policy applied to log messages while the data channel is full; accessed atomically
+	active                int32                 // flag to indicate whether the log service is up and running; accessed atomically
+	logFile               int32                 // flag to indicate whether a log file has been setup; accessed atomically
+	networkLog            int32                 // flag to indicate whether a network log has been setup; accessed atomically
+	webhookLog            int32                 // flag to indicate whether a webhook log has been setup; accessed atomically
+	ringLog               int32                 // flag to indicate whether a ring log has been setup; accessed atomically
+	journalLog            int32                 // flag to indicate whether a journal log has been setup; accessed atomically
+	stdoutLogger                                // the stdout logger instance
+	stderrLogger                                // the stderr logger instance
+	fileLogger                                  // the file logger instance
+	networkLogger                               // the network logger instance
+	webhookLogger                               // the webhook logger instance
+	ringLogger                                  // the ring logger instance
+	journalLogger                               // the journal logger instance
+	tail                  ringLogger            // the last log records written by the log service
+	subscribers           []chan string         // the channels of the subscribers of the written log records
+	subscribersMu         sync.Mutex            // synchronizes the access to the subscribers
+	internalErrors        []InternalError       // the last internal errors, the oldest first
+	internalErrorsMu      sync.Mutex            // synchronizes the access to the internal errors
+	watchdogMu            sync.Mutex            // synchronizes the access to the watchdog callback
+	watchdogCallback      WatchdogCallback      // called if the log service missed heartbeats; nil if not used
+	traceMu               sync.Mutex            // synchronizes the access to the trace extractor
+	traceExtractor        TraceExtractor        // extracts the trace context written by WriteCtx; nil if not used
+	stdLog                stdLogCapture         // the settings of the standard library log package replaced by CaptureStdLog
+	stdLogMu              sync.Mutex            // synchronizes the access to stdLog
+	cmds                  []chan struct{}       // closed when stdout or stderr of a command started by LogCmd is read completely
+	cmdsMu                sync.Mutex            // synchronizes the access to cmds
+	verboseLevels         map[int]int           // the levels of the log destinations before SetVerbose; nil if not verbose
+	paused                bool                  // flag to indicate whether writing log records is paused
+	senders               sync.RWMutex          // read-locked while a log message is sent; locked while the log service is started or stopped or the data channel is replaced
+	stopped               bool                  // flag to indicate whether the log service was stopped; its settings are reset at the next start
+	customLoggers         map[int]*customLogger // the custom logger instances by log destination bit
+	customDestinations    int32                 // the combination of all registered custom log destination bits; accessed atomically
+	callerDestinations    int32                 // the combination of all log destination bits whose prefix contains the caller placeholder; accessed atomically
+	goidDestinations      int32                 // the combination of all log destination bits whose prefix contains the goid placeholder; accessed atomically
+	hooks                 []Hook                // the hooks called for each log record before it is written
+	errorHandler          ErrorHandler          // called for each log record which couldn't be written; nil if not used
+	fallbacks             map[int]int           // the fallback log destinations by the log destination bit whose writes failed
+	fallingBack           bool                  // flag to indicate whether a log record is written to its fallback log destinations
+	sampler               sampler               // the sampler of log messages
+	dedup                 deduplicator          // the suppression of consecutive identical log messages
+	redactor              redactor              // the redaction of sensitive data in log messages
+	diskGuard             diskGuard             // the detection of low disk space on the file system of the log file
+	maxRecordSize         int                   // the maximum size of the text of log messages in bytes; 0 if not limited
+	summary               statsSummary          // the periodic summary of the metrics of the log service
+	heartbeatRecord       heartbeatRecord       // the log record written periodically while no other log records are written
+	runFooter             bool                  // flag to indicate whether the run footer is written to the log file when the log service is stopped
+	location              *time.Location        // the time zone of the timestamps of log records; nil if local time is used
+	started               time.Time             // the point in time the log service was started
+	seq                   uint64                // the sequence number of the last log record
+	components            map[string]*Child     // the named components returned by GetLogger
+	componentsMu          sync.Mutex            // synchronizes the access to the named components
+	routes                []route               // the routing rules of log records to separate log files
+	logFiles              fileLoggers           // the additional log files of routing rules and named log files
+	namedDestinations     sync.Map              // the log destination bits of custom log destinations and named log files by name
+	stats                 ServiceStats          // the metrics of the log service
+	overflow              overflowBuffer        // the overflow file for log messages spilled while the data channel is full
+	dataQueue             chan logMessage       // to receive log data from the caller; this channel is buffered
+	priorityQueue         chan logMessage       // to receive log data of level ERROR or above, which is written preferentially
+	configService         chan configMessage    // to receive config service requests from the caller
+	configServiceResponse chan error            // to send an error response to the caller to continue the workflow
+	stopService           chan bool             // to receive a stop service request from the caller
+	stopServiceResponse   chan struct{}         // to send a signal to the caller to continue the workflow
+}
+
+// loadFlag returns the state of a flag of the log service, which is accessed atomically.
+func loadFlag(flag *int32) bool {
+	return atomic.LoadInt32(flag) == 1
+}
+
+// storeFlag sets the state of a flag of the log service, which is accessed atomically.
+func storeFlag(flag *int32, state bool) {
+	var v int32
+	if state {
+		v = 1
+	}
+	atomic.StoreInt32(flag, v)
+}
+
+// loadBits returns the log destination bits of a combination of log destinations, which is accessed atomically.
+func loadBits(bits *int32) int {
+	return int(atomic.LoadInt32(bits))
+}
+
+// addBits adds log destination bits to a combination of log destinations, which is accessed atomically.
+func addBits(bits *int32, destination int) {
+	for {
+		old := atomic.LoadInt32(bits)
+		if atomic.CompareAndSwapInt32(bits, old, old|int32(destination)) {
+			return
+		}
+	}
+}
+
+// removeBits removes log destination bits from a combination of log destinations, which is accessed atomically.
+func removeBits(bits *int32, destination int) {
+	for {
+		old := atomic.LoadInt32(bits)
+		if atomic.CompareAndSwapInt32(bits, old, old&^int32(destination)) {
+			return
+		}
+	}
 }
 
 // isActive returns true, if the log service is up and running, false otherwise.
 func (s *simpleLogService) isActive() bool {
-	return s.active
+	return loadFlag(&s.active)
 }
 
 // setActive sets the active flag of the log service.
 func (s *simpleLogService) setActive(state bool) {
-	s.active = state
+	storeFlag(&s.active, state)
+}
+
+// hasLogFile returns true, if a log file has been setup, false otherwise.
+func (s *simpleLogService) hasLogFile() bool {
+	return loadFlag(&s.logFile)
+}
+
+// setLogFile sets the log file flag of the log service.
+func (s *simpleLogService) setLogFile(state bool) {
+	storeFlag(&s.logFile, state)
+}
+
+// hasNetworkLog returns true, if a network log has been setup, false otherwise.
+func (s *simpleLogService) hasNetworkLog() bool {
+	return loadFlag(&s.networkLog)
+}
+
+// setNetworkLog sets the network log flag of the log service.
+func (s *simpleLogService) setNetworkLog(state bool) {
+	storeFlag(&s.networkLog, state)
+}
+
+// hasWebhookLog returns true, if a webhook log has been setup, false otherwise.
+func (s *simpleLogService) hasWebhookLog() bool {
+	return loadFlag(&s.webhookLog)
+}
+
+// setWebhookLog sets the webhook log flag of the log service.
+func (s *simpleLogService) setWebhookLog(state bool) {
+	storeFlag(&s.webhookLog, state)
+}
+
+// hasJournalLog returns true, if a journal log has been setup, false otherwise.
+func (s *simpleLogService) hasJournalLog() bool {
+	return loadFlag(&s.journalLog)
+}
+
+// setJournalLog sets the journal log flag of the log service.
+func (s *simpleLogService) setJournalLog(state bool) {
+	storeFlag(&s.journalLog, state)
+}
+
+// hasRingLog returns true, if a ring log has been setup, false otherwise.
+func (s *simpleLogService) hasRingLog() bool {
+	return loadFlag(&s.ringLog)
+}
+
+// setRingLog sets the ring log flag of the log service.
+func (s *simpleLogService) setRingLog(state bool) {
+	storeFlag(&s.ringLog, state)
 }
 
 // instance denotes the logWriter interface implementation by the stdoutLogger type.
 func (sl *stdoutLogger) instance() *logger {
 	if sl.self == nil {
-		sl.self = newLogger(os.Stdout)
+		f := consoleFile(os.Stdout)
+		sl.async = newAsyncWriter(f, nil)
+		sl.writer = bufio.NewWriter(sl.async)
+		sl.self = newTerminalLogger(f, sl.writer)
+	}
+	return sl.self
+}
+
+// instance denotes the logWriter interface implementation by the stderrLogger type.
+func (sl *stderrLogger) instance() *logger {
+	if sl.self == nil {
+		f := consoleFile(os.Stderr)
+		sl.async = newAsyncWriter(f, nil)
+		sl.writer = bufio.NewWriter(sl.async)
+		sl.self = newTerminalLogger(f, sl.writer)
 	}
 	return sl.self
 }
 
+// instance denotes the logWriter interface implementation by the customLogger type.
+func (c *customLogger) instance() *logger {
+	if c.file != nil {
+		// the log records of a named log file are written to its fileLogger
+		return c.file.instance()
+	}
+	if c.self == nil {
+		c.self = newLogger(c.writer)
+	}
+	return c.self
+}
+
 // instance denotes the logWriter interface implementation by the fileLogger type.
 func (f *fileLogger) instance() *logger {
 	if f.self == nil {
 		if f.desc == nil {
 			panic(sg004)
 		}
-		f.writer = bufio.NewWriter(f.desc)
+		f.lock.file = f.desc
+		f.lock.pending = f.lock.pending[:0]
+		f.async = newAsyncWriter(&f.lock, f.retryWindow)
+		f.writer = bufio.NewWriter(f.async)
 		// f.writer = bufio.NewWriterSize(f.desc, 10000000)
-		f.self = newLogger(f.writer)
-		f.desc.WriteString("\n")
+		f.audit.w = f.writer
+		if f.audit.enabled {
+			f.audit.resume(f.desc.Name())
+		}
+		f.self = newLogger(&f.audit)
+		if f.header != "" {
+			// the run header is written only once, not after a rotation or reopening of the log file
+			f.audit.Write([]byte(f.header + "\n"))
+			f.header = ""
+		} else {
+			f.desc.WriteString("\n")
+		}
 	}
 	return f.self
 }
@@ -61,53 +258,209 @@ func simpleLogger(lw logWriter) *logger {
 }
 
 // setupLogFile creates and opens the log file.
+// The current log file is kept if the log file can't be opened.
+// An error of the released log file is returned, but doesn't prevent the new log file from being used.
 func (f *fileLogger) setupLogFile(flag int, logName string) error {
-	var err error
-	f.desc, err = os.OpenFile(logName, flag, 0644)
+	desc, err := os.OpenFile(logName, flag, 0644)
+	if err != nil {
+		return err
+	}
+	if f.desc != nil {
+		// the log file is set up again
+		err = f.releaseFileLogger(false)
+	}
+	f.desc = desc
+	f.logName = logName
+	f.lastSize = 0
 	return err
 }
 
 // releaseFileLogger releases all fileLogger resources.
+// The name of the log file is kept, so that it can be reopened.
+// If log records couldn't be written by the worker goroutine, the log file is released anyway and the error
+// of the failed write is returned.
 func (f *fileLogger) releaseFileLogger(archive bool) error {
-	var err error
+	if f.desc == nil {
+		return nil
+	}
+	var writeErr error
 	if f.self != nil {
 		if f.writer.Buffered() >= 0 {
 			// only do the flush when the buffer has data to be written
 			f.writer.Flush()
 		}
+		writeErr = f.async.close()
 	}
-	if err = f.desc.Close(); err != nil {
+	if f.durable {
+		f.desc.Sync()
+	}
+	desc := f.desc
+	f.writer = nil
+	f.async = nil
+	f.desc = nil
+	f.self = nil
+	if err := desc.Close(); err != nil {
 		return err
 	}
 	if archive {
-		if err = s.archiveLogFile(s.desc.Name()); err != nil {
+		if err := f.archiveLogFile(desc.Name(), time.Now().Format("20060102150405")); err != nil {
 			return err
 		}
 	}
-	f.writer = nil
-	f.desc = nil
-	f.self = nil
-	return err
+	return writeErr
+}
+
+// flushLogFile writes the buffered log records to the log file.
+// In durable mode, the log file is synced to stable storage afterwards.
+func (f *fileLogger) flushLogFile() error {
+	f.unsynced = 0
+	if f.writer == nil || f.writer.Buffered() == 0 {
+		// only do the flush when the buffer has data to be written
+		return nil
+	}
+	if err := f.writer.Flush(); err != nil {
+		return err
+	}
+	if f.durable {
+		if err := f.async.wait(); err != nil {
+			return err
+		}
+		return f.desc.Sync()
+	}
+	return nil
+}
+
+// waitLogFile waits until the worker goroutine of the log file has written all log records passed to it.
+func (f *fileLogger) waitLogFile() error {
+	if f.async == nil {
+		return nil
+	}
+	return f.async.wait()
+}
+
+// checkLogFile checks whether the log file was removed, replaced or truncated externally.
+// It returns how the log file was modified, or an empty string if it wasn't.
+func (f *fileLogger) checkLogFile() (string, error) {
+	if f.desc == nil {
+		return "", nil
+	}
+	info, err := os.Stat(f.desc.Name())
+	if os.IsNotExist(err) {
+		return "removed", nil
+	} else if err != nil {
+		return "", err
+	}
+	current, err := f.desc.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !os.SameFile(info, current) {
+		return "replaced", nil
+	}
+	size := f.lastSize
+	f.lastSize = info.Size()
+	if info.Size() < size {
+		return "truncated", nil
+	}
+	return "", nil
+}
+
+// recoveredMessage is the text of the log record which notifies about the recovery of the log file.
+const recoveredMessage = "log file %s was %s externally and has been reopened"
+
+// recoverLogFile reopens the log file, if it was removed, replaced or truncated externally.
+// Otherwise, the log records would be written to a removed file or at an offset beyond the end of the file.
+// A log record of level WARN notifies about the recovery.
+func (s *simpleLogService) recoverLogFile() {
+	modification, err := s.checkLogFile()
+	if err != nil || modification == "" {
+		s.diagnose(err)
+		return
+	}
+	logName := s.fileLogger.logName
+	if err = s.changeLogFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, logName); err != nil {
+		s.diagnose(err)
+		return
+	}
+	notice := logMessage{destination: FILE, level: WARN, data: []any{fmt.Sprintf(recoveredMessage, logName, modification)}}
+	s.writeRecord(&notice)
 }
 
 // archiveLogFile archives the log file.
-func (f *fileLogger) archiveLogFile(logFileName string) error {
-	var err error
-	t := time.Now()
-	formatted := fmt.Sprintf("%d%02d%02d%02d%02d%02d", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
-	logArchiveName := logFileName + "_" + formatted
-	err = os.Rename(logFileName, logArchiveName)
-	return err
+// The log file is moved to the archive directory and named according to the archive name template,
+// by default <log file name>_<ts>. Afterwards, the archive hook is called with the path of the archive.
+func (f *fileLogger) archiveLogFile(logFileName, ts string) error {
+	logArchiveName, err := f.archivePath(logFileName, ts)
+	if err != nil {
+		return err
+	}
+	if err = moveFile(logFileName, logArchiveName); err != nil {
+		return err
+	}
+	if f.compressArchives {
+		if logArchiveName, err = compressFile(logArchiveName); err != nil {
+			return err
+		}
+	}
+	if f.archiveHook != nil {
+		f.archiveHook(logArchiveName)
+	}
+	f.uploader.enqueue(logArchiveName)
+	return nil
+}
+
+// rotateLogFile rotates the log file.
+// The log file is moved to the archive directory and named according to the archive name template, by default
+// <log file name>_<suffix>, and a new log file with the same name is created and used.
+// If the log file can't be archived, it is reopened and continued, so that no log records are lost, and the
+// archive error is returned. An error of the released log file is returned first.
+func (f *fileLogger) rotateLogFile(suffix string) error {
+	logFileName := f.logName
+	releaseErr := f.releaseFileLogger(false)
+	archiveErr := f.archiveLogFile(logFileName, suffix)
+	if err := f.setupLogFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, logFileName); err != nil {
+		return err
+	}
+	if releaseErr != nil {
+		return releaseErr
+	}
+	return archiveErr
+}
+
+// nextRotation returns the point in time of the next log file rotation after t.
+// The rotation points in time are aligned to multiples of the interval since midnight, e.g. an interval
+// of 24 hours rotates at midnight and an interval of 1 hour at every full hour.
+func nextRotation(t time.Time, interval time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight).Truncate(interval) + interval)
+}
+
+// rotationSuffix returns the suffix of a rotated log file, which is the start time of the rotated period.
+// The precision of the suffix depends on the interval, e.g. yyyymmdd for daily and yyyymmddHH for hourly rotation.
+func rotationSuffix(start time.Time, interval time.Duration) string {
+	switch {
+	case interval%(24*time.Hour) == 0:
+		return start.Format("20060102")
+	case interval%time.Hour == 0:
+		return start.Format("2006010215")
+	default:
+		return start.Format("200601021504")
+	}
 }
 
 // changeLogFile changes the name of the log file.
+// If the new log file can't be opened, the current log file is kept.
+// An error of the released log file is returned, but doesn't prevent the new log file from being used.
 func (f *fileLogger) changeLogFile(flag int, newLogName string) error {
-	var err error
-	// release old fileLogger resources
-	if err = f.releaseFileLogger(false); err != nil {
+	desc, err := os.OpenFile(newLogName, flag, 0644)
+	if err != nil {
 		return err
 	}
-	err = f.setupLogFile(flag, newLogName)
+	// release old fileLogger resources
+	err = f.releaseFileLogger(false)
+	f.desc = desc
+	f.logName = newLogName
+	f.lastSize = 0
 	return err
 }
 
@@ -130,46 +483,387 @@ func (s *simpleLogService) run(serviceRunning chan<- bool) {
 
 	defer close(s.stopServiceResponse)
 
-	// ticker to periodically trigger a flush of the log file buffer
-	flushBufferInterval := time.NewTicker(1000 * time.Millisecond)
+	// ticker to periodically trigger a flush of the log file buffer, which is also the heartbeat of the log service
+	flushBufferInterval := time.NewTicker(heartbeatInterval)
+	defer flushBufferInterval.Stop()
+	s.beat()
+
+	// timer to trigger the time-based rotation of the log file
+	var rotationTimer *time.Timer
+	var rotationDue <-chan time.Time // nil, if the log file isn't rotated
+	var rotationTime time.Time       // point in time of the next log file rotation
+	scheduleRotation := func() {
+		if rotationTimer != nil {
+			rotationTimer.Stop()
+			rotationDue = nil
+		}
+		if s.rotation > 0 {
+			rotationTime = nextRotation(s.now(), s.rotation)
+			rotationTimer = time.NewTimer(time.Until(rotationTime))
+			rotationDue = rotationTimer.C
+		}
+	}
+	scheduleRotation()
+	defer func() {
+		if rotationTimer != nil {
+			rotationTimer.Stop()
+		}
+	}()
+
+	// ticker to periodically write a summary of the metrics of the log service
+	var summaryTicker *time.Ticker
+	var summaryDue <-chan time.Time // nil, if no summaries are written
+	scheduleSummary := func() {
+		if summaryTicker != nil {
+			summaryTicker.Stop()
+			summaryTicker, summaryDue = nil, nil
+		}
+		if s.summary.interval > 0 {
+			summaryTicker = time.NewTicker(s.summary.interval)
+			summaryDue = summaryTicker.C
+		}
+	}
+	defer func() {
+		if summaryTicker != nil {
+			summaryTicker.Stop()
+		}
+	}()
+
+	// ticker to periodically check whether a heartbeat record is written
+	var heartbeatRecordTicker *time.Ticker
+	var heartbeatRecordDue <-chan time.Time // nil, if no heartbeat records are written
+	scheduleHeartbeatRecord := func() {
+		if heartbeatRecordTicker != nil {
+			heartbeatRecordTicker.Stop()
+			heartbeatRecordTicker, heartbeatRecordDue = nil, nil
+		}
+		if s.heartbeatRecord.interval > 0 {
+			heartbeatRecordTicker = time.NewTicker(s.heartbeatRecord.interval)
+			heartbeatRecordDue = heartbeatRecordTicker.C
+		}
+	}
+	defer func() {
+		if heartbeatRecordTicker != nil {
+			heartbeatRecordTicker.Stop()
+		}
+	}()
 
 	// service loop
 	for {
 		select {
 		case serviceRunning <- true:
 		case archivelog := <-s.stopService:
-			flush()
-			s.releaseFileLogger(archivelog)
+			s.paused = false // write the log messages kept while the log service is paused
+			s.flush()
+			s.summarizeRepeated()
+			s.reportDrops()
+			s.writeFooter()
+			// failed writes of the log file are handled while the fallback log destinations are still available
+			s.diagnose(s.flushLogFile())
+			s.waitLogFile()
+			s.writeFailedChunks(FILE, s.fileLogger.async)
+			s.diagnose(s.logFiles.flush())
+			s.logFiles.wait()
+			s.writeFailedLogFiles()
+			s.releaseConsole()
+			s.diagnose(s.overflow.release())
+			s.diagnose(s.releaseFileLogger(archivelog))
+			s.uploader.release()
+			s.releaseLogFiles()
+			s.releaseNetworkLogger()
+			s.releaseWebhookLogger()
+			s.diagnose(s.releaseJournalLogger())
+			s.releaseSinks()
+			s.releasePipes()
+			s.releaseSubscribers()
 			return
+		case logData = <-s.priorityQueue:
+			s.trackQueue()
+			logData.restore()
+			s.writeMessage(&logData)
+			s.drain(&logData)
 		case logData = <-s.dataQueue:
-			writeMessage(&logData)
-		case <-flushBufferInterval.C:
-			if s.writer != nil {
-				// only do the flush when the buffer has data to be written
-				if s.writer.Buffered() > 0 {
-					s.writer.Flush()
-				}
+			s.trackQueue()
+			logData.restore()
+			s.writeMessage(&logData)
+			s.drain(&logData)
+		case <-rotationDue:
+			if s.desc != nil {
+				s.flush()
+				s.stats.Rotations++
+				s.diagnose(s.rotateLogFile(rotationSuffix(rotationTime.Add(-s.rotation), s.rotation)))
 			}
+			scheduleRotation()
+		case <-summaryDue:
+			s.writeSummary()
+		case <-heartbeatRecordDue:
+			s.writeHeartbeatRecord()
+		case <-flushBufferInterval.C:
+			s.beat()
+			// sampling counts log messages per second
+			s.sampler.reset()
+			s.reportDrops()
+			s.replayOverflow()
+			s.flushBuffers()
+			s.recoverLogFile()
+			s.checkDiskSpace(false)
 		case cfgData = <-s.configService:
 			switch cfgData.task {
 			case initlog:
-				flag := cfgData.data[logflag].(int)
-				logName := cfgData.data[logfilename].(string)
-				err := s.setupLogFile(flag, logName)
-				s.configServiceResponse <- err
+				req := cfgData.request.(*logFileRequest)
+				s.configServiceResponse <- s.setupLogFile(req.flag, req.name)
 			case switchlog:
-				flush()
-				flag := cfgData.data[logflag].(int)
-				newLogName := cfgData.data[logfilename].(string)
-				err := s.changeLogFile(flag, newLogName)
+				s.flush()
+				req := cfgData.request.(*logFileRequest)
+				s.configServiceResponse <- s.changeLogFile(req.flag, req.name)
+			case reopenlog:
+				s.flush()
+				// the log file is reopened by its name, as it might not be opened after a failed rotation
+				err := s.changeLogFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, s.fileLogger.logName)
+				if filesErr := s.logFiles.reopen(); err == nil {
+					err = filesErr
+				}
 				s.configServiceResponse <- err
 			case setprefix:
-				if logPrefix, ok := cfgData.data[stdoutlogprefix]; ok {
-					s.stdoutLogger.prefix = logPrefix.([]string)
-				} else if logPrefix, ok = cfgData.data[filelogprefix]; ok {
-					s.fileLogger.prefix = logPrefix.([]string)
+				req := cfgData.request.(*prefixRequest)
+				s.settings(req.destination).prefix = req.prefix
+				req.items = s.settings(req.destination).prefixItems()
+				s.configServiceResponse <- nil
+			case setlevelprefix:
+				req := cfgData.request.(*prefixRequest)
+				settings := s.settings(req.destination)
+				if len(req.prefix) > 0 {
+					if settings.levelPrefix == nil {
+						settings.levelPrefix = make(map[int][]string)
+					}
+					settings.levelPrefix[req.level] = req.prefix
 				} else {
-					panic(sg003)
+					delete(settings.levelPrefix, req.level)
+				}
+				req.items = settings.prefixItems()
+				s.configServiceResponse <- nil
+			case setlevel:
+				req := cfgData.request.(*settingRequest)
+				s.settings(req.destination).level = req.value
+				s.configServiceResponse <- nil
+			case setformat:
+				req := cfgData.request.(*settingRequest)
+				s.settings(req.destination).format = req.value
+				s.configServiceResponse <- nil
+			case setsampling:
+				req := cfgData.request.(*samplingRequest)
+				s.sampler = sampler{first: req.first, thereafter: req.thereafter}
+				s.configServiceResponse <- nil
+			case setbuffersize:
+				// no log messages are sent meanwhile, so the data channel is empty after the flush
+				s.flush()
+				s.dataQueue = make(chan logMessage, cfgData.request.(int))
+				s.configServiceResponse <- nil
+			case getstats:
+				*cfgData.request.(*ServiceStats) = s.snapshot()
+				s.configServiceResponse <- nil
+			case synclog:
+				s.flush()
+				s.summarizeRepeated()
+				s.flushBuffers()
+				err := s.waitConsole()
+				if fileErr := s.waitLogFile(); fileErr != nil {
+					err = fileErr
+				}
+				s.writeFailedChunks(FILE, s.fileLogger.async)
+				if filesErr := s.logFiles.wait(); filesErr != nil {
+					err = filesErr
+				}
+				s.writeFailedLogFiles()
+				s.configServiceResponse <- err
+			case setdeduplication:
+				s.summarizeRepeated()
+				s.dedup = deduplicator{enabled: cfgData.request.(bool)}
+				s.configServiceResponse <- nil
+			case setratelimit:
+				req := cfgData.request.(*rateLimitRequest)
+				settings := s.settings(req.destination)
+				settings.limiter = nil
+				if req.perSecond > 0 {
+					settings.limiter = newRateLimiter(req.perSecond, req.burst)
+				}
+				s.configServiceResponse <- nil
+			case setfilter:
+				req := cfgData.request.(*filterRequest)
+				settings := s.settings(req.destination)
+				settings.include = req.include
+				settings.exclude = req.exclude
+				s.configServiceResponse <- nil
+			case setmultiline:
+				req := cfgData.request.(*multilineRequest)
+				settings := s.settings(req.destination)
+				settings.multiline = req.mode
+				settings.indent = req.indent
+				s.configServiceResponse <- nil
+			case setmaxrecordsize:
+				s.maxRecordSize = cfgData.request.(int)
+				s.configServiceResponse <- nil
+			case setheartbeatrecord:
+				s.heartbeatRecord = *cfgData.request.(*heartbeatRecord)
+				s.heartbeatRecord.idle = true
+				scheduleHeartbeatRecord()
+				s.configServiceResponse <- nil
+			case setrunfooter:
+				s.runFooter = cfgData.request.(bool)
+				s.configServiceResponse <- nil
+			case setrunheader:
+				s.fileLogger.header = s.expandHeader(cfgData.request.(string))
+				s.configServiceResponse <- nil
+			case setstatssummary:
+				s.summary = *cfgData.request.(*statsSummary)
+				s.summary.last = s.snapshot()
+				scheduleSummary()
+				s.configServiceResponse <- nil
+			case settagfilter:
+				req := cfgData.request.(*tagFilterRequest)
+				settings := s.settings(req.destination)
+				settings.includeTags = req.include
+				settings.excludeTags = req.exclude
+				s.configServiceResponse <- nil
+			case addhook:
+				s.hooks = append(s.hooks, cfgData.request.(Hook))
+				s.configServiceResponse <- nil
+			case setcolor:
+				req := cfgData.request.(*colorRequest)
+				s.settings(req.destination).color = req.enabled
+				s.configServiceResponse <- nil
+			case setdurability:
+				req := cfgData.request.(*durabilityRequest)
+				s.durable = req.enabled
+				s.syncRate = req.syncRate
+				s.configServiceResponse <- s.flushLogFile()
+			case setarchive:
+				req := cfgData.request.(*archiveRequest)
+				s.archiveDir = req.dir
+				s.archiveTemplate = req.template
+				s.configServiceResponse <- nil
+			case setarchivehook:
+				s.archiveHook = cfgData.request.(func(string))
+				s.configServiceResponse <- nil
+			case setaudit:
+				err := s.flushLogFile()
+				if err == nil {
+					err = s.waitLogFile()
+				}
+				req := cfgData.request.(*auditRequest)
+				s.audit.enabled = req.enabled
+				s.audit.key = req.key
+				if err == nil && s.audit.enabled {
+					err = s.audit.resume(s.fileLogger.logName)
+				}
+				s.configServiceResponse <- err
+			case setfilelock:
+				err := s.flushLogFile()
+				if err == nil {
+					err = s.waitLogFile()
+				}
+				enabled := cfgData.request.(bool)
+				if err == nil && enabled && s.desc == nil {
+					err = ErrNoLogFile
+				} else if err == nil && enabled {
+					// check whether the log file can be locked at all
+					if err = lockFile(s.desc); err == nil {
+						err = unlockFile(s.desc)
+					}
+				}
+				if err == nil {
+					s.lock.enabled = enabled
+				}
+				s.configServiceResponse <- err
+			case setredaction:
+				req := cfgData.request.(*redactionRequest)
+				s.redactor = redactor{patterns: req.patterns, replacement: req.replacement}
+				s.configServiceResponse <- nil
+			case setarchiveuploader:
+				s.uploader.release()
+				s.uploader = nil
+				if req := cfgData.request.(*uploaderRequest); req.uploader != nil {
+					s.uploader = newArchiveUploader(req.uploader, req.removeLocal)
+				}
+				s.configServiceResponse <- nil
+			case initjournallog:
+				s.configServiceResponse <- s.setupJournal(cfgData.request.(string))
+			case initringlog:
+				s.setupRing(cfgData.request.(int))
+				s.configServiceResponse <- nil
+			case dumpring:
+				s.flush()
+				s.configServiceResponse <- s.dumpRing()
+			case pauselog:
+				s.setPaused(cfgData.request.(bool))
+				s.configServiceResponse <- nil
+			case setverbose:
+				// write the pending log messages with the levels they were written with
+				s.flush()
+				s.setVerbosity(cfgData.request.(bool))
+				s.configServiceResponse <- nil
+			case tailrecords:
+				req := cfgData.request.(*tailRequest)
+				req.lines = s.tail.lines(req.n)
+				s.configServiceResponse <- nil
+			case openlogfile:
+				req := cfgData.request.(*destinationRequest)
+				var err error
+				req.destination, err = s.addNamedLogFile(req.name, req.logName)
+				s.configServiceResponse <- err
+			case addroute:
+				r := cfgData.request.(route)
+				_, err := s.logFiles.open(r.file, s.fileLogger.retryWindow)
+				if err == nil {
+					s.routes = append(s.routes, r)
+				}
+				s.configServiceResponse <- err
+			case settimezone:
+				s.location = cfgData.request.(*time.Location)
+				scheduleRotation()
+				s.configServiceResponse <- nil
+			case setrotation:
+				s.rotation = cfgData.request.(time.Duration)
+				scheduleRotation()
+				s.configServiceResponse <- nil
+			case initnetworklog:
+				req := cfgData.request.(*networkRequest)
+				s.configServiceResponse <- s.setupConnection(req.network, req.address)
+			case resetconsole:
+				s.releaseConsole()
+				s.configServiceResponse <- nil
+			case registersink:
+				req := cfgData.request.(*destinationRequest)
+				var err error
+				req.destination, err = s.addSink(req.name, req.sink)
+				s.configServiceResponse <- err
+			case registerdestination:
+				req := cfgData.request.(*destinationRequest)
+				var err error
+				req.destination, err = s.addCustomLogger(req.name, req.writer)
+				s.configServiceResponse <- err
+			case initwebhooklog:
+				s.setupWebhook(cfgData.request.(string))
+				s.configServiceResponse <- nil
+			case seterrorhandler:
+				s.errorHandler = cfgData.request.(ErrorHandler)
+				s.configServiceResponse <- nil
+			case setdiskguard:
+				s.flush()
+				req := cfgData.request.(*diskGuardRequest)
+				s.diskGuard.minFree = req.minFree
+				s.diskGuard.mode = req.mode
+				s.checkDiskSpace(true)
+				s.configServiceResponse <- nil
+			case setfallback:
+				req := cfgData.request.(*fallbackRequest)
+				if req.fallback == 0 {
+					delete(s.fallbacks, req.destination)
+				} else {
+					if s.fallbacks == nil {
+						s.fallbacks = make(map[int]int)
+					}
+					s.fallbacks[req.destination] = req.fallback
 				}
 				s.configServiceResponse <- nil
 			}
@@ -177,28 +871,956 @@ func (s *simpleLogService) run(serviceRunning chan<- bool) {
 	}
 }
 
-// writeMessage writes data of log messages to a dedicated destination.
-func writeMessage(logMsg *logMessage) {
-	switch logMsg.destination {
+// configure sends a config request to the log service and returns the response of the log service.
+// ErrServiceTimeout is returned if the log service doesn't accept or answer the config request within
+// configTimeout, e.g. because it is stuck writing to a log destination, instead of waiting forever.
+func (s *simpleLogService) configure(task int, request any) error {
+	timer := time.NewTimer(configTimeout)
+	defer timer.Stop()
+	select {
+	case s.configService <- configMessage{task, request}:
+	case <-timer.C:
+		return ErrServiceTimeout
+	}
+	select {
+	case err := <-s.configServiceResponse:
+		return err
+	case <-timer.C:
+		// receive the late response, so that it isn't taken for the response of the next config request
+		go func(response <-chan error) {
+			<-response
+		}(s.configServiceResponse)
+		return ErrServiceTimeout
+	}
+}
+
+// settings returns the settings of a single log destination, e.g. STDOUT or FILE.
+// If the destination is unknown or a combination of log destinations, nil is returned.
+func (s *simpleLogService) settings(destination int) *logSettings {
+	switch destination {
 	case STDOUT:
-		simpleLogger(&s.stdoutLogger).write(logMsg)
+		return &s.stdoutLogger.logSettings
+	case STDERR:
+		return &s.stderrLogger.logSettings
 	case FILE:
-		simpleLogger(&s.fileLogger).write(logMsg)
-	case MULTI:
-		logMsg.destination = MULTI & STDOUT
-		simpleLogger(&s.stdoutLogger).write(logMsg)
-		logMsg.destination = MULTI & FILE
-		simpleLogger(&s.fileLogger).write(logMsg)
+		return &s.fileLogger.logSettings
+	case NETWORK:
+		return &s.networkLogger.logSettings
+	case WEBHOOK:
+		return &s.webhookLogger.logSettings
+	case RING:
+		return &s.ringLogger.logSettings
+	case JOURNAL:
+		return &s.journalLogger.logSettings
+	}
+	if c, ok := s.customLoggers[destination]; ok {
+		return &c.logSettings
+	}
+	return nil
+}
+
+// isDestination returns true, if the destination is a single log destination, false otherwise.
+// Besides the built-in log destinations, registered custom log destinations are also accepted.
+func (s *simpleLogService) isDestination(destination int) bool {
+	return s.isDestinations(destination) && destination&(destination-1) == 0
+}
+
+// isDestinations returns true, if the destination is a log destination or a combination of them, false otherwise.
+func (s *simpleLogService) isDestinations(destination int) bool {
+	return destination != 0 && destination&^(allDestinations|loadBits(&s.customDestinations)) == 0
+}
+
+// addCustomLogger adds a custom logger, which writes log records to the io.Writer w, and returns its
+// log destination bit.
+func (s *simpleLogService) addCustomLogger(name string, w io.Writer) (int, error) {
+	destination := firstCustomDestination
+	for _, c := range s.customLoggers {
+		if c.name == name {
+			return 0, ErrDestinationExists
+		}
+	}
+	for s.customLoggers[destination] != nil {
+		if destination == lastCustomDestination {
+			return 0, ErrTooManyDestinations
+		}
+		destination <<= 1
+	}
+	if s.customLoggers == nil {
+		s.customLoggers = make(map[int]*customLogger)
+	}
+	s.customLoggers[destination] = &customLogger{name: name, writer: w}
+	return destination, nil
+}
+
+// accepts returns true, if a log message passes the level threshold, the tag filters and the filters of a log
+// destination, false otherwise. If include filters are set, the text of the log message has to match at least one of them.
+// If exclude filters are set, the text of the log message must not match any of them.
+func (ls *logSettings) accepts(logMsg *logMessage) bool {
+	return ls.matches(logMsg) && ls.limiter.allow()
+}
+
+// matches returns true, if a log message passes the level threshold and the filters of the log destination,
+// false otherwise.
+func (ls *logSettings) matches(logMsg *logMessage) bool {
+	if !isLogged(logMsg.level, ls.level) || !ls.matchesTags(logMsg) {
+		return false
 	}
+	if len(ls.include) == 0 && len(ls.exclude) == 0 {
+		return true
+	}
+	text := logMsg.text()
+	for _, re := range ls.exclude {
+		if re.MatchString(text) {
+			return false
+		}
+	}
+	if len(ls.include) == 0 {
+		return true
+	}
+	for _, re := range ls.include {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
 
+// isLogged returns true, if a log message of the given level passes the level threshold, false otherwise.
+// Log messages without a level are always logged.
+func isLogged(level, threshold int) bool {
+	return level == 0 || level >= threshold
+}
+
+// writeMessage writes data of log messages to a dedicated destination.
+// If the log message is addressed to multiple log destinations, it is written to each of them.
+func (s *simpleLogService) writeMessage(logMsg *logMessage) {
+	if logMsg.batch != nil {
+		// write the log messages of the batch one after another, before any other log message
+		msgs := logMsg.unbatch()
+		for i := range msgs {
+			s.writeMessage(&msgs[i])
+		}
+		return
+	}
+	if s.paused {
+		// keep the log message in the overflow file until the log service is resumed
+		s.diagnose(s.overflow.spill(*logMsg))
+		return
+	}
+	if !s.sampler.sample(logMsg) {
+		// the log message was dropped by sampling
+		return
+	}
+	if !s.runHooks(logMsg) {
+		// the log message was dropped by a hook
+		return
+	}
+	s.redactor.redact(logMsg)
+	if s.deduplicate(logMsg) {
+		// the log message is a duplicate of the last one
+		return
+	}
+	s.writeRecord(logMsg)
+}
+
+// writeRecord writes a log message to each of its log destinations which accepts it.
+func (s *simpleLogService) writeRecord(logMsg *logMessage) {
+	if !s.diskGuard.degraded(logMsg) {
+		// the disk space is low and the log message is dropped
+		return
+	}
+	s.seq++
+	logMsg.seq = s.seq
+	s.stats.countLevel(logMsg.level)
+	if logMsg.destination&s.heartbeatRecord.destination != 0 {
+		s.heartbeatRecord.idle = false
+	}
+	logMsg.uptime = time.Since(s.started)
+	if s.maxRecordSize > 0 {
+		s.truncate(logMsg)
+	}
+	s.writeDestinations(logMsg)
+	s.writeTail(logMsg)
+	s.publish()
+	if len(s.routes) > 0 {
+		s.writeRoutes(logMsg)
+	}
+}
+
+// writeDestinations writes a log message to each of its standard and custom log destinations which accepts it.
+func (s *simpleLogService) writeDestinations(logMsg *logMessage) {
+	if logMsg.destination&STDOUT != 0 && s.stdoutLogger.accepts(logMsg) {
+		s.writeTo(STDOUT, &s.stdoutLogger, &s.stdoutLogger.logSettings, logMsg)
+	}
+	if logMsg.destination&STDERR != 0 && s.stderrLogger.accepts(logMsg) {
+		s.writeTo(STDERR, &s.stderrLogger, &s.stderrLogger.logSettings, logMsg)
+	}
+	if logMsg.destination&FILE != 0 && s.desc != nil && s.fileLogger.accepts(logMsg) {
+		s.writeTo(FILE, &s.fileLogger, &s.fileLogger.logSettings, logMsg)
+		if s.durable && s.syncRate > 0 {
+			if s.unsynced++; s.unsynced >= s.syncRate {
+				s.diagnose(s.flushLogFile())
+			}
+		}
+	}
+	if logMsg.destination&NETWORK != 0 && s.address != "" && s.networkLogger.accepts(logMsg) {
+		s.writeTo(NETWORK, &s.networkLogger, &s.networkLogger.logSettings, logMsg)
+	}
+	if logMsg.destination&WEBHOOK != 0 && s.url != "" && s.webhookLogger.accepts(logMsg) {
+		s.writeTo(WEBHOOK, &s.webhookLogger, &s.webhookLogger.logSettings, logMsg)
+	}
+	if logMsg.destination&RING != 0 && s.ring != nil && s.ringLogger.accepts(logMsg) {
+		s.writeTo(RING, &s.ringLogger, &s.ringLogger.logSettings, logMsg)
+		if logMsg.level >= ERROR && s.desc != nil {
+			// an error occurred - write the log records leading to it to the log file
+			s.diagnose(s.dumpRing())
+		}
+	}
+	if logMsg.destination&JOURNAL != 0 && s.journalLogger.conn != nil && s.journalLogger.accepts(logMsg) {
+		s.writeJournal(logMsg)
+	}
+	if logMsg.destination >= firstCustomDestination {
+		for destination := firstCustomDestination; destination <= logMsg.destination && destination <= lastCustomDestination; destination <<= 1 {
+			if c, ok := s.customLoggers[destination]; ok && logMsg.destination&destination != 0 && c.accepts(logMsg) {
+				if c.sink != nil {
+					s.writeSink(destination, c, logMsg)
+				} else {
+					s.writeTo(destination, c, &c.logSettings, logMsg)
+				}
+			}
+		}
+	}
+}
+
+// writeTo writes a log message to a single log destination and counts the written log record.
+func (s *simpleLogService) writeTo(destination int, lw logWriter, settings *logSettings, logMsg *logMessage) {
+	start := s.now()
+	n, err := simpleLogger(lw).write(settings, logMsg, start)
+	s.stats.WriteTime += time.Since(start)
+	s.stats.count(destination, n)
+	if err != nil {
+		s.writeFailed(destination, err, logMsg)
+	}
+	if destination == FILE {
+		// the log file is written by a worker goroutine, which reports its failed writes later
+		s.writeFailedChunks(FILE, s.fileLogger.async)
+	}
+}
+
+// now returns the current time in the time zone of the timestamps of log records.
+func (s *simpleLogService) now() time.Time {
+	if s.location != nil {
+		return time.Now().In(s.location)
+	}
+	return time.Now()
+}
+
+// setTimeZone implements SetTimeZone for the log service.
+func (s *simpleLogService) setTimeZone(loc *time.Location) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(settimezone, loc)
+}
+
+// drain writes the log messages, which are currently in the data channel, in one pass and the log records
+// buffered by stdout and stderr afterwards, so that they are written with a single write each.
+// To not delay config requests, log messages arriving during the pass are left to the next pass.
+// Log messages in the priority channel are written before each log message of the data channel.
+// The log message m is used to receive the log messages.
+func (s *simpleLogService) drain(m *logMessage) {
+	s.drainPriority(m)
+	for n := len(s.dataQueue); n > 0; n-- {
+		*m = <-s.dataQueue
+		m.restore()
+		s.writeMessage(m)
+		s.drainPriority(m)
+	}
+	if len(s.dataQueue) == 0 {
+		// the log service caught up with the data channel
+		s.replayOverflow()
+	}
+	s.flushConsole()
+}
+
+// drainPriority writes the log messages, which are currently in the priority channel.
+// The log message m is used to receive the log messages.
+func (s *simpleLogService) drainPriority(m *logMessage) {
+	for len(s.priorityQueue) > 0 {
+		*m = <-s.priorityQueue
+		m.restore()
+		s.writeMessage(m)
+	}
+}
+
+// flushConsole writes the log records buffered by stdout and stderr.
+func (s *simpleLogService) flushConsole() {
+	if s.stdoutLogger.writer != nil && s.stdoutLogger.writer.Buffered() > 0 {
+		s.diagnose(s.stdoutLogger.writer.Flush())
+	}
+	if s.stderrLogger.writer != nil && s.stderrLogger.writer.Buffered() > 0 {
+		s.diagnose(s.stderrLogger.writer.Flush())
+	}
+}
+
+// waitConsole waits until the worker goroutines of stdout and stderr have written all log records passed to them.
+func (s *simpleLogService) waitConsole() error {
+	var err error
+	if s.stdoutLogger.async != nil {
+		err = s.stdoutLogger.async.wait()
+	}
+	if s.stderrLogger.async != nil {
+		if stderrErr := s.stderrLogger.async.wait(); stderrErr != nil {
+			err = stderrErr
+		}
+	}
+	return err
+}
+
+// releaseConsole writes the log records buffered by stdout and stderr and stops their worker goroutines.
+func (s *simpleLogService) releaseConsole() {
+	s.flushConsole()
+	if s.stdoutLogger.async != nil {
+		s.diagnose(s.stdoutLogger.async.close())
+	}
+	if s.stderrLogger.async != nil {
+		s.diagnose(s.stderrLogger.async.close())
+	}
+	s.stdoutLogger.writer, s.stdoutLogger.async, s.stdoutLogger.self = nil, nil, nil
+	s.stderrLogger.writer, s.stderrLogger.async, s.stderrLogger.self = nil, nil, nil
+}
+
+// flushBuffers writes the log records buffered by the log destinations.
+func (s *simpleLogService) flushBuffers() {
+	s.stats.Flushes++
+	s.flushConsole()
+	s.diagnose(s.flushLogFile())
+	s.writeFailedChunks(FILE, s.fileLogger.async)
+	s.diagnose(s.logFiles.flush())
+	s.writeFailedLogFiles()
+	if len(s.backlog) > 0 {
+		// try to send log records buffered while the remote host wasn't reachable
+		s.sendBacklog()
+	}
+	if len(s.batch) > 0 {
+		// post the log records collected since the last flush
+		s.sendBatch()
+	}
+	s.flushSinks()
 }
 
 // flush flushes(writes) messages, which are still buffered in the data channel
 // and not yet wrtitten do disc.
-func flush() {
+func (s *simpleLogService) flush() {
 	var m logMessage
+	s.drainPriority(&m)
 	for len(s.dataQueue) > 0 {
 		m = <-s.dataQueue
-		writeMessage(&m)
+		m.restore()
+		s.writeMessage(&m)
+		s.drainPriority(&m)
+	}
+	s.replayOverflow()
+	s.flushConsole()
+}
+
+// setPrefix implements SetPrefix for the log service.
+func (s *simpleLogService) setPrefix(destination int, prefix ...string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	req := &prefixRequest{destination: destination, prefix: prefix}
+	if err := s.configure(setprefix, req); err != nil {
+		return err
+	}
+	s.setPrefixPlaceholders(destination, req.items)
+	return nil
+}
+
+// setPrefixForLevel implements SetPrefixForLevel for the log service.
+func (s *simpleLogService) setPrefixForLevel(destination, level int, prefix ...string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	req := &prefixRequest{destination: destination, level: level, prefix: prefix}
+	if err := s.configure(setlevelprefix, req); err != nil {
+		return err
+	}
+	s.setPrefixPlaceholders(destination, req.items)
+	return nil
+}
+
+// setPrefixPlaceholders records whether the prefix of the log destination contains the caller or goid placeholder.
+// Only for log messages to such destinations, the caller or goroutine ID is captured.
+func (s *simpleLogService) setPrefixPlaceholders(destination int, prefix []string) {
+	removeBits(&s.callerDestinations, destination)
+	removeBits(&s.goidDestinations, destination)
+	for _, v := range prefix {
+		switch v {
+		case callerTag:
+			addBits(&s.callerDestinations, destination)
+		case goidTag:
+			addBits(&s.goidDestinations, destination)
+		}
+	}
+}
+
+// setLevel implements SetLevel for the log service.
+func (s *simpleLogService) setLevel(destination int, level int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	return s.configure(setlevel, &settingRequest{destination: destination, value: level})
+}
+
+// setFormat implements SetFormat for the log service.
+func (s *simpleLogService) setFormat(destination int, format int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if format != TEXT && format != JSON && format != GELF {
+		return ErrUnknownFormat
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	return s.configure(setformat, &settingRequest{destination: destination, value: format})
+}
+
+// setRotation implements SetRotation for the log service.
+func (s *simpleLogService) setRotation(interval time.Duration) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if interval < 0 {
+		return ErrInvalidInterval
+	}
+	return s.configure(setrotation, interval)
+}
+
+// setDurability implements SetDurability for the log service.
+func (s *simpleLogService) setDurability(enabled bool, records int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if records < 0 {
+		return ErrInvalidSyncRate
+	}
+	return s.configure(setdurability, &durabilityRequest{enabled: enabled, syncRate: records})
+}
+
+// setColor implements SetColor for the log service.
+func (s *simpleLogService) setColor(destination int, enabled bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if destination != STDOUT && destination != STDERR {
+		return ErrUnknownDestination
+	}
+	return s.configure(setcolor, &colorRequest{destination: destination, enabled: enabled})
+}
+
+// setFilter implements SetFilter for the log service.
+func (s *simpleLogService) setFilter(destination int, include, exclude []string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	includeFilter, err := compileFilters(include)
+	if err != nil {
+		return err
+	}
+	excludeFilter, err := compileFilters(exclude)
+	if err != nil {
+		return err
+	}
+	return s.configure(setfilter, &filterRequest{destination: destination, include: includeFilter, exclude: excludeFilter})
+}
+
+// compileFilters compiles the regular expressions of filters.
+func compileFilters(filters []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, filter := range filters {
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// shutdown implements Shutdown for the log service.
+func (s *simpleLogService) shutdown(archivelog bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	s.waitCmds(context.Background())
+	s.releaseStdLog()
+	s.releaseOutput()
+	// wait for the log messages being sent; the log service writes each of them before it stops
+	s.senders.Lock()
+	defer s.senders.Unlock()
+	if !s.isActive() {
+		// the log service was stopped by a concurrent call
+		return ErrNotRunning
+	}
+	s.stop(archivelog)
+	s.setActive(false)
+	s.setLogFile(false)
+	s.setNetworkLog(false)
+	s.setWebhookLog(false)
+	s.setRingLog(false)
+	s.setJournalLog(false)
+	s.stopped = true
+	return nil
+}
+
+// setBufferSize implements SetBufferSize for the log service.
+func (s *simpleLogService) setBufferSize(bufferSize int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if bufferSize < 0 {
+		return ErrInvalidBufferSize
+	}
+	// wait for the log messages being sent, so that no sender still uses the replaced data channel
+	s.senders.Lock()
+	defer s.senders.Unlock()
+	if !s.isActive() {
+		// the log service was stopped by a concurrent call
+		return ErrNotRunning
+	}
+	return s.configure(setbuffersize, bufferSize)
+}
+
+// shutdownContext implements ShutdownContext for the log service.
+func (s *simpleLogService) shutdownContext(ctx context.Context, archivelog bool) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	s.waitCmds(ctx)
+	s.releaseStdLog()
+	s.releaseOutput()
+	if !s.lockSenders(ctx) {
+		return s.abandon(), ctx.Err()
+	}
+	defer s.senders.Unlock()
+	if !s.isActive() {
+		// the log service was stopped by a concurrent call
+		return 0, ErrNotRunning
+	}
+	select {
+	case s.stopService <- archivelog:
+		select {
+		case <-s.stopServiceResponse:
+		case <-ctx.Done():
+			return s.abandon(), ctx.Err()
+		}
+	case <-ctx.Done():
+		return s.abandon(), ctx.Err()
+	}
+	s.setActive(false)
+	s.setLogFile(false)
+	s.setNetworkLog(false)
+	s.setWebhookLog(false)
+	s.setRingLog(false)
+	s.setJournalLog(false)
+	s.stopped = true
+	return 0, nil
+}
+
+// lockSenders waits for the log messages being sent, but gives up when the context expires.
+// It returns true, if the senders are locked, false otherwise.
+func (s *simpleLogService) lockSenders(ctx context.Context) bool {
+	locked := make(chan struct{})
+	go func() {
+		s.senders.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return true
+	case <-ctx.Done():
+		go func() {
+			// release the lock as soon as it is acquired, as the log service is abandoned
+			<-locked
+			s.senders.Unlock()
+		}()
+		return false
+	}
+}
+
+// abandon marks the log service as stopped without waiting for the service goroutine, which is stuck.
+// It returns the number of log messages left in the data channel.
+func (s *simpleLogService) abandon() int {
+	s.setActive(false)
+	s.setLogFile(false)
+	s.setNetworkLog(false)
+	s.setWebhookLog(false)
+	s.setRingLog(false)
+	s.setJournalLog(false)
+	return len(s.dataQueue) + len(s.priorityQueue)
+}
+
+// reset restores the default settings of a log service which was stopped before, so that the next start of the
+// log service is like the first one. The named components returned by GetLogger are kept.
+func (s *simpleLogService) reset() {
+	atomic.StoreUint64(&s.dropped, 0)
+	s.reportedDrops = 0
+	atomic.StoreInt32(&s.dropPolicy, BLOCK)
+	s.stdoutLogger = stdoutLogger{}
+	s.stderrLogger = stderrLogger{}
+	s.fileLogger = fileLogger{}
+	s.networkLogger = networkLogger{}
+	s.webhookLogger = webhookLogger{}
+	s.ringLogger = ringLogger{}
+	s.journalLogger = journalLogger{}
+	s.verboseLevels = nil
+	s.customLoggers = nil
+	atomic.StoreInt32(&s.customDestinations, 0)
+	atomic.StoreInt32(&s.callerDestinations, 0)
+	atomic.StoreInt32(&s.goidDestinations, 0)
+	atomic.StoreInt64(&s.retryWindow, 0)
+	s.hooks = nil
+	s.errorHandler = nil
+	s.fallbacks = nil
+	s.watchdogMu.Lock()
+	s.watchdogCallback = nil
+	s.watchdogMu.Unlock()
+	s.traceMu.Lock()
+	s.traceExtractor = nil
+	s.traceMu.Unlock()
+	s.internalErrorsMu.Lock()
+	s.internalErrors = nil
+	s.internalErrorsMu.Unlock()
+	s.sampler = sampler{}
+	s.dedup = deduplicator{}
+	s.redactor = redactor{}
+	s.diskGuard = diskGuard{}
+	s.maxRecordSize = 0
+	s.summary = statsSummary{}
+	s.runFooter = false
+	s.heartbeatRecord = heartbeatRecord{}
+	s.location = nil
+	s.namedDestinations.Range(func(name, _ any) bool {
+		s.namedDestinations.Delete(name)
+		return true
+	})
+	s.stats = ServiceStats{}
+}
+
+// startup implements Startup for the log service.
+func (s *simpleLogService) startup(bufferSize int) error {
+	s.senders.Lock()
+	defer s.senders.Unlock()
+	if s.isActive() {
+		return ErrAlreadyRunning
+	}
+	if s.stopped {
+		s.reset()
+		s.stopped = false
+	}
+	s.dataQueue = make(chan logMessage, bufferSize)
+	s.priorityQueue = make(chan logMessage, priorityBufferSize)
+	s.configService = make(chan configMessage)
+	s.configServiceResponse = make(chan error)
+	s.stopService = make(chan bool)
+	s.stopServiceResponse = make(chan struct{})
+	s.started = time.Now()
+	s.seq = 0
+	s.tail.setupRing(tailSize)
+	s.fileLogger.retryWindow = &s.retryWindow
+	s.networkLogger.retryWindow = &s.retryWindow
+	serviceRunning := make(chan bool)
+	resolveProcessInfo()
+
+	go s.run(serviceRunning)
+	if !<-serviceRunning {
+		return ErrNotRunning
+	}
+	go s.watchdog(s.stopServiceResponse, watchdogInterval, heartbeatTimeout)
+	s.setActive(true)
+	return nil
+}
+
+// setupLog implements SetupLog for the log service.
+func (s *simpleLogService) setupLog(logName string, appendlog bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	var flag int
+	if appendlog {
+		flag = os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	} else {
+		flag = os.O_TRUNC | os.O_CREATE | os.O_WRONLY
+	}
+	if err := s.configure(initlog, &logFileRequest{flag: flag, name: logName}); err != nil {
+		return wrapError(ErrOpenLogFile, err)
+	}
+	s.setLogFile(true)
+	return nil
+}
+
+// setupNetworkLog implements SetupNetworkLog for the log service.
+func (s *simpleLogService) setupNetworkLog(network, address string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if err := s.configure(initnetworklog, &networkRequest{network: network, address: address}); err != nil {
+		return wrapError(ErrConnect, err)
+	}
+	s.setNetworkLog(true)
+	return nil
+}
+
+// setupWebhookLog implements SetupWebhookLog for the log service.
+func (s *simpleLogService) setupWebhookLog(url string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if err := s.configure(initwebhooklog, url); err != nil {
+		return err
+	}
+	s.setWebhookLog(true)
+	return nil
+}
+
+// registerDestination implements RegisterDestination for the log service.
+func (s *simpleLogService) registerDestination(name string, w io.Writer) (int, error) {
+	if !s.isActive() {
+		return 0, ErrNotRunning
+	}
+	req := &destinationRequest{name: name, writer: w}
+	if err := s.configure(registerdestination, req); err != nil {
+		return 0, err
+	}
+	destination := req.destination
+	addBits(&s.customDestinations, destination)
+	s.namedDestinations.Store(name, destination)
+	return destination, nil
+}
+
+// switchLog implements SwitchLog for the log service.
+func (s *simpleLogService) switchLog(newLogName string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	flag := os.O_EXCL | os.O_CREATE | os.O_WRONLY
+	return wrapError(ErrOpenLogFile, s.configure(switchlog, &logFileRequest{flag: flag, name: newLogName}))
+}
+
+// switchLogAppend implements SwitchLogAppend for the log service.
+func (s *simpleLogService) switchLogAppend(logName string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	flag := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	return wrapError(ErrOpenLogFile, s.configure(switchlog, &logFileRequest{flag: flag, name: logName}))
+}
+
+// reopen implements Reopen for the log service.
+func (s *simpleLogService) reopen() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	return wrapError(ErrOpenLogFile, s.configure(reopenlog, nil))
+}
+
+// write implements Write for the log service.
+func (s *simpleLogService) write(destination int, values ...any) error {
+	return s.enqueue(logMessage{destination: destination}.withValues(values))
+}
+
+// writeBatch implements WriteBatch for the log service.
+func (s *simpleLogService) writeBatch(destination int, records [][]any) error {
+	if len(records) == 0 {
+		return nil
+	}
+	batch := make([][]any, len(records))
+	for i, values := range records {
+		batch[i] = append([]any(nil), values...)
+	}
+	return s.enqueue(logMessage{destination: destination, batch: batch})
+}
+
+// tryWrite implements TryWrite for the log service.
+func (s *simpleLogService) tryWrite(destination int, values ...any) bool {
+	return s.enqueue(logMessage{destination: destination, timeout: -1}.withValues(values)) == nil
+}
+
+// writeTimeout implements WriteTimeout for the log service.
+func (s *simpleLogService) writeTimeout(timeout time.Duration, destination int, values ...any) error {
+	if timeout <= 0 {
+		timeout = -1
+	}
+	return s.enqueue(logMessage{destination: destination, timeout: timeout}.withValues(values))
+}
+
+// conditionalWrite implements ConditionalWrite for the log service.
+func (s *simpleLogService) conditionalWrite(condition bool, destination int, values ...any) error {
+	if !condition {
+		if !s.isActive() {
+			return ErrNotRunning
+		}
+		return nil
+	}
+	return s.enqueue(logMessage{destination: destination}.withValues(values))
+}
+
+// writeIfError implements WriteIfError for the log service.
+func (s *simpleLogService) writeIfError(destination int, err error, values ...any) error {
+	if err == nil {
+		if !s.isActive() {
+			return ErrNotRunning
+		}
+		return nil
+	}
+	return s.enqueue(logMessage{destination: destination, level: ERROR}.withValues(append(values[:len(values):len(values)], err)))
+}
+
+// vetPrintf is always false. It guards the calls of fmt.Sprintf by which the printf analyzer of go vet recognizes
+// the Writef functions as printf wrappers, so that mismatched format verbs are reported at their call sites,
+// although the formatting is done by the log service.
+const vetPrintf = false
+
+// writef implements Writef for the log service.
+func (s *simpleLogService) writef(destination int, format string, values ...any) error {
+	if vetPrintf {
+		_ = fmt.Sprintf(format, values...)
+	}
+	return s.enqueue(logMessage{destination: destination, format: format}.withValues(values))
+}
+
+// conditionalWritef implements ConditionalWritef for the log service.
+func (s *simpleLogService) conditionalWritef(condition bool, destination int, format string, values ...any) error {
+	if vetPrintf {
+		_ = fmt.Sprintf(format, values...)
+	}
+	if !condition {
+		if !s.isActive() {
+			return ErrNotRunning
+		}
+		return nil
+	}
+	return s.enqueue(logMessage{destination: destination, format: format}.withValues(values))
+}
+
+// writeString implements WriteString for the log service.
+func (s *simpleLogService) writeString(destination int, line string) error {
+	return s.enqueue(logMessage{destination: destination, line: line})
+}
+
+// writeSync implements WriteSync for the log service.
+func (s *simpleLogService) writeSync(destination int, values ...any) error {
+	if err := s.enqueue(logMessage{destination: destination}.withValues(values)); err != nil {
+		return err
+	}
+	return s.sync()
+}
+
+// writeWithStack implements WriteWithStack for the log service.
+func (s *simpleLogService) writeWithStack(destination int, values ...any) error {
+	return s.enqueue(logMessage{destination: destination, stack: stackTrace(stackSkip)}.withValues(values))
+}
+
+// writeKV implements WriteKV for the log service.
+func (s *simpleLogService) writeKV(destination int, msg string, keysAndValues ...any) error {
+	if err := checkFields(keysAndValues); err != nil {
+		return err
+	}
+	return s.enqueue(logMessage{destination: destination, data: []any{msg}, fields: keysAndValues})
+}
+
+// checkFields checks whether structured fields are specified as pairs of string keys and values.
+// ErrInvalidFields is returned if not.
+func checkFields(keysAndValues []any) error {
+	if len(keysAndValues)%2 != 0 {
+		return ErrInvalidFields
+	}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if _, ok := keysAndValues[i].(string); !ok {
+			return ErrInvalidFields
+		}
+	}
+	return nil
+}
+
+// log implements Log for the log service.
+func (s *simpleLogService) log(level int, destination int, values ...any) error {
+	if _, ok := levelNames[level]; !ok {
+		return ErrUnknownLevel
+	}
+	return s.enqueue(logMessage{destination: destination, level: level}.withValues(values))
+}
+
+// enqueue sends a log message to the data queue of the log service.
+// An error is returned if the log service is not running, the log destination is unknown
+// or the log message should be written to a log file which has not been setup.
+func (s *simpleLogService) enqueue(logMsg logMessage) error {
+	// the log service isn't stopped until the log message is sent, so that it is written by the log service
+	s.senders.RLock()
+	defer s.senders.RUnlock()
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestinations(logMsg.destination) {
+		return ErrUnknownDestination
+	}
+	if logMsg.destination&FILE != 0 && !s.hasLogFile() {
+		return ErrNoLogFile
+	}
+	if logMsg.destination&NETWORK != 0 && !s.hasNetworkLog() {
+		return ErrNoNetworkLog
+	}
+	if logMsg.destination&WEBHOOK != 0 && !s.hasWebhookLog() {
+		return ErrNoWebhookLog
+	}
+	if logMsg.destination&RING != 0 && !s.hasRingLog() {
+		return ErrNoRingLog
+	}
+	if logMsg.destination&JOURNAL != 0 && !s.hasJournalLog() {
+		return ErrNoJournalLog
+	}
+	if logMsg.destination&loadBits(&s.callerDestinations) != 0 {
+		// capture only the program counter here; it is resolved to file and line by the log service
+		var pc [1]uintptr
+		if runtime.Callers(callerSkip, pc[:]) > 0 {
+			logMsg.caller = pc[0]
+		}
+	}
+	if logMsg.destination&loadBits(&s.goidDestinations) != 0 {
+		logMsg.goid = goroutineID()
+	}
+	if logMsg.timeout != 0 {
+		return s.sendWithin(logMsg)
 	}
+	s.send(logMsg)
+	return nil
 }
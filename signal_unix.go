@@ -0,0 +1,53 @@
+//go:build !windows
+
+package simplelog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reopenOnSignal implements ReopenOnSignal for the log service.
+func (s *simpleLogService) reopenOnSignal() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	stopped := s.stopServiceResponse // closed when the log service is stopped
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				s.reopen()
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// verboseOnSignal implements VerboseOnSignal for the log service.
+func (s *simpleLogService) verboseOnSignal() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	stopped := s.stopServiceResponse // closed when the log service is stopped
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case sig := <-signals:
+				s.setVerbose(sig == syscall.SIGUSR1)
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
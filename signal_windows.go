@@ -0,0 +1,21 @@
+//go:build windows
+
+package simplelog
+
+// reopenOnSignal implements ReopenOnSignal for the log service.
+// Windows doesn't support SIGHUP.
+func (s *simpleLogService) reopenOnSignal() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return ErrNotSupported
+}
+
+// verboseOnSignal implements VerboseOnSignal for the log service.
+// Windows doesn't support SIGUSR1 and SIGUSR2.
+func (s *simpleLogService) verboseOnSignal() error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return ErrNotSupported
+}
@@ -2,23 +2,112 @@
 // ease of use and performance.
 // Once started, the simple logger runs as a service and listens for logging
 // requests. Logging requests can be send to different log destinations,
-// such as standard out, a log file, or both.
+// such as standard out, standard error, a log file, a remote host, an HTTP webhook, or any combination of them.
 // The simple logger can be used simultaneously from multiple goroutines.
+// Besides the package level functions, which work on a default log service,
+// independent log services can be created by calling New.
 package simplelog
 
 import (
-	"os"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os/exec"
+	"time"
 )
 
 // message catalog
 const (
 	sg000 = "log service is not running"
 	sg001 = "log service was already started"
-	sg002 = "log service has not been started"
 	sg003 = "unknown log destination specified"
 	sg004 = "log file not setup"
+	sg005 = "unknown log level specified"
+	sg006 = "unknown log format specified"
+	sg007 = "invalid interval specified"
+	sg008 = "network log not setup"
+	sg009 = "webhook log not setup"
+	sg010 = "log destination already registered"
+	sg011 = "too many log destinations registered"
+	sg012 = "invalid structured fields specified"
+	sg013 = "invalid sampling specified"
+	sg014 = "invalid rate limit specified"
+	sg015 = "invalid sync rate specified"
+	sg016 = "unknown drop policy specified"
+	sg017 = "invalid buffer size specified"
+	sg018 = "not supported on this platform"
+	sg019 = "invalid archive name template specified"
+	sg020 = "audit log verification failed"
+	sg021 = "invalid route specified"
+	sg022 = "ring log not setup"
+	sg023 = "data channel is full"
+	sg024 = "log service did not respond in time"
+	sg025 = "invalid fallback destination specified"
+	sg026 = "invalid retry window specified"
+	sg027 = "unknown low disk space mode specified"
+	sg028 = "journal log not setup"
+	sg029 = "log record too large"
+	sg030 = "log records not acknowledged"
+	sg031 = "not a socket or named pipe"
+	sg032 = "stdout and stderr already captured"
+	sg033 = "stdout or stderr of command already set"
+	sg034 = "invalid maximum record size specified"
+	sg035 = "unknown multi-line mode specified"
+	sg036 = "log file could not be opened"
+	sg037 = "remote host could not be connected"
+	sg038 = "queue of the record sink is full"
 )
 
+// errors returned by the simplelog functions, which can be matched by errors.Is
+var (
+	ErrNotRunning             = newError("sg000", sg000) // the log service is not running
+	ErrAlreadyRunning         = newError("sg001", sg001) // the log service was already started
+	ErrUnknownDestination     = newError("sg003", sg003) // an unknown log destination was specified
+	ErrNoLogFile              = newError("sg004", sg004) // a log record should be written to a log file which has not been setup
+	ErrUnknownLevel           = newError("sg005", sg005) // an unknown log level was specified
+	ErrUnknownFormat          = newError("sg006", sg006) // an unknown log format was specified
+	ErrInvalidInterval        = newError("sg007", sg007) // an invalid interval was specified
+	ErrNoNetworkLog           = newError("sg008", sg008) // a log record should be written to a network log which has not been setup
+	ErrNoWebhookLog           = newError("sg009", sg009) // a log record should be written to a webhook log which has not been setup
+	ErrDestinationExists      = newError("sg010", sg010) // a log destination with the same name was already registered
+	ErrTooManyDestinations    = newError("sg011", sg011) // no further log destination can be registered
+	ErrInvalidFields          = newError("sg012", sg012) // structured fields are not specified as pairs of string keys and values
+	ErrInvalidSampling        = newError("sg013", sg013) // an invalid sampling configuration was specified
+	ErrInvalidRateLimit       = newError("sg014", sg014) // an invalid rate limit was specified
+	ErrInvalidSyncRate        = newError("sg015", sg015) // an invalid number of log records between syncs was specified
+	ErrUnknownDropPolicy      = newError("sg016", sg016) // an unknown drop policy was specified
+	ErrInvalidBufferSize      = newError("sg017", sg017) // an invalid buffer size was specified
+	ErrNotSupported           = newError("sg018", sg018) // the function is not supported on this platform
+	ErrInvalidArchiveTemplate = newError("sg019", sg019) // an archive name template with an unknown placeholder or a path separator was specified
+	ErrAuditViolation         = newError("sg020", sg020) // a line of an audited log file was modified, inserted or deleted
+	ErrInvalidRoute           = newError("sg021", sg021) // a routing rule without log file was specified
+	ErrNoRingLog              = newError("sg022", sg022) // the ring log has not been setup
+	ErrQueueFull              = newError("sg023", sg023) // a log message was dropped because the data channel was full
+	ErrServiceTimeout         = newError("sg024", sg024) // the log service didn't accept or answer a config request in time
+	ErrInvalidFallback        = newError("sg025", sg025) // a fallback log destination contains the log destination it is used for
+	ErrInvalidRetryWindow     = newError("sg026", sg026) // a negative retry window was specified
+	ErrUnknownDiskMode        = newError("sg027", sg027) // the low disk space mode isn't a combination of ROTATELOG, DROPDEBUG and STDOUTONLY
+	ErrNoJournalLog           = newError("sg028", sg028) // the journal log has not been setup
+	ErrMessageTooLarge        = newError("sg029", sg029) // a GELF message doesn't fit into the maximum number of chunks
+	ErrNoAck                  = newError("sg030", sg030) // a log collector didn't acknowledge the receipt of log records
+	ErrNoPipe                 = newError("sg031", sg031) // the path of a pipe destination is neither a Unix domain socket nor a named pipe
+	ErrOutputCaptured         = newError("sg032", sg032) // stdout and stderr are already captured by a log service
+	ErrCmdOutputSet           = newError("sg033", sg033) // stdout or stderr of a command passed to LogCmd is already set
+	ErrInvalidRecordSize      = newError("sg034", sg034) // a negative maximum record size was specified
+	ErrUnknownMultilineMode   = newError("sg035", sg035) // the multi-line mode isn't RAWLINES, PREFIXLINES or INDENTLINES
+	ErrOpenLogFile            = newError("sg036", sg036) // a log file couldn't be opened; wraps the error of the file system
+	ErrConnect                = newError("sg037", sg037) // the remote host of the network log couldn't be connected; wraps the error of the network
+	ErrSinkQueueFull          = newError("sg038", sg038) // a batch of log records was dropped because too many batches were waiting to be sent
+)
+
+// ErrorCode returns the code of an error of the log service in the message catalog, e.g. sg000 for ErrNotRunning,
+// so that conditions can be distinguished, e.g. in monitoring, without comparing messages. Errors wrapping an error
+// of the log service return its code. An empty code is returned if err isn't an error of the log service.
+func ErrorCode(err error) string {
+	return errorCode(err)
+}
+
 // SetPrefix sets the prefix for log records.
 // If the prefix should also contain actual time data, the Golang reference time placeholders can be used accordingly:
 //
@@ -34,59 +123,364 @@ const (
 // delimited by # tags and can be used for example as follows: #2006-01-02 15:04:05.000000#.
 // Note that not all placeholders have to be used and they can be used in any order.
 //
-// The destination specifies the name of the log destination where the prefix should be used, e.g. STDOUT or FILE.
+// Furthermore, the following placeholders can be used as prefix items:
+//
+//	#caller#: the source file and line of the code which called the simplelog function, e.g. main.go:42
+//	#host#: the host name
+//	#pid#: the process ID
+//	#goid#: the ID of the goroutine which called the simplelog function
+//	#seq#: the sequence number of the log record, which is counted up for each log record since Startup
+//	#uptime#: the seconds elapsed since Startup, e.g. 12.345678
+//
+// For log messages written by an io.Writer returned by Writer, the caller can't be determined reliably.
+//
+// The destination specifies the name of the log destination where the prefix should be used, e.g. STDOUT, STDERR, FILE, NETWORK or WEBHOOK.
 // The prefix specifies the prefix for each log record for a given log destination.
-func SetPrefix(destination int, prefix ...string) {
-	if s.isActive() {
-		switch destination {
-		case STDOUT:
-			s.configService <- configMessage{setprefix, map[int]any{stdoutlogprefix: prefix}}
-		case FILE:
-			s.configService <- configMessage{setprefix, map[int]any{filelogprefix: prefix}}
-		default:
-			panic(sg003)
-		}
-		<-s.configServiceResponse
-	} else {
-		panic(sg002)
-	}
+// An error is returned if the log service is not running or the destination is unknown.
+func SetPrefix(destination int, prefix ...string) error {
+	return s.setPrefix(destination, prefix...)
+}
+
+// SetPrefixForLevel sets the prefix for log records of a certain level, which is used instead of the prefix set
+// by SetPrefix, e.g. to mark error records or to log them with a higher timestamp precision.
+// The same placeholders as with SetPrefix can be used.
+//
+// The destination specifies the name of the log destination where the prefix should be used, e.g. STDOUT or FILE.
+// The level specifies the level of the log records, e.g. ERROR.
+// The prefix specifies the prefix for log records of the level; without prefix items, the log records of the level
+// use the prefix set by SetPrefix again.
+// An error is returned if the log service is not running, the level or the destination is unknown.
+func SetPrefixForLevel(destination, level int, prefix ...string) error {
+	return s.setPrefixForLevel(destination, level, prefix...)
+}
+
+// SetLevel sets the minimum log level for log records written by Log.
+// Log records with a lower level than the specified one are dropped by the log service before they are formatted.
+// Log records written by Write or ConditionalWrite don't have a level and are therefore always written.
+//
+// The destination specifies the name of the log destination where the level should be used, e.g. STDOUT, STDERR, FILE, NETWORK or WEBHOOK.
+// The level specifies the minimum log level, e.g. DEBUG, INFO, WARN, ERROR or FATAL.
+// An error is returned if the log service is not running, the destination or the level is unknown.
+func SetLevel(destination int, level int) error {
+	return s.setLevel(destination, level)
+}
+
+// SetFormat sets the format of log records.
+// By default, log records are written as plain text lines (TEXT). With the JSON format each log record is
+// written as a JSON object in one line, containing the fields timestamp, prefix, level and message.
+// With the GELF format each log record is written as a GELF message for Graylog. Written to the NETWORK destination,
+// GELF messages are compressed and split into chunks via UDP and terminated by a null byte via TCP, as expected by
+// the GELF inputs of Graylog, e.g. SetupNetworkLog("udp", "graylog:12201") and SetFormat(NETWORK, GELF).
+//
+// The destination specifies the name of the log destination where the format should be used, e.g. STDOUT, STDERR, FILE, NETWORK or WEBHOOK.
+// The format specifies the format of the log records, e.g. TEXT, JSON or GELF.
+// An error is returned if the log service is not running, the destination or the format is unknown.
+func SetFormat(destination int, format int) error {
+	return s.setFormat(destination, format)
+}
+
+// SetRotation sets the interval of the time-based log file rotation.
+// When the interval has elapsed, the log file is renamed to <log file name>_<start of the rotated period> and a
+// new log file with the same name is created and used. The rotation points in time are aligned to multiples
+// of the interval since midnight, e.g. an interval of 24 hours rotates the log file at midnight into a file
+// named <log file name>_yyyymmdd and an interval of 1 hour at every full hour into <log file name>_yyyymmddHH.
+//
+// The interval specifies the rotation interval; an interval of 0 disables the rotation.
+// An error is returned if the log service is not running or the interval is negative.
+func SetRotation(interval time.Duration) error {
+	return s.setRotation(interval)
+}
+
+// SetAudit enables or disables the tamper-evident audit mode of the log file.
+// In audit mode, each line written to the log file ends with " audit=<sequence number>:<HMAC>". The HMAC-SHA256
+// of a line covers the HMAC of the previous line, the sequence number and the line itself, so that Verify detects
+// modified, inserted or deleted lines. If the log file already contains audited lines, their chain is continued.
+// Deleting lines at the end of the log file can't be detected by the chain itself; to detect it, the sequence
+// number of the last line should be kept elsewhere, e.g. in a separate system.
+//
+// The enabled parameter specifies whether the audit mode is enabled.
+// The key parameter specifies the secret key of the HMAC; without a key, the lines are only chained by hashes.
+// An error is returned if the log service is not running or the log file hasn't been set up.
+func SetAudit(enabled bool, key []byte) error {
+	return s.setAudit(enabled, key)
+}
+
+// Verify verifies the chain of the audited lines of a log file written in audit mode.
+// Lines written before the audit mode was enabled are skipped.
+// The logFileName parameter specifies the log file and the key parameter the key used with SetAudit.
+// ErrAuditViolation, including the number of the first violating line, is returned if a line was modified,
+// inserted or deleted.
+func Verify(logFileName string, key []byte) error {
+	return verify(logFileName, key)
+}
+
+// AddRoute adds a routing rule, which writes the log records matching all its conditions to a separate log file,
+// e.g. Route{Level: ERROR, File: "error.log"} writes errors to error.log or Route{Component: "[http]",
+// File: "access.log"} writes the log records of the HTTP component to access.log. The log records are written to
+// their log destinations as well, in the format and with the prefix of FILE. The log file of a routing rule is
+// appended, if it already exists, reopened by Reopen and closed by Shutdown, which also removes all routing rules.
+// The route parameter specifies the routing rule.
+// An error is returned if the log service is not running, the routing rule is invalid or its log file
+// can't be opened.
+func AddRoute(route Route) error {
+	return s.addRoute(route)
+}
+
+// SetArchive sets where and under which name archived log files are stored. Log files are archived when they
+// are rotated and, if requested, at shutdown.
+//
+// The dir parameter specifies the directory the archived log files are moved to, which is created if needed;
+// an empty dir keeps them next to the log file. If the directory is on another file system, the log file is copied.
+// The template parameter specifies the name of the archived log files, where {name} is replaced by the name of
+// the log file, {ts} by the timestamp and {seq} by a sequence number, e.g. "{name}.{ts}.{seq}"; an empty template
+// uses the default "{name}_{ts}". Without {seq}, a sequence number is only added if the name is already taken.
+// An error is returned if the log service is not running or the template is invalid.
+func SetArchive(dir, template string) error {
+	return s.setArchive(dir, template)
+}
+
+// SetArchiveHook sets a function which is called each time a log file was archived, i.e. rotated or archived at
+// shutdown, e.g. to compress the archive, upload it or notify a log shipper.
+// The hook is called within the log service goroutine, hence it should return quickly and hand long-running work
+// over to another goroutine.
+// The hook parameter specifies the function which is called with the path of the archive; nil removes the hook.
+// ErrNotRunning is returned if the log service is not running.
+func SetArchiveHook(hook func(archivedPath string)) error {
+	return s.setArchiveHook(hook)
+}
+
+// SetArchiveUploader sets an object storage, e.g. an S3 bucket, which archived log files are uploaded to.
+// The archived log files are uploaded asynchronously in a dedicated goroutine, so that slow uploads don't stall
+// the log service. Failed uploads are retried with exponential backoff; if all retries fail, the archived log file
+// is kept locally. At shutdown, the pending archived log files are uploaded before Shutdown returns.
+//
+// The u parameter specifies the object storage; nil stops uploading archived log files.
+// The removeLocal parameter specifies whether an archived log file is removed after it was uploaded successfully.
+// ErrNotRunning is returned if the log service is not running.
+func SetArchiveUploader(u Uploader, removeLocal bool) error {
+	return s.setArchiveUploader(u, removeLocal)
+}
+
+// SetTimeZone sets the time zone of the timestamps of log records, i.e. of the date and time placeholders of
+// prefixes and the timestamp of JSON log records. The points in time of the log file rotation are aligned to
+// midnight in this time zone as well. This way, the logs of hosts in different time zones can be correlated.
+// The loc parameter specifies the time zone, e.g. time.UTC; nil uses the local time zone again.
+// ErrNotRunning is returned if the log service is not running.
+func SetTimeZone(loc *time.Location) error {
+	return s.setTimeZone(loc)
+}
+
+// SetDurability sets the durable mode of the log file. In durable mode, the log file is synced to stable storage
+// (see os.File.Sync) each time the buffered log records are written to it, so the log records survive a power loss.
+//
+// The enabled parameter specifies whether the durable mode is enabled.
+// The records parameter specifies the number of log records after which the buffered log records are written and
+// synced additionally to the periodic flush; 0 syncs only with the periodic flush, 1 syncs each log record.
+// An error is returned if the log service is not running or records is negative.
+func SetDurability(enabled bool, records int) error {
+	return s.setDurability(enabled, records)
+}
+
+// SetColor enables or disables colorized log records.
+// If enabled, the level of a log record is colorized depending on the level, e.g. ERROR in red, and the prefix
+// in cyan, using ANSI color codes. Colors are only used if the log destination is a terminal; if the output is
+// piped or redirected to a file, the log records are written without colors. Log records in JSON format are never colorized.
+//
+// The destination specifies the name of the log destination where colors should be used, e.g. STDOUT or STDERR.
+// The enabled flag indicates whether colors are used (true) or not (false).
+// An error is returned if the log service is not running or the destination is neither STDOUT nor STDERR.
+func SetColor(destination int, enabled bool) error {
+	return s.setColor(destination, enabled)
+}
+
+// SetFilter sets regular expression filters for log records of a log destination.
+// The filters are applied to the text of the log messages, i.e. without prefix and level, and make it possible to
+// suppress noisy log records, e.g. third-party output redirected by Writer, without touching the call sites.
+// Calling SetFilter replaces the filters set before; calling it with empty filters removes all filters.
+//
+// The destination specifies the name of the log destination where the filters should be used, e.g. STDOUT or FILE.
+// The include filters specify regular expressions of which at least one must match; if empty, all log records match.
+// The exclude filters specify regular expressions of which none must match.
+// An error is returned if the log service is not running, the destination is unknown or a regular expression is invalid.
+func SetFilter(destination int, include, exclude []string) error {
+	return s.setFilter(destination, include, exclude)
+}
+
+// SetMultiline sets how the continuation lines of multi-line log records, e.g. of stack traces or pretty-printed
+// JSON, are written in TEXT format, so that line-based parsers don't treat them as separate log records.
+// In mode RAWLINES, the continuation lines are written as they are. In mode PREFIXLINES, each continuation line
+// is prefixed with the prefix, the component and the level of the log record. In mode INDENTLINES, each
+// continuation line is prefixed with the indent marker, e.g. a tab.
+// The destination parameter specifies the log destination, e.g. FILE.
+// The mode parameter specifies the multi-line mode and the indent parameter the indent marker in mode INDENTLINES.
+// An error is returned if the log service is not running, the destination is unknown or the mode is unknown.
+func SetMultiline(destination, mode int, indent string) error {
+	return s.setMultiline(destination, mode, indent)
+}
+
+// SetMaxRecordSize sets the maximum size of the text of log messages, which prevents a single accidental dump of a
+// huge payload from bloating the log file and stalling the log service. Longer texts are truncated at a UTF-8
+// character boundary and the marker "...[truncated N bytes]" is appended, whereas N is the number of cut bytes.
+// The prefix, the structured fields and the stack trace of log records aren't truncated.
+// The size parameter specifies the maximum size in bytes; 0 removes the limit.
+// An error is returned if the log service is not running or the size is negative.
+func SetMaxRecordSize(size int) error {
+	return s.setMaxRecordSize(size)
+}
+
+// SetTagFilter sets tag filters for log records of a log destination, which make it possible to filter the log
+// records of subsystems coarsely by the tags set by WriteTagged, without regular expressions.
+// Calling SetTagFilter replaces the tag filters set before; calling it with empty tags removes all tag filters.
+//
+// The destination specifies the name of the log destination where the tag filters should be used, e.g. FILE.
+// The include tags specify the tags of which log records must have at least one; if empty, all log records match.
+// The exclude tags specify the tags of which log records must have none.
+// An error is returned if the log service is not running or the destination is unknown.
+func SetTagFilter(destination int, include, exclude []string) error {
+	return s.setTagFilter(destination, include, exclude)
+}
+
+// SetSampling sets the sampling of log messages, which keeps high-frequency log messages, e.g. of tight loops,
+// from overwhelming the log destinations. Of log messages with the same sample key, the first ones per second are
+// logged and thereafter only every n-th one. The sample key of a log message written by Writef is its format
+// specifier, of all other log messages the text of its first value.
+//
+// The first parameter specifies the number of log messages per sample key and second which are logged;
+// 0 disables the sampling.
+// The thereafter parameter specifies that thereafter only every n-th log message is logged; 0 drops all of them.
+// An error is returned if the log service is not running or a parameter is negative.
+func SetSampling(first, thereafter int) error {
+	return s.setSampling(first, thereafter)
+}
+
+// SetRateLimit limits the number of log records written to a log destination. Log records exceeding the limit
+// are dropped by the log service, instead of backing up the data channel and blocking the writing goroutines.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The perSecond parameter specifies the number of log records per second; 0 removes the rate limit.
+// The burst parameter specifies the number of log records which may be written at once.
+// An error is returned if the log service is not running, the destination is unknown or the limit is invalid.
+func SetRateLimit(destination, perSecond, burst int) error {
+	return s.setRateLimit(destination, perSecond, burst)
+}
+
+// SetRedaction sets patterns of sensitive data, e.g. passwords, tokens or credit card numbers, which are replaced
+// in log records before they are written to any log destination. The redaction is done within the log service
+// goroutine, after the hooks were called, and applies to the text of log records and the values of their
+// structured fields.
+//
+// The patterns parameter specifies the regular expressions of sensitive data; no patterns disable the redaction.
+// The replacement parameter specifies the text which replaces each match, e.g. "***".
+// An error is returned if the log service is not running or a pattern is no valid regular expression.
+func SetRedaction(patterns []string, replacement string) error {
+	return s.setRedaction(patterns, replacement)
+}
+
+// SetDeduplication enables or disables the suppression of consecutive identical log messages.
+// If enabled, only the first of consecutive identical log messages is written. As soon as a different log message
+// is written or the log service is stopped, a log record "last message repeated N times" summarizes the suppressed
+// duplicates. This keeps e.g. retry loops from flooding the log destinations.
+// An error is returned if the log service is not running.
+func SetDeduplication(enabled bool) error {
+	return s.setDeduplication(enabled)
+}
+
+// SetDropPolicy sets the policy applied to log messages while the data channel of the log service is full, e.g.
+// because a slow disk stalls the log service. By default, the writing goroutines are blocked (BLOCK). Otherwise,
+// either the log message to be written (DROPNEWEST) or the oldest log message in the data channel (DROPOLDEST)
+// is dropped, so writing never blocks. The number of dropped log messages is reported periodically to STDERR.
+// With SPILL, log messages are spilled to a temporary overflow file instead and written as soon as the log service
+// has caught up. Spilled log messages are written as text; the values of their structured fields become strings.
+// An error is returned if the log service is not running or the policy is unknown.
+func SetDropPolicy(policy int) error {
+	return s.setDropPolicy(policy)
+}
+
+// AddHook adds a hook which is called for each log record before it is written to its log destinations.
+// Hooks can be used to modify, enrich or drop log records, e.g. to add static fields, redact secrets or count
+// errors. They are called one after another in the order they were added, within the log service goroutine,
+// hence they don't need to be synchronized. A hook has to return the (modified) log record or nil to drop it.
+// The hook parameter specifies the hook to be added.
+// ErrNotRunning is returned if the log service is not running.
+func AddHook(hook Hook) error {
+	return s.addHook(hook)
+}
+
+// ConfigFromEnv configures the log service from environment variables, so that the logging can be reconfigured
+// without code changes, e.g. in container deployments. If the log service is not running, it is started.
+// The following environment variables are honored:
+//   - SIMPLELOG_BUFFER: the size of the data channel (default 1)
+//   - SIMPLELOG_FILE: the name of the log file, which is appended to
+//   - SIMPLELOG_DESTINATION: the comma-separated log destinations, i.e. stdout, stderr, file, multi, network or
+//     webhook (default file if SIMPLELOG_FILE is set, stdout otherwise)
+//   - SIMPLELOG_LEVEL: the minimum level of the log destinations, e.g. INFO
+//   - SIMPLELOG_PREFIX: the prefix of the log destinations, e.g. "#2006-01-02 15:04:05# #pid#"
+//
+// The log destinations are returned, so that they can be used to write log messages.
+// An error is returned if a value is invalid or the configuration fails.
+func ConfigFromEnv() (int, error) {
+	return s.configFromEnv()
+}
+
+// LoadConfig configures the log service from a JSON configuration file, so that the logging can be tuned without
+// recompiling. If the log service is not running, it is started. The configuration file has the following structure,
+// whereby all entries are optional:
+//
+//	{
+//	  "buffer": 100,
+//	  "file": {"name": "app.log", "append": true, "rotation": "24h"},
+//	  "network": {"network": "tcp", "address": "logs.example.com:514"},
+//	  "webhook": {"url": "https://logs.example.com/ingest"},
+//	  "destinations": {
+//	    "stdout": {"prefix": ["#2006-01-02 15:04:05#", "#pid#"], "level": "INFO", "format": "text", "color": true},
+//	    "file": {"level": "DEBUG", "format": "json"}
+//	  }
+//	}
+//
+// An error is returned if the configuration file isn't a JSON file or can't be read, a value is invalid
+// or the configuration fails.
+func LoadConfig(path string) error {
+	return s.loadConfig(path)
 }
 
 // Shutdown stops the log service including post-processing and cleanup.
 // Before the log service is stopped, all pending log messages are flushed and resources are released.
+// Log messages which other goroutines are writing at the same time are written exactly once before the log
+// service stops; log messages written afterwards are rejected with ErrNotRunning.
 // Archiving a log file means that it will be renamed and no new messages will be appended on a new run.
 // The archived log file is of the following format: <log file name>_yyyymmddHHMMSS.
 // The archivelog flag indicates whether the log file will be archived (true) or not (false).
-func Shutdown(archivelog bool) {
-	if s.isActive() {
-		s.stop(archivelog)
-		s.setActive(false)
-	} else {
-		panic(sg000)
-	}
+// ErrNotRunning is returned if the log service is not running.
+func Shutdown(archivelog bool) error {
+	return s.shutdown(archivelog)
+}
+
+// SetBufferSize changes the size of the data channel of the log service, i.e. the number of log messages which
+// can be buffered before writing blocks, without restarting the log service. The log messages buffered so far
+// are written before the data channel is replaced.
+// An error is returned if the log service is not running or the buffer size is negative.
+func SetBufferSize(bufferSize int) error {
+	return s.setBufferSize(bufferSize)
+}
+
+// ShutdownContext stops the log service like Shutdown, but gives up when the context expires before all pending
+// log messages are flushed, e.g. because a log destination is stuck on a dead network file system.
+// In that case, the log service is abandoned and the number of log messages which were not flushed is returned
+// together with the error of the context.
+// The archivelog flag indicates whether the log file will be archived (true) or not (false).
+// ErrNotRunning is returned if the log service is not running.
+func ShutdownContext(ctx context.Context, archivelog bool) (int, error) {
+	return s.shutdownContext(ctx, archivelog)
 }
 
 // Startup starts the log service.
 // The log service runs in its own goroutine.
 // The bufferSize specifies the number of log messages which can be buffered before the log service blocks.
-func Startup(bufferSize int) {
-	if !s.isActive() {
-		s.dataQueue = make(chan logMessage, bufferSize)
-		s.configService = make(chan configMessage)
-		s.configServiceResponse = make(chan error)
-		s.stopService = make(chan bool)
-		s.stopServiceResponse = make(chan struct{})
-		serviceRunning := make(chan bool)
-
-		go s.run(serviceRunning)
-		if !<-serviceRunning {
-			panic(sg000)
-		} else {
-			s.setActive(true)
-		}
-	} else {
-		panic(sg001)
-	}
+// The log service can be started again after Shutdown as often as required; each start uses the default settings,
+// as if it was the first one. Startup and Shutdown can be called concurrently.
+// ErrAlreadyRunning is returned if the log service was already started.
+func Startup(bufferSize int) error {
+	return s.startup(bufferSize)
 }
 
 // SetupLog opens and initially creates a log file.
@@ -94,79 +488,674 @@ func Startup(bufferSize int) {
 // With appendLog it is possible to specify, if a new run of the application first truncates the
 // old log before new log entries are written (false) or if new messages are appended to the already
 // existing log (true).
-func SetupLog(logName string, appendlog bool) {
-	if s.isActive() {
-		var flag int
-		if appendlog {
-			flag = os.O_APPEND | os.O_CREATE | os.O_WRONLY
-		} else {
-			flag = os.O_TRUNC | os.O_CREATE | os.O_WRONLY
-		}
-		s.configService <- configMessage{initlog, map[int]any{logflag: flag, logfilename: logName}}
-		if err := <-s.configServiceResponse; err != nil {
-			panic(err)
-		}
-	} else {
-		panic(sg002)
-	}
+// An error is returned if the log service is not running or the log file can't be opened.
+func SetupLog(logName string, appendlog bool) error {
+	return s.setupLog(logName, appendlog)
+}
+
+// SetRunHeader sets the run header, which is written as first line of the log file instead of the blank line
+// separating the runs of the application in an appended log file, so that it's easy to find where a new run begins.
+// The run header can contain the placeholders #host#, #pid#, #cmd# for the command line and #version# for the
+// version of the main module of the program. Other text enclosed in # is a date/time layout, e.g.
+// #2006-01-02 15:04:05#, which is replaced by the start time of the log service. DefaultRunHeader contains the
+// start time, the version, the process ID and the command line.
+// The run header is written when the first log record is written to the log file, not after a rotation or
+// reopening of the log file, hence it has to be set before.
+// The header parameter specifies the run header; an empty run header writes the blank line again.
+// An error is returned if the log service is not running.
+func SetRunHeader(header string) error {
+	return s.setRunHeader(header)
+}
+
+// SetRunFooter enables or disables the run footer, which is written as last line to the log file when the log
+// service is stopped, e.g. "=== run ended: 1234 log records written, 0 dropped, 0 rate limited, uptime 1h2m3.456s ===".
+// It helps to verify that no log records were lost while the application terminated.
+// An error is returned if the log service is not running.
+func SetRunFooter(enabled bool) error {
+	return s.setRunFooter(enabled)
+}
+
+// SetupNetworkLog connects the log service to a remote host which receives the network log.
+// Log records written to the NETWORK destination are streamed to the remote host, one log record per line
+// or datagram. If the connection to the remote host breaks, the log service reconnects automatically
+// and buffers the log records in the meantime. If the buffer is full, the oldest log records are dropped.
+// The network parameter specifies the name of the network, e.g. tcp or udp.
+// The address parameter specifies the address of the remote host, e.g. collector:5140.
+// An error is returned if the log service is not running or the remote host can't be connected.
+func SetupNetworkLog(network, address string) error {
+	return s.setupNetworkLog(network, address)
+}
+
+// SetupWebhookLog sets the URL of an HTTP webhook which receives the webhook log.
+// Log records written to the WEBHOOK destination are collected and posted in batches as JSON array to the URL,
+// at least once per second. By default, the log records are posted as JSON strings; if the format of the
+// WEBHOOK destination is set to JSON by SetFormat, they are posted as JSON objects instead. Failed posts are retried with
+// exponential backoff. The posts are done in a dedicated goroutine, hence a slow webhook doesn't stall the log service.
+// The url parameter specifies the URL of the webhook.
+// ErrNotRunning is returned if the log service is not running.
+func SetupWebhookLog(url string) error {
+	return s.setupWebhookLog(url)
+}
+
+// SetupJournalLog connects the JOURNAL destination to the systemd journal via the native journald protocol.
+// Log records written to the JOURNAL destination are sent with their text as MESSAGE, their level as syslog
+// PRIORITY, the name of the executable as SYSLOG_IDENTIFIER, the component of a Child as COMPONENT and each
+// structured field as journal field with the upper-cased key, e.g. user_id becomes USER_ID, so that they can be
+// queried with journalctl, e.g. journalctl USER_ID=42.
+// An error is returned if the log service is not running or journald isn't reachable, e.g. on systems without systemd.
+func SetupJournalLog() error {
+	return s.setupJournalLog()
+}
+
+// SetupRingLog sets up the ring log, an in-memory ring buffer, which keeps the last log records written to the
+// RING destination. As soon as a log record of level ERROR or above is written to the RING destination, the
+// buffered log records are written to the log file and the ring buffer is emptied. This way, the log file
+// contains the context of errors, e.g. debug log records, without writing all debug log records to it.
+// Set a prefix with date and time placeholders for the RING destination to keep the time of the log records.
+// The size parameter specifies the number of log records kept in the ring buffer.
+// An error is returned if the log service is not running or the size is not positive.
+func SetupRingLog(size int) error {
+	return s.setupRingLog(size)
+}
+
+// DumpRing writes the log records buffered by the ring log to the log file and empties the ring buffer.
+// An error is returned if the log service is not running or the ring log or the log file has not been setup.
+func DumpRing() error {
+	return s.dumpRingLog()
+}
+
+// Tail returns the last n log records written by the log service, oldest first, e.g. to show the recent log
+// records on an admin HTTP endpoint. The log records are formatted with the settings of the log file, but
+// without line break. Up to 1000 log records are kept, regardless of their log destinations.
+// If the log service is not running, nil is returned.
+func Tail(n int) []string {
+	return s.tailRecords(n)
+}
+
+// Subscribe returns a channel, which receives each log record written by the log service, e.g. to tail the log
+// records in a web UI in real time. The log records are formatted like the ones returned by Tail.
+// The bufferSize parameter specifies the size of the channel. If the channel is full, log records are dropped
+// for the subscriber instead of blocking the log service. The returned cancel function ends the subscription;
+// the channel is closed when the subscription ends or the log service is stopped.
+// An error is returned if the log service is not running or the buffer size is not positive.
+func Subscribe(bufferSize int) (<-chan string, func(), error) {
+	return s.subscribe(bufferSize)
+}
+
+// StreamHandler returns an HTTP handler, which streams the log records written by the log service to its
+// clients as Server-Sent Events, e.g. http.Handle("/logs", simplelog.StreamHandler()). Each log record is sent
+// as one event. A client which can't keep up loses log records.
+func StreamHandler() http.Handler {
+	return s.streamHandler()
+}
+
+// RegisterDestination registers a custom log destination, which writes log records to an io.Writer, e.g. an
+// in-memory buffer, a pipe or a test recorder. The returned log destination bit can be used like the built-in
+// log destinations, e.g. it can be combined with them or its prefix can be set by SetPrefix.
+// The name parameter specifies the unique name of the custom log destination.
+// The w parameter specifies the io.Writer the log records are written to. Each log record is written by one call
+// of its Write method, which is called from the log service goroutine only.
+// An error is returned if the log service is not running, a log destination with the same name was already
+// registered or no further log destination can be registered.
+func RegisterDestination(name string, w io.Writer) (int, error) {
+	return s.registerDestination(name, w)
+}
+
+// OpenLogFile opens a named log file, e.g. an audit log, in addition to the log file set up by SetupLog and
+// registers it as custom log destination. Log records are written to it like to any other log destination,
+// e.g. Write(FILE|Named("audit"), ...), through the same log service. The log file is appended, if it already
+// exists, reopened by Reopen and closed by Shutdown, which also removes the log destination.
+// The name parameter specifies the name of the log destination and the logName parameter the name of the log file.
+// It returns the log destination bit of the named log file. An error is returned if the log service is not
+// running, a log destination with the same name is already registered or the log file can't be opened.
+func OpenLogFile(name, logName string) (int, error) {
+	return s.openLogFile(name, logName)
+}
+
+// RegisterPipeDestination registers a custom log destination, which writes the log records to a Unix domain socket
+// or a named pipe (FIFO), e.g. of a sidecar log collector on a host where log files can't be written. If the reader
+// isn't available, e.g. while it restarts, the log service reconnects automatically and buffers the log records in
+// the meantime. If the buffer is full, the oldest log records are dropped.
+// The name parameter specifies the name of the log destination and the path parameter the path of the Unix domain
+// socket, stream or datagram, or of the named pipe.
+// An error is returned if the log service is not running, the name is already registered or the path is neither a
+// Unix domain socket nor a named pipe.
+func RegisterPipeDestination(name, path string) (int, error) {
+	return s.registerPipeDestination(name, path)
+}
+
+// RegisterFluentDestination registers a custom log destination, which sends the log records to a Fluentd or
+// Fluent Bit server, e.g. of a Kubernetes DaemonSet, using the Fluent forward protocol over TCP. Each log record
+// is sent with the tag as map of its message, level, component and structured fields. The log records are sent in
+// batches at least once per second and each batch has to be acknowledged by the server; batches which aren't
+// acknowledged are sent again, while the oldest log records are dropped if the server isn't reachable for long.
+// The name parameter specifies the name of the log destination, the address parameter the address of the
+// server, e.g. localhost:24224, and the tag parameter the tag of the log records, e.g. app.service.
+// An error is returned if the log service is not running, the name is already registered or the server isn't
+// reachable.
+func RegisterFluentDestination(name, address, tag string) (int, error) {
+	return s.registerFluentDestination(name, address, tag)
+}
+
+// RegisterKafkaDestination registers a custom log destination, which produces the log records to a Kafka topic,
+// for pipelines treating logs as event stream. The log records are produced as JSON objects, as written in JSON
+// format, in batches of up to 100 log records at least once per second. The batches are produced by a dedicated
+// goroutine in the order of the log records, hence a slow Kafka cluster doesn't stall the log service.
+//
+// The name parameter specifies the name of the log destination and p the Kafka producer, which wraps the producer
+// of a Kafka client library. The keyField parameter specifies the key of the structured field, whose value is used
+// as partition key, e.g. user_id; empty if the log records have no partition key. The onError parameter specifies a
+// function which is called with the batches which couldn't be delivered; nil if not used.
+// An error is returned if the log service is not running or the name is already registered.
+func RegisterKafkaDestination(name string, p Producer, topic, keyField string, onError DeliveryErrorCallback) (int, error) {
+	return s.registerKafkaDestination(name, p, topic, keyField, onError)
+}
+
+// RegisterOTLPDestination registers a custom log destination, which exports the log records to an OpenTelemetry
+// collector using OTLP over HTTP with JSON encoding. The log records are exported in batches of up to 100 log
+// records at least once per second by a dedicated goroutine; failed exports are retried with exponential backoff.
+// The level of a log record is mapped to its severity and the text to its body. The prefix of the log destination,
+// the component of a Child and the structured fields are mapped to attributes, whereas the prefix placeholders are
+// mapped to the OpenTelemetry semantic conventions, e.g. the caller placeholder to code.filepath and code.lineno.
+// The trace_id and span_id fields, e.g. set by WriteCtx with a trace extractor, are mapped to the trace context.
+// The name parameter specifies the name of the log destination, the endpoint parameter the URL of the logs endpoint
+// of the collector, e.g. http://localhost:4318/v1/logs, and the serviceName parameter the service.name attribute
+// of the resource.
+// An error is returned if the log service is not running or the name is already registered.
+func RegisterOTLPDestination(name, endpoint, serviceName string) (int, error) {
+	return s.registerOTLPDestination(name, endpoint, serviceName)
+}
+
+// SetTraceExtractor sets a function which extracts the IDs of the active trace and span from the context passed
+// to WriteCtx, e.g. from the span context of an OpenTelemetry tracer. The IDs are written as trace_id and span_id
+// fields, which correlates the log records with the traces.
+// The extractor parameter specifies the trace extractor; nil removes it.
+// An error is returned if the log service is not running.
+func SetTraceExtractor(extractor TraceExtractor) error {
+	return s.setTraceExtractor(extractor)
+}
+
+// Named returns the log destination bit of a named log file or a custom log destination registered by
+// RegisterDestination, or 0 if no log destination with this name is registered.
+// The name parameter specifies the name of the log destination.
+func Named(name string) int {
+	return s.named(name)
 }
 
 // SwitchLog closes the current log file and a new log file with the specified name is created and used.
 // Thereby, the current log file is not deleted, the new log file must not exist and the log service
 // doesn't need to be stopped for this task. The new log file must not exist.
 // The newLogName specifies the name of the new log to switch to.
-func SwitchLog(newLogName string) {
-	if s.isActive() {
-		var err error
-		flag := os.O_EXCL | os.O_CREATE | os.O_WRONLY
-		s.configService <- configMessage{switchlog, map[int]any{logflag: flag, logfilename: newLogName}}
-		if err = <-s.configServiceResponse; err != nil {
-			panic(err)
-		}
-	} else {
-		panic(sg002)
-	}
+// An error is returned if the log service is not running, no log file has been setup or the new log file can't be created.
+func SwitchLog(newLogName string) error {
+	return s.switchLog(newLogName)
 }
 
-// Write writes a log message to a specified destination.
+// SwitchLogAppend closes the current log file and switches to the log file with the specified name like SwitchLog.
+// Unlike SwitchLog, the log file may exist and is appended to, e.g. to switch back to a previously used log file
+// or to resume after a crash.
+// The logName specifies the name of the log to switch to.
+// An error is returned if the log service is not running, no log file has been setup or the log file can't be opened.
+func SwitchLogAppend(logName string) error {
+	return s.switchLogAppend(logName)
+}
+
+// Reopen closes and reopens the log file with the same name. If the log file was moved by an external tool like
+// logrotate, the log records are written to a new log file with the original name instead of the moved one.
+// An error is returned if the log service is not running or no log file has been setup.
+func Reopen() error {
+	return s.reopen()
+}
+
+// ReopenOnSignal installs a signal handler, which reopens the log file each time the process receives SIGHUP,
+// as expected by logrotate. The signal handler is removed when the log service is stopped.
+// An error is returned if the log service is not running or the platform doesn't support SIGHUP, e.g. Windows.
+func ReopenOnSignal() error {
+	return s.reopenOnSignal()
+}
+
+// Pause pauses writing log records, e.g. while the storage backing the log directory is swapped out.
+// While the log service is paused, log messages are kept in a temporary overflow file in the directory for
+// temporary files. They are written in order when Resume is called or the log service is stopped.
+// An error is returned if the log service is not running.
+func Pause() error {
+	return s.pause()
+}
+
+// Resume resumes writing log records after Pause and writes the log messages kept in the meantime.
+// An error is returned if the log service is not running.
+func Resume() error {
+	return s.resume()
+}
+
+// SetVerbose sets the minimum level of all log destinations to DEBUG, if verbose is true, e.g. to diagnose a
+// long-running daemon which can't be restarted. If verbose is false, the levels of the log destinations before
+// the first call of SetVerbose(true) are restored; changes of the levels in between are discarded.
+// An error is returned if the log service is not running.
+func SetVerbose(verbose bool) error {
+	return s.setVerbose(verbose)
+}
+
+// VerboseOnSignal installs a signal handler, which calls SetVerbose(true) each time the process receives SIGUSR1
+// and SetVerbose(false) each time it receives SIGUSR2. The signal handler is removed when the log service is stopped.
+// An error is returned if the log service is not running or the platform doesn't support SIGUSR1 and SIGUSR2,
+// e.g. Windows.
+func VerboseOnSignal() error {
+	return s.verboseOnSignal()
+}
+
+// WriteIfError writes a log message of level ERROR, followed by an error, to a specified destination, if the error
+// isn't nil, e.g. WriteIfError(FILE, err, "open config failed:"). It replaces the common three-liner of an if
+// statement checking the error and a Write call.
 // The destination parameter specifies the log destination, where the data will be written to.
-// The logValues parameter consists of one or multiple values that are logged.
-func Write(destination int, values ...any) {
-	if s.isActive() {
-		switch destination {
-		case STDOUT:
-			s.dataQueue <- logMessage{STDOUT, values}
-		case FILE:
-			s.dataQueue <- logMessage{FILE, values}
-		case MULTI:
-			s.dataQueue <- logMessage{MULTI, values}
-		default:
-			panic(sg003)
-		}
-	} else {
-		panic(sg002)
-	}
+// The err parameter specifies the error; nothing is written if it is nil.
+// The values parameter consists of zero or multiple values that are logged in front of the error.
+// The same errors as of Write are returned.
+func WriteIfError(destination int, err error, values ...any) error {
+	return s.writeIfError(destination, err, values...)
+}
+
+// WriteUnless writes a log message to a specified destination, unless a condition is true. It is the counterpart of
+// ConditionalWrite, e.g. WriteUnless(ok, FILE, "cache miss").
+// The condition parameter disables (true) or enables (false) whether or not a message is written.
+// See Write for details of the other parameters and the returned errors.
+func WriteUnless(condition bool, destination int, values ...any) error {
+	return s.conditionalWrite(!condition, destination, values...)
+}
+
+// WriteString writes a preformatted line to a specified destination. Unlike Write, the line isn't formatted by
+// the log service, which makes WriteString the fastest way to log lines which are already formatted.
+// A trailing newline of the line is omitted.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The line parameter specifies the line to be logged.
+// An error is returned if the log service is not running or the log destination is unknown.
+func WriteString(destination int, line string) error {
+	return s.writeString(destination, line)
 }
 
-// ConditionalWrite writes or doesn't write a log message to a specified destination based on a condition.
-// The condition parameter enables (true) or disables (false) whether or not a message is written.
+// WriteBytes writes a preformatted line to a specified destination like WriteString.
+// The line is copied, so the caller may reuse the byte slice after WriteBytes returns.
+func WriteBytes(destination int, line []byte) error {
+	return s.writeString(destination, string(line))
+}
+
+// WriteBatch writes a batch of log messages to a specified destination. The batch is passed to the log service
+// at once and its log messages are written contiguously, i.e. they aren't interleaved with log messages of other
+// goroutines, e.g. when the collected results of a worker pool are flushed.
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The records parameter specifies the values of each log message, which are logged like the values of Write.
+// An error is returned if the log service is not running or the log destination is unknown.
+func WriteBatch(destination int, records [][]any) error {
+	return s.writeBatch(destination, records)
+}
+
+// TryWrite writes a log message to a specified destination like Write, but never waits while the data channel is
+// full, regardless of the drop policy. Use it on latency-critical paths, e.g. request handlers, which must never
+// stall on logging.
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+// It returns true, if the log message was passed to the log service, false otherwise, e.g. if the data channel
+// was full or the log service is not running.
+func TryWrite(destination int, values ...any) bool {
+	return s.tryWrite(destination, values...)
+}
+
+// WriteTimeout writes a log message to a specified destination like Write, but waits at most the timeout while
+// the data channel is full, regardless of the drop policy.
+// The timeout parameter specifies the maximum wait time; if it isn't positive, WriteTimeout doesn't wait at all.
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+// An error is returned if the log service is not running, the log destination is unknown or the log message was
+// dropped, as the data channel was still full after the timeout (ErrQueueFull).
+func WriteTimeout(timeout time.Duration, destination int, values ...any) error {
+	return s.writeTimeout(timeout, destination, values...)
+}
+
+// WriteSync writes a log message to a specified destination like Write, but returns only after the log message
+// and all log messages written before have been written and flushed to their log destinations.
+// Use it for critical log records or in tests which immediately read the log file.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+// An error is returned if the log service is not running or the log destination is unknown.
+func WriteSync(destination int, values ...any) error {
+	return s.writeSync(destination, values...)
+}
+
+// WriteError writes an error as log message of level ERROR to a specified destination. Instead of only the message
+// of the error, the complete chain of wrapped errors is written, each wrapped error with its type on a line of its
+// own, e.g. "  caused by *fs.PathError: open app.yaml: permission denied". If an error of the chain provides a stack
+// trace by the verb %+v, e.g. an error of github.com/pkg/errors, it is written on the lines following the log record.
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The err parameter specifies the error; nothing is written if it is nil.
+// An error is returned if the log service is not running or the log destination is unknown.
+func WriteError(destination int, err error) error {
+	return s.writeError(destination, err)
+}
+
+// WriteWithStack writes a log message to a specified destination, followed by the stack trace of the calling
+// goroutine. The stack trace is captured when WriteWithStack is called, i.e. before the log message is passed
+// to the log service.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+// An error is returned if the log service is not running or the log destination is unknown.
+func WriteWithStack(destination int, values ...any) error {
+	return s.writeWithStack(destination, values...)
+}
+
+// WriteKV writes a log message with structured fields to a specified destination.
+// In TEXT format, the fields are appended to the message as key=value pairs, e.g. "request done user=bob status=200".
+// Values which are empty or contain blanks, quotes or equal signs are quoted. In JSON format, the fields
+// are written as JSON object in the field "fields" of the log record.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The msg parameter specifies the log message.
+// The keysAndValues parameter consists of alternating keys and values, whereas keys must be strings.
+// ErrInvalidFields is returned if the keys and values aren't specified as pairs with string keys;
+// otherwise the same errors as of Write are returned.
+func WriteKV(destination int, msg string, keysAndValues ...any) error {
+	return s.writeKV(destination, msg, keysAndValues...)
+}
+
+// WriteFields writes a log message with additional strongly typed structured fields to a specified destination,
+// e.g. WriteFields(FILE, "request done", Str("user", u), Int("status", 200), Dur("latency", d)). Unlike the keys
+// and values of WriteKV, the values of the fields aren't boxed into interfaces by the caller.
+// The fields are written like the structured fields of WriteKV.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The msg parameter specifies the log message.
+// The fields parameter consists of the fields created by Int, Uint, Float, Str, Bool, Dur, Err or Any.
+// The same errors as of Write are returned.
+func WriteFields(destination int, msg string, fields ...Field) error {
+	return s.writeFields(destination, msg, fields...)
+}
+
+// WriteTagged writes a log message with tags to a specified destination, e.g.
+// WriteTagged(FILE, []string{"auth", "slow"}, "login took", d). The tags are used by the tag filters set by
+// SetTagFilter and are written as tags in JSON format.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The tags parameter specifies the tags of the log message, e.g. the subsystems it belongs to.
+// The values parameter consists of one or multiple values that are logged.
+// The same errors as of Write are returned.
+func WriteTagged(destination int, tags []string, values ...any) error {
+	return s.writeTagged(destination, tags, values...)
+}
+
+// StartTimer starts a Timer, which measures the duration of an operation, e.g.
+// t := StartTimer("db.query"); defer t.Log(FILE). The Log method of the Timer writes the name of the operation and
+// the elapsed duration as structured field, e.g. "db.query elapsed=12.3456ms".
+// The name parameter specifies the name of the operation.
+func StartTimer(name string) *Timer {
+	return s.startTimer(name)
+}
+
+// Derive returns a Child, a lightweight handle for a component of the application, e.g. an HTTP server or a
+// database layer. Log messages written by the Child carry the component name after the prefix of the log
+// destination, e.g. "2023-01-02 15:04:05 [http] request served", and the structured fields of the Child in
+// addition to their own ones. A Child can derive further children, whose component names are appended.
+// The component parameter specifies the name of the component, e.g. "[http]".
+// The keysAndValues parameter consists of alternating keys and values, whereas keys must be strings;
+// otherwise, writing log messages by the Child returns ErrInvalidFields.
+func Derive(component string, keysAndValues ...any) *Child {
+	return s.derive(component, nil, keysAndValues)
+}
+
+// GetLogger returns the Child of the named component, e.g. "http" or "db", which is created on first use.
+// Log messages written by the Child carry the component name in brackets, e.g. [http], after the prefix of the
+// log destination. Each named component has its own minimum level and log destinations, which can be changed at
+// runtime by SetComponentLevel and SetComponentDestinations, e.g. to turn on verbose logging for just one
+// subsystem. Log messages filtered by them aren't passed to the log service at all.
+// The name parameter specifies the name of the component.
+func GetLogger(name string) *Child {
+	return s.getLogger(name)
+}
+
+// SetComponentLevel sets the minimum level of log records written by the named component.
+// Log records written by Write or Writef of the component don't have a level and are always written.
+// The name parameter specifies the name of the component, see GetLogger.
+// The level parameter specifies the minimum level, e.g. DEBUG.
+// ErrUnknownLevel is returned if the level is unknown.
+func SetComponentLevel(name string, level int) error {
+	return s.setComponentLevel(name, level)
+}
+
+// SetComponentDestinations restricts the log destinations log records of the named component are written to.
+// Log records addressed to other log destinations are written only to the allowed ones, if any.
+// The name parameter specifies the name of the component, see GetLogger.
+// The destinations parameter specifies the allowed log destinations, e.g. STDOUT | FILE; 0 removes the restriction.
+// ErrUnknownDestination is returned if a log destination is unknown.
+func SetComponentDestinations(name string, destinations int) error {
+	return s.setComponentDestinations(name, destinations)
+}
+
+// WithContext returns a copy of the context ctx which carries the given structured fields in addition to the
+// structured fields already carried by ctx. The fields are written with every log message written by WriteCtx
+// with this context, which makes it possible to add request-scoped data, e.g. a request ID, to all log records
+// of a request.
+// The keysAndValues parameter consists of alternating keys and values, whereas keys must be strings.
+func WithContext(ctx context.Context, keysAndValues ...any) context.Context {
+	parent := contextFields(ctx)
+	fields := make([]any, 0, len(parent)+len(keysAndValues))
+	fields = append(fields, parent...)
+	fields = append(fields, keysAndValues...)
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+// WriteCtx writes a log message with the structured fields carried by the context ctx to a specified destination.
+// The fields are written the same way as the fields of WriteKV.
+// The ctx parameter specifies the context carrying the structured fields set by WithContext.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The values parameter consists of one or multiple values that are logged.
+// The same errors as of WriteKV are returned.
+func WriteCtx(ctx context.Context, destination int, values ...any) error {
+	return s.writeCtx(ctx, destination, values...)
+}
+
+// Log writes a log message with a log level to a specified destination.
+// The log message is only written, if its level is equal to or higher than the level set for the destination by SetLevel.
+// The level parameter specifies the log level of the message, e.g. DEBUG, INFO, WARN, ERROR or FATAL.
 // The destination parameter specifies the log destination, where the data will be written to.
 // The logValues parameter consists of one or multiple values that are logged.
-func ConditionalWrite(condition bool, destination int, values ...any) {
-	if s.isActive() {
-		if condition {
-			switch destination {
-			case STDOUT:
-				s.dataQueue <- logMessage{STDOUT, values}
-			case FILE:
-				s.dataQueue <- logMessage{FILE, values}
-			case MULTI:
-				s.dataQueue <- logMessage{MULTI, values}
-			default:
-				panic(sg003)
-			}
-		}
-	} else {
-		panic(sg002)
+// An error is returned if the log service is not running, the destination is unknown or
+// the log message should be written to a log file, network log or webhook log which has not been setup.
+func Log(level int, destination int, values ...any) error {
+	return s.log(level, destination, values...)
+}
+
+// Fatal writes a log record of level FATAL to a log destination and terminates the program by calling os.Exit(1).
+// Before, all log messages in the data channel and all buffered log records are written synchronously, so the
+// tail of the log isn't lost.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+func Fatal(destination int, values ...any) {
+	s.fatal(destination, values...)
+}
+
+// Panicw writes a log record of level FATAL to a log destination and panics with the values formatted like
+// fmt.Sprint. Before, all log messages in the data channel and all buffered log records are written synchronously.
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The values parameter specifies the values to be logged.
+func Panicw(destination int, values ...any) {
+	s.panicw(destination, values...)
+}
+
+// RecoverAndLog recovers a panic and writes a log record of level FATAL with the panic value and the stack trace of
+// the panicking goroutine to a log destination. Before it returns, the log is flushed synchronously.
+// RecoverAndLog must be deferred directly, e.g. defer simplelog.RecoverAndLog(simplelog.FILE, true).
+//
+// The destination parameter specifies the log destination, which can be a single one or a combination of them.
+// The repanic parameter specifies whether RecoverAndLog panics again with the recovered panic value.
+func RecoverAndLog(destination int, repanic bool) {
+	if r := recover(); r != nil {
+		s.logPanic(destination, r, repanic)
 	}
 }
+
+// Healthy returns true, if the log service is running and its goroutine isn't stuck, e.g. for a /healthz endpoint.
+// The log service records a heartbeat every second; it is healthy as long as its last heartbeat isn't older than
+// five seconds. A log service which is stuck, e.g. writing to a log destination which doesn't accept data, isn't
+// healthy.
+func Healthy() bool {
+	return s.healthy()
+}
+
+// LastHeartbeat returns the point in time of the last heartbeat of the log service.
+// The zero time is returned if the log service has never been started.
+func LastHeartbeat() time.Time {
+	return s.lastHeartbeat()
+}
+
+// SetHeartbeatRecord sets a heartbeat record, which is written as log record of level INFO to a log destination
+// if no other log records were written to it within an interval, e.g. "still alive". So log-based monitoring can
+// distinguish a quiet application from a dead one.
+// The destination parameter specifies the log destination the heartbeat records are written to.
+// The interval parameter specifies the interval, e.g. 5*time.Minute; 0 stops the heartbeat records.
+// The text parameter specifies the text of the heartbeat records; "still alive" is written if it is empty.
+// An error is returned if the log service is not running, the interval is negative or the log destination is
+// unknown.
+func SetHeartbeatRecord(destination int, interval time.Duration, text string) error {
+	return s.setHeartbeatRecord(destination, interval, text)
+}
+
+// SetWatchdogCallback sets a function which is called if the log service missed heartbeats, i.e. if its goroutine
+// is stuck for more than five seconds, e.g. writing to a log destination which doesn't accept data. The function
+// gets the number of missed heartbeats and the point in time of the last heartbeat. It is called once per stall
+// by a dedicated watchdog goroutine and must not write log messages, since the log service doesn't accept them.
+// A nil function removes the watchdog callback.
+func SetWatchdogCallback(callback WatchdogCallback) error {
+	return s.setWatchdogCallback(callback)
+}
+
+// InternalErrors returns the last internal errors of the log service, the oldest first, e.g. failed writes to log
+// destinations or failed log file rotations. Up to 100 internal errors are kept until the log service is started
+// again. Besides, a diagnostic record is written directly to stderr for each internal error, since the log
+// service never panics because of a failing log destination.
+func InternalErrors() []InternalError {
+	return s.getInternalErrors()
+}
+
+// SetErrorHandler sets a function which is called for each log record which couldn't be written to a log
+// destination, e.g. because the disk is full or the log file was removed. The function gets the error and the log
+// record, whose Destination is the failing log destination. It is called by the log service and must not call
+// functions which wait for the log service, e.g. WriteSync or the Set functions. A nil function removes the error
+// handler.
+func SetErrorHandler(handler ErrorHandler) error {
+	return s.setErrorHandler(handler)
+}
+
+// SetFallback sets the fallback log destinations of a log destination, e.g. SetFallback(FILE, STDERR).
+// Log records which couldn't be written to the log destination are written to its fallback log destinations
+// instead, so that they aren't lost. A fallback of 0 removes the fallback log destinations.
+func SetFallback(destination, fallback int) error {
+	return s.setFallback(destination, fallback)
+}
+
+// SetRetry sets the time window in which transient write failures of the log file and the network log, e.g.
+// EAGAIN, ENOSPC or a broken pipe, are retried with exponential backoff before they are handled as errors.
+// The retries don't change the order of the log records. A window of 0, which is the default, disables retries.
+func SetRetry(window time.Duration) error {
+	return s.setRetry(window)
+}
+
+// SetDiskGuard sets the minimum free disk space in bytes of the file system of the log file and the low disk space
+// mode, a combination of ROTATELOG, DROPDEBUG and STDOUTONLY. The free disk space is checked immediately and then
+// every 10 seconds. When it falls below the minimum, a log record of level WARN is written to STDERR and
+// the log service degrades according to the mode until the free disk space recovers:
+//   - ROTATELOG: the log file is rotated once and the archived log file is compressed with gzip.
+//   - DROPDEBUG: log records of level DEBUG aren't written to the log file.
+//   - STDOUTONLY: log records of the log file are written to stdout instead.
+//
+// A minimum of 0 disables the check. On platforms where the free disk space can't be determined, the check is
+// reported as internal error.
+func SetDiskGuard(minFree uint64, mode int) error {
+	return s.setDiskGuard(minFree, mode)
+}
+
+// SetFileLock enables or disables the locking of the log file, so that several processes, e.g. forked workers,
+// can safely append to the same log file. If enabled, whole lines are written at the end of the log file while
+// holding an exclusive advisory lock (flock) on it, hence lines of different processes don't interleave.
+// ErrNotSupported is returned on platforms without advisory locks, e.g. Windows.
+func SetFileLock(enabled bool) error {
+	return s.setFileLock(enabled)
+}
+
+// Stats returns the metrics of the log service, e.g. the number of log records written per log destination and
+// per level, the number of dropped log messages and the current number of log messages in the data channel.
+// The metrics are collected by the log service since it was started.
+// An error is returned if the log service is not running.
+func Stats() (ServiceStats, error) {
+	return s.getStats()
+}
+
+// SetStatsSummary sets the periodic summary of the metrics of the log service, so that operators can see the
+// health of the logging inside the log itself. Every interval, a log record of level INFO is written with the
+// number of log records written per level and per log destination, the number of dropped and rate limited log
+// records and the highest number of queued log messages since the last summary, e.g.
+// "log statistics written=12 written.error=1 written.file=12 dropped=0 rate_limited=0 queue_high_water=3".
+// The destination parameter specifies the log destination the summaries are written to.
+// The interval parameter specifies the interval between two summaries, e.g. 5*time.Minute; 0 stops the summaries.
+// An error is returned if the log service is not running, the interval is negative or the log destination is
+// unknown.
+func SetStatsSummary(destination int, interval time.Duration) error {
+	return s.setStatsSummary(destination, interval)
+}
+
+// Writer returns an io.Writer which writes data as log messages to a specified destination.
+// Each line written to the io.Writer becomes a separate log record, which makes it possible to redirect the output
+// of third-party code, e.g. http.Server.ErrorLog, exec.Cmd or the standard library log package, to the log service.
+// Lines are expected to be written completely by one call of the Write method of the io.Writer.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The Write method of the io.Writer returns the same errors as Write.
+func Writer(destination int) io.Writer {
+	return newDestinationWriter(s, destination)
+}
+
+// CaptureStdLog redirects the output of the standard library log package, e.g. log.Printf calls of dependencies,
+// to a specified destination, so that it is written with the prefix of the log destination instead of to stderr.
+// The flags of the log package are cleared, as the prefix of the log destination is placed in front of each log
+// record. The former output and flags of the log package are restored when the log service is stopped.
+// The destination parameter specifies the log destination, where the output will be written to.
+// An error is returned if the log service is not running or the log destination is unknown.
+func CaptureStdLog(destination int) error {
+	return s.captureStdLog(destination)
+}
+
+// CaptureOutput redirects the file descriptors of stdout and stderr of the process to a specified destination,
+// which captures output that bypasses the log service entirely, e.g. of C libraries or of child processes
+// inheriting stdout and stderr. Each line of the output is written as log record with the component name [stdout]
+// or [stderr]. The STDOUT and STDERR log destinations keep writing to the original stdout and stderr.
+// The file descriptors are restored when the log service is stopped. As file descriptors belong to the process,
+// stdout and stderr can be captured by one log service at a time; the capture is only supported on Unix systems.
+// The destination parameter specifies the log destination, where the output will be written to.
+// An error is returned if the log service is not running, the log destination is unknown, stdout and stderr are
+// already captured or the file descriptors can't be redirected.
+func CaptureOutput(destination int) error {
+	return s.captureOutput(destination)
+}
+
+// LogCmd starts a command, whose stdout and stderr are written to a specified destination, one log record per
+// line, which replaces the goroutines scanning the output of each child process. The log records of stdout and
+// stderr carry the prefix followed by the component name [stdout] or [stderr], e.g. [backup] [stderr].
+// The command has to be waited for by its Wait method as usual. Shutdown waits until the commands started by LogCmd
+// closed their stdout and stderr, i.e. usually until they exited, so that their complete output is written;
+// ShutdownContext waits at most until its context expires.
+// The cmd parameter specifies the command, whose Stdout and Stderr must not be set.
+// The destination parameter specifies the log destination, where the output will be written to.
+// The prefix parameter specifies the component name of the command, e.g. [backup]; empty if not used.
+// An error is returned if the log service is not running, the log destination is unknown, Stdout or Stderr of the
+// command is already set or the command can't be started.
+func LogCmd(cmd *exec.Cmd, destination int, prefix string) error {
+	return s.logCmd(cmd, destination, prefix)
+}
+
+// StdLogger returns a *log.Logger of the standard library which writes its output as log messages to a specified
+// destination, so that code holding a *log.Logger, e.g. http.Server.ErrorLog or database drivers, writes to the log
+// service without changes. The *log.Logger writes no date or time, as the prefix of the log destination is placed
+// in front of each log record.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The prefix parameter specifies the prefix of the *log.Logger, which is placed in front of each log message.
+func StdLogger(destination int, prefix string) *log.Logger {
+	return newStdLogger(s, destination, prefix)
+}
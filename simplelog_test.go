@@ -1,11 +1,34 @@
+//go:build !simplelog_off
+
 package simplelog
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestStartup(t *testing.T) {
@@ -38,6 +61,413 @@ func TestShutdown(t *testing.T) {
 	}
 }
 
+func TestPriorityQueue(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 4)}
+
+	Startup(3)
+	destination, _ := RegisterDestination("blocking", w)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the first log message
+	Log(INFO, destination, "message 2")
+	Log(INFO, destination, "message 3")
+	Log(ERROR, destination, "disk full")
+	go func() {
+		for range w.writing {
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		w.release <- struct{}{}
+	}
+	Shutdown(false)
+	close(w.writing)
+
+	expected := "message 1\nERROR disk full\nINFO message 2\nINFO message 3\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := &blockingWriter{writing: make(chan struct{}, 2), release: make(chan struct{}, 2)}
+	a := newAsyncWriter(w, nil)
+
+	// writing must not be delayed by the blocked underlying writer
+	a.Write([]byte("record 1\n"))
+	a.Write([]byte("record 2\n"))
+	if output := w.String(); output != "" {
+		t.Error("Expected no output before the underlying writer is released but got:", output)
+	}
+	w.release <- struct{}{}
+	w.release <- struct{}{}
+	if err := a.close(); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+
+	expected := "record 1\nrecord 2\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected output:", expected, "- but got:", output)
+	}
+}
+
+type flakyWriter struct {
+	bytes.Buffer
+	failures int // number of writes which fail before data is accepted
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.failures > 0 {
+		w.failures--
+		return 0, syscall.EAGAIN
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteRetry(t *testing.T) {
+	window := int64(time.Second)
+	w := &flakyWriter{failures: 2}
+	a := newAsyncWriter(w, &window)
+
+	a.Write([]byte("record 1\n"))
+	a.Write([]byte("record 2\n"))
+	if err := a.close(); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	expected := "record 1\nrecord 2\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected output:", expected, "- but got:", output)
+	}
+
+	w = &flakyWriter{failures: 2}
+	if _, err := writeRetry(w, []byte("record 1\n"), nil); err != syscall.EAGAIN {
+		t.Error("Expected error", syscall.EAGAIN, "but got", err)
+	}
+}
+
+func TestSpill(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 4)}
+
+	Startup(1)
+	destination, _ := RegisterDestination("blocking", w)
+	SetDropPolicy(SPILL)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the first log message
+	Write(destination, "message 2")
+	Write(destination, "message 3")
+	WriteKV(destination, "message 4", "answer", 42)
+	if !s.overflow.pending() {
+		t.Error("Expected spilled log messages")
+	}
+	go func() {
+		for range w.writing {
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		w.release <- struct{}{}
+	}
+	Shutdown(false)
+	close(w.writing)
+
+	expected := "message 1\nmessage 2\nmessage 3\nmessage 4 answer=42\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetBufferSize(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetBufferSize(-1); err != ErrInvalidBufferSize {
+		t.Error("Expected error", ErrInvalidBufferSize, "but got", err)
+	}
+	Write(destination, "message 1")
+	SetBufferSize(100)
+	Write(destination, "message 2")
+	if size := cap(s.dataQueue); size != 100 {
+		t.Error("Expected buffer size", 100, "but got", size)
+	}
+	Shutdown(false)
+
+	expected := "message 1\nmessage 2\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetBufferSizeConcurrently(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	var wg sync.WaitGroup
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Write(destination, "message", j)
+			}
+		}()
+	}
+	for _, size := range []int{1, 10, 2, 0, 5} {
+		if err := SetBufferSize(size); err != nil {
+			t.Error("Expected no error but got", err)
+		}
+	}
+	wg.Wait()
+	Shutdown(false)
+
+	if written := strings.Count(buf.String(), "\n"); written != 400 {
+		t.Error("Expected log records", 400, "but got", written)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+	t.Setenv("SIMPLELOG_FILE", logFile)
+	t.Setenv("SIMPLELOG_LEVEL", "warn")
+	t.Setenv("SIMPLELOG_BUFFER", "10")
+	t.Setenv("SIMPLELOG_PREFIX", "#2006-01-02 15:04:05# app:")
+
+	destination, err := ConfigFromEnv()
+	if err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if destination != FILE {
+		t.Error("Expected destination", FILE, "but got", destination)
+	}
+	if size := cap(s.dataQueue); size != 10 {
+		t.Error("Expected buffer size", 10, "but got", size)
+	}
+	Log(INFO, destination, "Don't panic")
+	Log(WARN, destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.HasSuffix(lines[0], " app: WARN The answer to all questions is 42") || len(lines[0]) != 63 {
+		t.Error("Expected log record:", "<date> <time> app: WARN The answer to all questions is 42", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+
+	t.Setenv("SIMPLELOG_FILE", "")
+	t.Setenv("SIMPLELOG_DESTINATION", "stdout,printer")
+	if _, err := ConfigFromEnv(); err != ErrUnknownDestination {
+		t.Error("Expected error", ErrUnknownDestination, "but got", err)
+	}
+	Shutdown(false)
+}
+
+func TestLoadConfig(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "test1.log")
+	configFile := filepath.Join(dir, "simplelog.json")
+	config := `{
+	  "buffer": 10,
+	  "file": {"name": "` + filepath.ToSlash(logFile) + `"},
+	  "destinations": {"file": {"prefix": ["app:"], "level": "warn"}}
+	}`
+	os.WriteFile(configFile, []byte(config), 0644)
+
+	if err := LoadConfig(filepath.Join(dir, "simplelog.yaml")); err != ErrUnknownFormat {
+		t.Error("Expected error", ErrUnknownFormat, "but got", err)
+	}
+	if err := LoadConfig(configFile); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Log(INFO, FILE, "Don't panic")
+	Log(WARN, FILE, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if string(data) != "\napp: WARN The answer to all questions is 42\n" {
+		t.Error("Expected log record:", "app: WARN The answer to all questions is 42", "- but got:", string(data))
+	}
+}
+
+func TestSwitchLogAppend(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	dir := t.TempDir()
+	logFile1 := filepath.Join(dir, "test1.log")
+	logFile2 := filepath.Join(dir, "test2.log")
+
+	Startup(1)
+	SetupLog(logFile1, false)
+	WriteSync(FILE, "message 1")
+	SwitchLog(logFile2)
+	WriteSync(FILE, "message 2")
+	if err := SwitchLog(logFile1); err == nil {
+		t.Error("Expected error for existing file", logFile1, "but got none")
+	}
+	if err := SwitchLogAppend(logFile1); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	WriteSync(FILE, "message 3")
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile1); string(data) != "\nmessage 1\n\nmessage 3\n" {
+		t.Error("Expected log records:", "message 1, message 3", "- but got:", string(data))
+	}
+}
+
+func TestSetRunHeader(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	os.WriteFile(logFile, []byte("message 1\n"), 0644)
+
+	Startup(1)
+	SetRunHeader("=== run of " + cmdTag + " (pid " + pidTag + ") ===")
+	SetupLog(logFile, true)
+	Write(FILE, "message 2")
+	Shutdown(false)
+
+	expected := "message 1\n=== run of " + strings.Join(os.Args, " ") + " (pid " + strconv.Itoa(os.Getpid()) + ") ===\nmessage 2\n"
+	if data, _ := os.ReadFile(logFile); string(data) != expected {
+		t.Error("Expected log records:", expected, "- but got:", string(data))
+	}
+}
+
+func TestSetRunFooter(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := filepath.Join(t.TempDir(), "test.log")
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetRunFooter(true)
+	Write(FILE, "message 1")
+	Write(STDOUT, "message 2")
+	Write(FILE, "message 3")
+	Shutdown(false)
+
+	data, _ := os.ReadFile(logFile)
+	expected := "\nmessage 1\nmessage 3\n=== run ended: 2 log records written, 0 dropped, 0 rate limited, uptime "
+	if !strings.HasPrefix(string(data), expected) || !strings.HasSuffix(string(data), " ===\n") {
+		t.Error("Expected log records followed by the run footer:", expected, "- but got:", string(data))
+	}
+}
+
+func TestReopen(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "test1.log")
+	movedFile := logFile + ".1"
+
+	Startup(1)
+	SetupLog(logFile, false)
+	WriteSync(FILE, "message 1")
+	os.Rename(logFile, movedFile) // like logrotate
+	Reopen()
+	WriteSync(FILE, "message 2")
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(movedFile); string(data) != "\nmessage 1\n" {
+		t.Error("Expected log record:", "message 1", "- but got:", string(data))
+	}
+	if data, _ := os.ReadFile(logFile); string(data) != "\nmessage 2\n" {
+		t.Error("Expected log record:", "message 2", "- but got:", string(data))
+	}
+}
+
+func TestRecoverLogFile(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := filepath.Join(t.TempDir(), "test1.log")
+
+	Startup(1)
+	SetupLog(logFile, false)
+	WriteSync(FILE, "message 1")
+	os.Remove(logFile)
+	// the log file is checked with the periodic flush
+	for i := 0; i < 30; i++ {
+		if _, err := os.Stat(logFile); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	WriteSync(FILE, "message 2")
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Error("Expected to find recreated file", logFile, "- but got:", err)
+	} else if !strings.Contains(string(data), "WARN log file "+logFile+" was removed externally and has been reopened\nmessage 2\n") {
+		t.Error("Expected notice followed by log record:", "message 2", "- but got:", string(data))
+	}
+}
+
+func TestShutdownContext(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 3)}
+
+	Startup(2)
+	destination, _ := RegisterDestination("blocking", w)
+	Write(destination, "message 1")
+	<-w.writing // the log service is stuck writing the first log message
+	Write(destination, "message 2")
+	Write(destination, "message 3")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	unflushed, err := ShutdownContext(ctx, false)
+
+	if err != context.DeadlineExceeded {
+		t.Error("Expected error", context.DeadlineExceeded, "but got", err)
+	}
+	if unflushed != 2 {
+		t.Error("Expected unflushed log messages", 2, "but got", unflushed)
+	}
+	if a := s.isActive(); a == true {
+		t.Error("Expected state false but got", a)
+	}
+	// let the abandoned log service continue
+	go func() {
+		for range w.writing {
+		}
+	}()
+	close(w.release)
+}
+
+func TestErrors(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	if err := Write(STDOUT, "The answer to all questions is", 42); err != ErrNotRunning {
+		t.Error("Expected error", ErrNotRunning, "but got:", err)
+	}
+	if err := Shutdown(false); err != ErrNotRunning {
+		t.Error("Expected error", ErrNotRunning, "but got:", err)
+	}
+
+	Startup(1)
+	if err := Startup(1); err != ErrAlreadyRunning {
+		t.Error("Expected error", ErrAlreadyRunning, "but got:", err)
+	}
+	if err := Write(FILE, "The answer to all questions is", 42); err != ErrNoLogFile {
+		t.Error("Expected error", ErrNoLogFile, "but got:", err)
+	}
+	if err := Write(42, "The answer to all questions is", 42); err != ErrUnknownDestination {
+		t.Error("Expected error", ErrUnknownDestination, "but got:", err)
+	}
+	if err := Log(42, STDOUT, "The answer to all questions is", 42); err != ErrUnknownLevel {
+		t.Error("Expected error", ErrUnknownLevel, "but got:", err)
+	}
+	if err := Shutdown(false); err != nil {
+		t.Error("Expected no error but got:", err)
+	}
+}
+
 func TestChangeLogFile(t *testing.T) {
 	logFile1 := "test1.log"
 	logFile2 := "test2.log"
@@ -79,88 +509,185 @@ func TestChangeLogFile(t *testing.T) {
 	}
 }
 
-func TestSetPrefix(t *testing.T) {
+func TestRotation(t *testing.T) {
+	now := time.Date(2025, 1, 1, 13, 45, 30, 0, time.Local)
+
+	if next := nextRotation(now, 24*time.Hour); !next.Equal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)) {
+		t.Error("Expected next daily rotation at midnight but got", next)
+	}
+	if next := nextRotation(now, time.Hour); !next.Equal(time.Date(2025, 1, 1, 14, 0, 0, 0, time.Local)) {
+		t.Error("Expected next hourly rotation at 14:00 but got", next)
+	}
+	if suffix := rotationSuffix(now, 24*time.Hour); suffix != "20250101" {
+		t.Error("Expected rotation suffix 20250101 but got", suffix)
+	}
+	if suffix := rotationSuffix(now, time.Hour); suffix != "2025010113" {
+		t.Error("Expected rotation suffix 2025010113 but got", suffix)
+	}
+}
+
+func TestRotationArchiveFailure(t *testing.T) {
 	s = new(simpleLogService) // reset service instance
-	logFile := "test1.log"
-	expectedPrefix := "#2006-01-02 15:04:05.000000#[Test]"
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "test.log")
+	// the archive directory can't be created below a regular file
+	blocker := filepath.Join(dir, "blocker")
+	os.WriteFile(blocker, nil, 0644)
 
 	Startup(1)
-	SetPrefix(STDOUT, "#2006-01-02 15:04:05.000000#", "[Test]")
-	SetPrefix(FILE, "#2006-01-02 15:04:05.000000#", "[Test]")
+	SetupLog(logFile, false)
+	SetArchive(filepath.Join(blocker, "archive"), "")
+	WriteSync(FILE, "message 1")
+	SetRotation(50 * time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	SetRotation(0)
+	err := WriteSync(FILE, "message 2")
+	internalErrors := InternalErrors()
 	Shutdown(false)
 
-	var prefix string
-	for _, v := range s.stdoutLogger.prefix {
-		prefix += v
+	if err != nil {
+		t.Error("Expected no error but got", err)
 	}
-	if !strings.Contains(prefix, expectedPrefix) {
-		t.Error("Expected to find:", expectedPrefix, "- but found:", prefix)
+	if len(internalErrors) == 0 {
+		t.Error("Expected the failed archiving as internal error but got none")
+	}
+	if data, _ := os.ReadFile(logFile); !strings.Contains(string(data), "message 1\n") || !strings.Contains(string(data), "message 2\n") {
+		t.Error("Expected log records:", "message 1, message 2", "- but got:", string(data))
 	}
+}
 
-	prefix = ""
-	for _, v := range s.fileLogger.prefix {
-		prefix += v
+func TestReopenAfterFailedSetupLog(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "test.log")
+
+	Startup(1)
+	SetupLog(logFile, false)
+	if err := SetupLog(filepath.Join(dir, "missing", "test.log"), false); err == nil {
+		t.Error("Expected error for missing directory but got none")
 	}
-	if !strings.Contains(prefix, expectedPrefix) {
-		t.Error("Expected to find:", expectedPrefix, "- but found:", prefix)
+	WriteSync(FILE, "message 1")
+	if err := Reopen(); err != nil {
+		t.Error("Expected no error but got", err)
 	}
-	if _, err := os.Stat(logFile); err == nil {
-		os.Remove(logFile)
+	if err := SetAudit(true, []byte("secret")); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	WriteSync(FILE, "message 2")
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile); !strings.HasPrefix(string(data), "\nmessage 1\n\nmessage 2") {
+		t.Error("Expected log records:", "message 1, message 2", "- but got:", string(data))
 	}
 }
 
-func TestLogToStdout(t *testing.T) {
+func TestSetAudit(t *testing.T) {
 	s = new(simpleLogService) // reset service instance
-	stdOut := os.Stdout
 	logFile := "test1.log"
+	key := []byte("secret")
 
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
 
 	Startup(1)
-	Write(STDOUT, "The answer to all questions is", 42)
+	SetupLog(logFile, false)
+	SetAudit(true, key)
+	for i := 1; i <= 3; i++ {
+		Write(FILE, "The answer to all questions is", 42)
+	}
+	Shutdown(false)
+	// the chain is continued if the log file is appended
+	Startup(1)
+	SetupLog(logFile, true)
+	SetAudit(true, key)
+	Write(FILE, "The answer to all questions is", 42)
 	Shutdown(false)
 
-	_ = w.Close()
+	if err := Verify(logFile, key); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	data, _ := os.ReadFile(logFile)
+	lines := strings.Split(string(data), "\n")
+	if !strings.Contains(lines[len(lines)-2], " audit=4:") {
+		t.Error("Expected sequence number 4 in line:", lines[len(lines)-2])
+	}
+	lines[2] = strings.Replace(lines[2], "42", "43", 1)
+	os.WriteFile(logFile, []byte(strings.Join(lines, "\n")), 0644)
+	if err := Verify(logFile, key); !errors.Is(err, ErrAuditViolation) {
+		t.Error("Expected error", ErrAuditViolation, "but got", err)
+	}
+	os.Remove(logFile)
+}
 
-	result, _ := io.ReadAll(r)
-	output := string(result)
+func TestAddRoute(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	errorLog := filepath.Join(t.TempDir(), "error.log")
+	accessLog := filepath.Join(t.TempDir(), "access.log")
 
-	os.Stdout = stdOut
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := AddRoute(Route{Level: ERROR}); err != ErrInvalidRoute {
+		t.Error("Expected error", ErrInvalidRoute, "but got", err)
+	}
+	AddRoute(Route{Level: ERROR, File: errorLog})
+	AddRoute(Route{Level: FATAL, File: errorLog})
+	AddRoute(Route{Component: "[http]", Pattern: "^GET ", File: accessLog})
+	Log(ERROR, destination, "The answer to all questions is", 42)
+	Log(INFO, destination, "The answer to all questions is", 42)
+	Derive("[http]").Write(destination, "GET /index.html")
+	Derive("[http]").Write(destination, "server started")
+	s.sync()
+	stats, _ := Stats()
+	Shutdown(false)
 
-	if !strings.Contains(output, "The answer to all questions is "+fmt.Sprint(42)) {
-		t.Error("Expected to find:", "The answer to all questions is "+fmt.Sprint(42), "- but found:", output)
+	if stats.Written[FILE] != 0 || stats.Routed[errorLog] != 1 || stats.Routed[accessLog] != 1 {
+		t.Error("Expected 1 log record per routed log file and none of the log file but got", stats.Written[FILE], stats.Routed)
 	}
-	if _, err := os.Stat(logFile); err == nil {
-		os.Remove(logFile)
+	if data, _ := os.ReadFile(errorLog); string(data) != "\nERROR The answer to all questions is 42\n" {
+		t.Error("Expected log record:", "ERROR The answer to all questions is 42", "- but got:", string(data))
+	}
+	if data, _ := os.ReadFile(accessLog); string(data) != "\n[http] GET /index.html\n" {
+		t.Error("Expected log record:", "[http] GET /index.html", "- but got:", string(data))
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Error("Expected 4 log records but got:", buf.String())
 	}
 }
 
-func TestLogToFile(t *testing.T) {
-	s = new(simpleLogService) // reset service instance
-	logFile := "test1.log"
-
-	if _, err := os.Stat(logFile); err == nil {
-		os.Remove(logFile)
+func TestFailedRouteWrite(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail the writes to the routed log file")
 	}
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	var failed []string
 
 	Startup(1)
-	SetupLog(logFile, false)
-	Write(FILE, "The answer to all questions is", 42)
+	AddRoute(Route{Level: ERROR, File: "/dev/full"})
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetFallback(FILE, destination)
+	SetErrorHandler(func(err error, rec *Record) {
+		failed = append(failed, fmt.Sprint(rec.Destination == FILE, " ", rec.Text()))
+	})
+	Log(ERROR, STDOUT, "message 1")
+	WriteSync(STDOUT, "message 2")
+	internalErrors := InternalErrors()
 	Shutdown(false)
 
-	data, err := os.ReadFile(logFile)
-
-	if err != nil {
-		t.Error("Expected to find file", logFile, "- but got:", err)
-	} else if !strings.Contains(string(data), "The answer to all questions is "+fmt.Sprint(42)) {
-		t.Error("Expected log record contains:", "The answer to all questions is "+fmt.Sprint(42), "- but it doesn't:", string(data))
-	} else {
-		os.Remove(logFile)
+	if !strings.Contains(buf.String(), "message 1") {
+		t.Error("Expected log record:", "message 1", "- but got:", buf.String())
+	}
+	if len(failed) != 1 || !strings.HasSuffix(failed[0], "message 1") {
+		t.Error("Expected error handler call:", "message 1", "- but got:", failed)
+	}
+	if len(internalErrors) == 0 {
+		t.Error("Expected internal error but got", internalErrors)
 	}
 }
 
-func TestConditionalLogToFile(t *testing.T) {
+func TestSetupRingLog(t *testing.T) {
 	s = new(simpleLogService) // reset service instance
 	logFile := "test1.log"
 
@@ -170,20 +697,1840 @@ func TestConditionalLogToFile(t *testing.T) {
 
 	Startup(1)
 	SetupLog(logFile, false)
-	ConditionalWrite(false, FILE, "The answer to all questions is", 42)
+	if err := Write(RING, "ring"); err != ErrNoRingLog {
+		t.Error("Expected error", ErrNoRingLog, "but got", err)
+	}
+	if err := SetupRingLog(0); err != ErrInvalidBufferSize {
+		t.Error("Expected error", ErrInvalidBufferSize, "but got", err)
+	}
+	if err := SetupRingLog(2); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	for i := 1; i <= 3; i++ {
+		Log(INFO, RING, "step", i)
+	}
+	Log(ERROR, RING, "failed")
+	Log(INFO, RING, "step", 4)
+	if err := DumpRing(); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile); string(data) != "\nINFO step 3\nERROR failed\nINFO step 4\n" {
+		t.Error("Expected log records:", "INFO step 3, ERROR failed, INFO step 4", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestTail(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	if records := Tail(1); records != nil {
+		t.Error("Expected no log records but got", records)
+	}
+	Startup(1)
+	SetPrefix(FILE, "#seq#")
+	for i := 1; i <= 3; i++ {
+		Write(STDOUT, "step", i)
+	}
+	records := Tail(2)
+	Shutdown(false)
+
+	if len(records) != 2 || records[0] != "2 step 2" || records[1] != "3 step 3" {
+		t.Error("Expected log records:", "2 step 2, 3 step 3", "- but got:", records)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	if _, _, err := Subscribe(1); err != ErrNotRunning {
+		t.Error("Expected error", ErrNotRunning, "but got", err)
+	}
+	Startup(1)
+	records, cancel, err := Subscribe(4)
+	if err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	server := httptest.NewServer(StreamHandler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal("Expected no error but got", err)
+	}
+	defer resp.Body.Close()
+	Write(STDOUT, "The answer to all questions is", 42)
+	if record := <-records; record != "The answer to all questions is 42" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", record)
+	}
+	event, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if event != "data: The answer to all questions is 42\n" {
+		t.Error("Expected event:", "data: The answer to all questions is 42", "- but got:", event)
+	}
+	cancel()
+	if _, ok := <-records; ok {
+		t.Error("Expected channel to be closed by cancel")
+	}
+	Shutdown(false)
+}
+
+func TestSetVerbose(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetLevel(FILE, WARN)
+	Log(DEBUG, FILE, "hidden")
+	SetVerbose(true)
+	Log(DEBUG, FILE, "verbose")
+	SetVerbose(false)
+	Log(DEBUG, FILE, "hidden")
+	Log(WARN, FILE, "restored")
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile); string(data) != "\nDEBUG verbose\nWARN restored\n" {
+		t.Error("Expected log records:", "DEBUG verbose, WARN restored", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestPause(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	if err := Pause(); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Write(FILE, "step", 1)
+	Write(FILE, "step", 2)
+	WriteSync(FILE, "step", 3)
+	if data, _ := os.ReadFile(logFile); strings.Contains(string(data), "step") {
+		t.Error("Expected no log records while paused but got:", string(data))
+	}
+	if err := Resume(); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Write(FILE, "step", 4)
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile); string(data) != "\nstep 1\nstep 2\nstep 3\nstep 4\n" {
+		t.Error("Expected log records:", "step 1, step 2, step 3, step 4", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	var written int32
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			for Write(FILE, "record") == nil {
+				atomic.AddInt32(&written, 1)
+			}
+			done <- struct{}{}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	Shutdown(false)
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	data, _ := os.ReadFile(logFile)
+	if n := strings.Count(string(data), "record\n"); n != int(atomic.LoadInt32(&written)) {
+		t.Error("Expected", atomic.LoadInt32(&written), "log records but got", n)
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	for i := 1; i <= 3; i++ {
+		if err := Startup(1); err != nil {
+			t.Error("Expected no error but got", err)
+		}
+		SetupLog(logFile, false)
+		if i == 1 {
+			SetPrefix(FILE, "[first]")
+		}
+		Write(FILE, "run", i)
+		errs := make(chan error, 2)
+		for j := 0; j < 2; j++ {
+			go func() {
+				errs <- Shutdown(false)
+			}()
+		}
+		if err1, err2 := <-errs, <-errs; (err1 == nil) == (err2 == nil) {
+			t.Error("Expected one Shutdown to fail with", ErrNotRunning, "but got", err1, "and", err2)
+		}
+		expected := "\n" + fmt.Sprint("run ", i) + "\n"
+		if i == 1 {
+			expected = "\n[first] run 1\n"
+		}
+		if data, _ := os.ReadFile(logFile); string(data) != expected {
+			t.Error("Expected log record:", strings.TrimSpace(expected), "- but got:", string(data))
+		}
+	}
+	os.Remove(logFile)
+}
+
+func TestOpenLogFile(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	destination, err := OpenLogFile("audit", auditLog)
+	if err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	if _, err = OpenLogFile("audit", auditLog); err != ErrDestinationExists {
+		t.Error("Expected error", ErrDestinationExists, "but got", err)
+	}
+	otherLog := filepath.Join(t.TempDir(), "other.log")
+	if _, err = OpenLogFile("audit", otherLog); err != ErrDestinationExists {
+		t.Error("Expected error", ErrDestinationExists, "but got", err)
+	}
+	if _, ok := s.logFiles[otherLog]; ok {
+		t.Error("Expected log file", otherLog, "to be closed again")
+	}
+	SetRetry(time.Second)
+	if window := s.logFiles[auditLog].retryWindow; window == nil || atomic.LoadInt64(window) != int64(time.Second) {
+		t.Error("Expected the retry window of the main log file for", auditLog)
+	}
+	if Named("audit") != destination {
+		t.Error("Expected log destination", destination, "but got", Named("audit"))
+	}
+	SetPrefix(Named("audit"), "[audit]")
+	Write(FILE|Named("audit"), "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if data, _ := os.ReadFile(logFile); string(data) != "\nThe answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+	if data, _ := os.ReadFile(auditLog); string(data) != "\n[audit] The answer to all questions is 42\n" {
+		t.Error("Expected log record:", "[audit] The answer to all questions is 42", "- but got:", string(data))
+	}
+	if Named("audit") != 0 {
+		t.Error("Expected named log file to be removed by Shutdown")
+	}
+}
+
+func TestSetArchive(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	if err := SetArchive(archiveDir, "{name}/{ts}"); err != ErrInvalidArchiveTemplate {
+		t.Error("Expected error", ErrInvalidArchiveTemplate, "but got", err)
+	}
+	if err := SetArchive(archiveDir, "{name}.{seq}"); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Write(FILE, "The answer to all questions is", 42)
+	Shutdown(true)
+
+	if _, err := os.Stat(logFile); err == nil {
+		t.Error("Expected archived log file", logFile, "to be moved")
+		os.Remove(logFile)
+	}
+	data, err := os.ReadFile(filepath.Join(archiveDir, logFile+".1"))
+	if err != nil {
+		t.Error("Expected to find archive", logFile+".1", "- but got:", err)
+	} else if string(data) != "\nThe answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", string(data))
+	}
+}
+
+func TestSetArchiveHook(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	var archived []string
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetArchive(t.TempDir(), "")
+	SetArchiveHook(func(archivedPath string) { archived = append(archived, archivedPath) })
+	Write(FILE, "The answer to all questions is", 42)
+	Shutdown(true)
+
+	if len(archived) != 1 {
+		t.Error("Expected 1 archived log file but got", len(archived))
+	} else if _, err := os.Stat(archived[0]); err != nil {
+		t.Error("Expected to find archive", archived[0], "- but got:", err)
+	}
+}
+
+// memoryStorage is an Uploader which stores the uploaded objects in memory.
+type memoryStorage struct {
+	objects map[string]string
+}
+
+func (m *memoryStorage) Upload(ctx context.Context, name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.objects[name] = string(data)
+	return nil
+}
+
+func TestSetDiskGuard(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	var archived []string
+	free := freeSpace
+	freeSpace = func(string) (uint64, error) { return 1 << 20, nil }
+	defer func() { freeSpace = free }()
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetArchive(t.TempDir(), "")
+	SetArchiveHook(func(archivedPath string) { archived = append(archived, archivedPath) })
+	Log(INFO, FILE, "message 1")
+	if err := SetDiskGuard(1<<30, 8); err != ErrUnknownDiskMode {
+		t.Error("Expected error", ErrUnknownDiskMode, "but got", err)
+	}
+	SetDiskGuard(1<<30, ROTATELOG|DROPDEBUG)
+	Log(DEBUG, FILE, "message 2")
+	Log(INFO, FILE, "message 3")
+	Shutdown(false)
+
+	if len(archived) != 1 || !strings.HasSuffix(archived[0], ".gz") {
+		t.Error("Expected 1 compressed archived log file but got", archived)
+	} else if f, err := os.Open(archived[0]); err != nil {
+		t.Error("Expected to find archive", archived[0], "- but got:", err)
+	} else {
+		defer f.Close()
+		zr, _ := gzip.NewReader(f)
+		if data, _ := io.ReadAll(zr); !strings.Contains(string(data), "INFO message 1") {
+			t.Error("Expected archived log record:", "INFO message 1", "- but got:", string(data))
+		}
+	}
+	data, _ := os.ReadFile(logFile)
+	if output := string(data); strings.Contains(output, "message 2") || !strings.Contains(output, "INFO message 3") {
+		t.Error("Expected log record:", "INFO message 3", "- but got:", output)
+	}
+}
+
+func TestSetArchiveUploader(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	storage := &memoryStorage{objects: map[string]string{}}
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetArchive(t.TempDir(), "{name}.{seq}")
+	SetArchiveUploader(storage, true)
+	Write(FILE, "The answer to all questions is", 42)
+	Shutdown(true)
+
+	if data, ok := storage.objects[logFile+".1"]; !ok {
+		t.Error("Expected uploaded archive", logFile+".1", "but got", storage.objects)
+	} else if data != "\nThe answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", data)
+	}
+	if _, err := os.Stat(filepath.Join(s.archiveDir, logFile+".1")); err == nil {
+		t.Error("Expected uploaded archive to be removed locally")
+	}
+}
+
+func TestTimestampCache(t *testing.T) {
+	if precision := layoutPrecision("2006-01-02 15:04:05.000000"); precision != time.Microsecond {
+		t.Error("Expected precision", time.Microsecond, "but got", precision)
+	}
+	if precision := layoutPrecision("15:04"); precision != time.Second {
+		t.Error("Expected precision", time.Second, "but got", precision)
+	}
+
+	l := newLogger(io.Discard)
+	now := time.Date(2025, 1, 1, 13, 45, 30, 100*int(time.Millisecond), time.Local)
+	for _, v := range []struct {
+		t        time.Time
+		expected string
+	}{
+		{now, "13:45:30.100"},
+		{now.Add(500 * time.Microsecond), "13:45:30.100"},
+		{now.Add(time.Millisecond), "13:45:30.101"},
+		{now.Add(time.Millisecond).UTC(), now.Add(time.Millisecond).UTC().Format("15:04:05.000")},
+	} {
+		if ts := string(l.appendTimestamp(nil, "15:04:05.000", v.t)); ts != v.expected {
+			t.Error("Expected timestamp", v.expected, "but got", ts)
+		}
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+	expectedPrefix := "#2006-01-02 15:04:05.000000#[Test]"
+
+	Startup(1)
+	SetPrefix(STDOUT, "#2006-01-02 15:04:05.000000#", "[Test]")
+	SetPrefix(FILE, "#2006-01-02 15:04:05.000000#", "[Test]")
+	Shutdown(false)
+
+	var prefix string
+	for _, v := range s.stdoutLogger.prefix {
+		prefix += v
+	}
+	if !strings.Contains(prefix, expectedPrefix) {
+		t.Error("Expected to find:", expectedPrefix, "- but found:", prefix)
+	}
+
+	prefix = ""
+	for _, v := range s.fileLogger.prefix {
+		prefix += v
+	}
+	if !strings.Contains(prefix, expectedPrefix) {
+		t.Error("Expected to find:", expectedPrefix, "- but found:", prefix)
+	}
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+}
+
+func TestSetTimeZone(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	loc := time.FixedZone("UTC+14", 14*60*60)
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "#-0700#")
+	SetTimeZone(loc)
+	Write(destination, "The answer to all questions is", 42)
+	SetTimeZone(nil)
+	Write(destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	local := time.Now().Format("-0700")
+	expected := "+1400 The answer to all questions is 42\n" + local + " The answer to all questions is 42\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSeqPrefix(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, seqTag, uptimeTag)
+	for i := 0; i < 3; i++ {
+		Write(destination, "The answer to all questions is", 42)
+	}
+	Shutdown(false)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	var last float64
+	for i, line := range lines {
+		var seq int
+		var uptime float64
+		if _, err := fmt.Sscanf(line, "%d %f", &seq, &uptime); err != nil || seq != i+1 || uptime < last {
+			t.Error("Expected sequence number", i+1, "and ascending uptime but got:", line)
+		}
+		last = uptime
+	}
+}
+
+func TestSetPrefixForLevel(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "[app]")
+	if err := SetPrefixForLevel(destination, 42, "[error]"); err != ErrUnknownLevel {
+		t.Error("Expected error", ErrUnknownLevel, "but got", err)
+	}
+	SetPrefixForLevel(destination, ERROR, "[error]", callerTag)
+	Log(INFO, destination, "The answer to all questions is", 42)
+	Log(ERROR, destination, "The answer to all questions is", 43)
+	SetPrefixForLevel(destination, ERROR)
+	Log(ERROR, destination, "The answer to all questions is", 44)
+	Shutdown(false)
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 4 {
+		t.Fatal("Expected 3 log records but got:", buf.String())
+	}
+	if lines[0] != "[app] INFO The answer to all questions is 42" {
+		t.Error("Expected log record:", "[app] INFO The answer to all questions is 42", "- but got:", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "[error] simplelog_test.go:") || !strings.HasSuffix(lines[1], " ERROR The answer to all questions is 43") {
+		t.Error("Expected log record with error prefix and caller but got:", lines[1])
+	}
+	if lines[2] != "[app] ERROR The answer to all questions is 44" {
+		t.Error("Expected log record:", "[app] ERROR The answer to all questions is 44", "- but got:", lines[2])
+	}
+}
+
+func TestCallerPrefix(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, callerTag)
+	_, _, line, _ := runtime.Caller(0)
+	Write(destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	expected := "simplelog_test.go:" + fmt.Sprint(line+1) + " The answer to all questions is 42\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+}
+
+func TestProcessPrefix(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, hostTag, pidTag, goidTag)
+	Write(destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	host, _ := os.Hostname()
+	expected := host + " " + fmt.Sprint(os.Getpid()) + " " + fmt.Sprint(goroutineID()) + " The answer to all questions is 42\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+}
+
+func TestLogToStdout(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	stdOut := os.Stdout
+	logFile := "test1.log"
+
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	Startup(1)
+	Write(STDOUT, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	_ = w.Close()
+
+	result, _ := io.ReadAll(r)
+	output := string(result)
+
+	os.Stdout = stdOut
+
+	if !strings.Contains(output, "The answer to all questions is "+fmt.Sprint(42)) {
+		t.Error("Expected to find:", "The answer to all questions is "+fmt.Sprint(42), "- but found:", output)
+	}
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+}
+
+func TestColor(t *testing.T) {
+	var buf bytes.Buffer
+	settings := logSettings{prefix: []string{"[Test]"}, color: true}
+	logMsg := logMessage{destination: STDOUT, level: ERROR, data: []any{"The answer to all questions is", 42}}
+
+	l := newLogger(&buf)
+	l.write(&settings, &logMsg, time.Now())
+	if output := buf.String(); output != "[Test] ERROR The answer to all questions is 42\n" {
+		t.Error("Expected log record without colors:", "[Test] ERROR The answer to all questions is 42", "- but got:", output)
+	}
+
+	buf.Reset()
+	l.terminal = true
+	l.write(&settings, &logMsg, time.Now())
+	expected := prefixColor + "[Test]" + resetColor + " " + levelColors[ERROR] + "ERROR" + resetColor + " The answer to all questions is 42\n"
+	if output := buf.String(); output != expected {
+		t.Errorf("Expected colorized log record: %q - but got: %q", expected, output)
+	}
+}
+
+func TestSetFilter(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetFilter(destination, []string{"answer"}, []string{"(4"}); err == nil {
+		t.Error("Expected error for invalid regular expression - but got none")
+	}
+	SetFilter(destination, []string{"answer"}, []string{"41"})
+	Write(destination, "The question is unknown")
+	Write(destination, "The answer to all questions is", 41)
+	Write(destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if output := buf.String(); output != "The answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", output)
+	}
+}
+
+func TestSetTagFilter(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetTagFilter(42, []string{"auth"}, nil); err != ErrUnknownDestination {
+		t.Error("Expected error", ErrUnknownDestination, "but got", err)
+	}
+	SetTagFilter(destination, []string{"auth", "db"}, []string{"slow"})
+	Write(destination, "untagged")
+	WriteTagged(destination, []string{"auth"}, "login failed")
+	WriteTagged(destination, []string{"auth", "slow"}, "login took", 3*time.Second)
+	WriteTagged(destination, []string{"http"}, "request served")
+	Shutdown(false)
+
+	if output := buf.String(); output != "login failed\n" {
+		t.Error("Expected log record:", "login failed", "- but got:", output)
+	}
+}
+
+func TestSetMaxRecordSize(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetMaxRecordSize(-1); err != ErrInvalidRecordSize {
+		t.Error("Expected error", ErrInvalidRecordSize, "but got", err)
+	}
+	SetMaxRecordSize(3)
+	Write(destination, "ok")
+	Write(destination, "Grüße aus Köln")
+	Shutdown(false)
+
+	expected := "ok\nGr...[truncated 15 bytes]\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetMultiline(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var prefixed, indented bytes.Buffer
+
+	Startup(1)
+	destination1, _ := RegisterDestination("prefixed", &prefixed)
+	destination2, _ := RegisterDestination("indented", &indented)
+	if err := SetMultiline(destination1, 42, ""); err != ErrUnknownMultilineMode {
+		t.Error("Expected error", ErrUnknownMultilineMode, "but got", err)
+	}
+	SetPrefix(destination1, "[app]")
+	SetPrefix(destination2, "[app]")
+	SetMultiline(destination1, PREFIXLINES, "")
+	SetMultiline(destination2, INDENTLINES, "\t")
+	Log(WARN, destination1|destination2, "request body:\n{\n  \"id\": 42\n}")
+	Shutdown(false)
+
+	expected := "[app] WARN request body:\n[app] WARN {\n[app] WARN   \"id\": 42\n[app] WARN }\n"
+	if output := prefixed.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+	expected = "[app] WARN request body:\n\t{\n\t  \"id\": 42\n\t}\n"
+	if output := indented.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+}
+
+func TestSetSampling(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetSampling(2, 3)
+	for i := 1; i <= 8; i++ {
+		Writef(destination, "The answer to all questions is %d", i)
+	}
+	Shutdown(false)
+
+	expected := "The answer to all questions is 1\nThe answer to all questions is 2\nThe answer to all questions is 5\nThe answer to all questions is 8\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetRateLimit(destination, 1, 0); err != ErrInvalidRateLimit {
+		t.Error("Expected error", ErrInvalidRateLimit, "but got", err)
+	}
+	SetRateLimit(destination, 1, 2)
+	for i := 1; i <= 5; i++ {
+		Write(destination, "message", i)
+	}
+	Shutdown(false)
+
+	expected := "message 1\nmessage 2\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetRedaction(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetRedaction([]string{"("}, "***"); err == nil {
+		t.Error("Expected error for an invalid pattern but got nil")
+	}
+	SetRedaction([]string{`password=\S+`, `\b\d{4}-\d{4}-\d{4}-\d{4}\b`}, "***")
+	Writef(destination, "login with password=%s", "secret")
+	WriteKV(destination, "payment", "card", "4111-1111-1111-1111")
+	Shutdown(false)
+
+	expected := "login with ***\npayment card=***\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetDeduplication(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetDeduplication(true)
+	for i := 0; i < 3; i++ {
+		Write(destination, "connection refused")
+	}
+	Write(destination, "connected")
+	Write(destination, "connected")
+	Shutdown(false)
+
+	expected := "connection refused\nlast message repeated 2 times\nconnected\nlast message repeated 1 times\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+// blockingWriter is an io.Writer which blocks each write until it is released.
+type blockingWriter struct {
+	bytes.Buffer
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.writing <- struct{}{}
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+func TestSetDropPolicy(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 3)}
+
+	Startup(1)
+	destination, _ := RegisterDestination("blocking", w)
+	if err := SetDropPolicy(42); err != ErrUnknownDropPolicy {
+		t.Error("Expected error", ErrUnknownDropPolicy, "but got", err)
+	}
+	SetDropPolicy(DROPNEWEST)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the first log message
+	Write(destination, "message 2")
+	Write(destination, "message 3")
+	w.release <- struct{}{}
+	<-w.writing
+	w.release <- struct{}{}
+	Shutdown(false)
+
+	if dropped := atomic.LoadUint64(&s.dropped); dropped != 1 {
+		t.Error("Expected dropped log messages", 1, "but got", dropped)
+	}
+	expected := "message 1\nmessage 2\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestSetDropPolicyConcurrently(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	var wg sync.WaitGroup
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Write(destination, "message", j)
+			}
+		}()
+	}
+	for _, policy := range []int{DROPNEWEST, DROPOLDEST, SPILL, BLOCK} {
+		if err := SetDropPolicy(policy); err != nil {
+			t.Error("Expected no error but got", err)
+		}
+	}
+	wg.Wait()
+	Shutdown(false)
+
+	if written := strings.Count(buf.String(), "\n"); written+int(atomic.LoadUint64(&s.dropped)) != 400 {
+		t.Error("Expected written or dropped log messages", 400, "but got", written)
+	}
+}
+
+func TestTryWrite(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 3)}
+
+	Startup(1)
+	destination, _ := RegisterDestination("blocking", w)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the first log message
+	if !TryWrite(destination, "message 2") {
+		t.Error("Expected log message to be passed to the log service")
+	}
+	if TryWrite(destination, "message 3") {
+		t.Error("Expected log message to be dropped")
+	}
+	if err := WriteTimeout(10*time.Millisecond, destination, "message 4"); err != ErrQueueFull {
+		t.Error("Expected error", ErrQueueFull, "but got", err)
+	}
+	w.release <- struct{}{}
+	<-w.writing
+	w.release <- struct{}{}
+	Shutdown(false)
+
+	expected := "message 1\nmessage 2\n"
+	if output := w.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := WriteBatch(destination, [][]any{{"result", 1}, {"result", 2}}); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Write(destination, "done")
+	Shutdown(false)
+
+	expected := "result 1\nresult 2\ndone\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestServiceTimeout(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 3)}
+	timeout := configTimeout
+	configTimeout = 10 * time.Millisecond
+	defer func() { configTimeout = timeout }()
+
+	Startup(1)
+	destination, _ := RegisterDestination("blocking", w)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the first log message
+	if err := SetLevel(STDOUT, WARN); err != ErrServiceTimeout {
+		t.Error("Expected error", ErrServiceTimeout, "but got", err)
+	}
+	w.release <- struct{}{}
+	if err := SetLevel(STDOUT, INFO); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	Shutdown(false)
+}
+
+func TestHealthy(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 1)}
+	timeout := heartbeatTimeout
+	defer func() { heartbeatTimeout = timeout }()
+
+	if Healthy() {
+		t.Error("Expected a log service which isn't running to be unhealthy")
+	}
+	if last := LastHeartbeat(); !last.IsZero() {
+		t.Error("Expected no heartbeat but got", last)
+	}
+	Startup(1)
+	destination, _ := RegisterDestination("blocking", w) // waits for the log service to run
+	if !Healthy() {
+		t.Error("Expected a running log service to be healthy")
+	}
+	if last := LastHeartbeat(); time.Since(last) > time.Second {
+		t.Error("Expected a recent heartbeat but got", last)
+	}
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the log message
+	heartbeatTimeout = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	if Healthy() {
+		t.Error("Expected a stuck log service to be unhealthy")
+	}
+	heartbeatTimeout = timeout
+	w.release <- struct{}{}
+	Shutdown(false)
+	if Healthy() {
+		t.Error("Expected a stopped log service to be unhealthy")
+	}
+}
+
+func TestSetHeartbeatRecord(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetHeartbeatRecord(destination, -time.Minute, ""); err != ErrInvalidInterval {
+		t.Error("Expected error", ErrInvalidInterval, "but got", err)
+	}
+	SetHeartbeatRecord(destination, 40*time.Millisecond, "")
+	for i := 0; i < 5; i++ {
+		// the application isn't quiet
+		Write(destination, "working")
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	SetHeartbeatRecord(destination, 0, "")
+	Shutdown(false)
+
+	output := buf.String()
+	last := strings.LastIndex(output, "working\n")
+	if strings.Contains(output[:last], "still alive") || !strings.HasSuffix(output[last:], "INFO still alive\n") {
+		t.Error("Expected heartbeat records only after the last log record - but got:", output)
+	}
+}
+
+func TestSetWatchdogCallback(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{}, 1)}
+	timeout, interval := heartbeatTimeout, watchdogInterval
+	heartbeatTimeout, watchdogInterval = 10*time.Millisecond, 5*time.Millisecond
+	defer func() { heartbeatTimeout, watchdogInterval = timeout, interval }()
+	alerts := make(chan time.Time, 10)
+
+	Startup(1)
+	SetWatchdogCallback(func(missed int, last time.Time) { alerts <- last })
+	destination, _ := RegisterDestination("blocking", w)
+	Write(destination, "message 1")
+	<-w.writing // the log service is blocked by the log message
+	select {
+	case <-alerts:
+	case <-time.After(time.Second):
+		t.Error("Expected the watchdog callback to be called for a stuck log service")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := len(alerts); n != 0 {
+		t.Error("Expected the watchdog callback to be called once per stall but got", n, "more calls")
+	}
+	w.release <- struct{}{}
+	Shutdown(false)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestInternalErrors(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	failing, _ := RegisterDestination("failing", failingWriter{})
+	destination, _ := RegisterDestination("buffer", &buf)
+	Write(failing, "message 1")
+	Write(destination, "message 2")
+	Shutdown(false)
+
+	if output := buf.String(); output != "message 2\n" {
+		t.Error("Expected log record:", "message 2", "- but got:", output)
+	}
+	internalErrors := InternalErrors()
+	if len(internalErrors) != 1 || internalErrors[0].Err.Error() != "disk full" || internalErrors[0].Time.IsZero() {
+		t.Error("Expected internal error", "disk full", "but got", internalErrors)
+	}
+}
+
+func TestSetFallback(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	var failed []string
+
+	Startup(1)
+	failing, _ := RegisterDestination("failing", failingWriter{})
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetFallback(failing, failing|destination); err != ErrInvalidFallback {
+		t.Error("Expected error", ErrInvalidFallback, "but got", err)
+	}
+	SetFallback(failing, destination)
+	SetErrorHandler(func(err error, rec *Record) {
+		failed = append(failed, fmt.Sprint(err, " ", rec.Destination == failing, " ", rec.Text()))
+	})
+	Write(failing, "message 1")
+	Shutdown(false)
+
+	if output := buf.String(); output != "message 1\n" {
+		t.Error("Expected log record:", "message 1", "- but got:", output)
+	}
+	if len(failed) != 1 || failed[0] != "disk full true message 1" {
+		t.Error("Expected error handler call:", "disk full true message 1", "- but got:", failed)
+	}
+}
+
+func TestFailedFileWrite(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail the writes to the log file")
+	}
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	var failed []string
+
+	Startup(1)
+	SetupLog("/dev/full", true)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetFallback(FILE, destination)
+	SetErrorHandler(func(err error, rec *Record) {
+		failed = append(failed, fmt.Sprint(rec.Destination == FILE, " ", rec.Text()))
+	})
+	err := WriteSync(FILE, "message 1")
+	internalErrors := InternalErrors()
+	Shutdown(false)
+
+	if err == nil {
+		t.Error("Expected write error but got", err)
+	}
+	if output := buf.String(); output != "message 1\n" {
+		t.Error("Expected log record:", "message 1", "- but got:", output)
+	}
+	if len(failed) != 1 || failed[0] != "true message 1" {
+		t.Error("Expected error handler call:", "true message 1", "- but got:", failed)
+	}
+	if len(internalErrors) == 0 {
+		t.Error("Expected internal error but got", internalErrors)
+	}
+}
+
+func TestReleaseFailedFileWrite(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail the writes to the log file")
+	}
+	s = new(simpleLogService) // reset service instance
+	logName := filepath.Join(t.TempDir(), "test.log")
+
+	Startup(1)
+	SetupLog("/dev/full", true)
+	Write(FILE, "message 1")
+	err := SwitchLog(logName)
+	Write(FILE, "message 2")
+	Shutdown(false)
+
+	if err == nil {
+		t.Error("Expected write error of the released log file but got", err)
+	}
+	if data, _ := os.ReadFile(logName); !strings.Contains(string(data), "message 2") {
+		t.Error("Expected log record:", "message 2", "- but got:", string(data))
+	}
+
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	Startup(1)
+	SetupLog("/dev/full", true)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetFallback(FILE, destination)
+	Write(FILE, "message 3")
+	Shutdown(false)
+
+	if output := buf.String(); output != "message 3\n" {
+		t.Error("Expected log record:", "message 3", "- but got:", output)
+	}
+	if internalErrors := InternalErrors(); len(internalErrors) == 0 {
+		t.Error("Expected internal error but got", internalErrors)
+	}
+}
+
+func TestAddHook(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	AddHook(func(rec *Record) *Record {
+		if rec.Level == DEBUG {
+			return nil
+		}
+		rec.Fields = append(rec.Fields, "app", "test")
+		return rec
+	})
+	Log(DEBUG, destination, "The answer to all questions is", 41)
+	Log(INFO, destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if output := buf.String(); output != "INFO The answer to all questions is 42 app=test\n" {
+		t.Error("Expected log record:", "INFO The answer to all questions is 42 app=test", "- but got:", output)
+	}
+}
+
+func TestLogToStderr(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	stdErr := os.Stderr
+
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	Startup(1)
+	Log(ERROR, STDERR, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	_ = w.Close()
+
+	result, _ := io.ReadAll(r)
+	output := string(result)
+
+	os.Stderr = stdErr
+
+	if !strings.Contains(output, "ERROR The answer to all questions is "+fmt.Sprint(42)) {
+		t.Error("Expected to find:", "ERROR The answer to all questions is "+fmt.Sprint(42), "- but found:", output)
+	}
+}
+
+func TestLogToFile(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	Write(FILE, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if !strings.Contains(string(data), "The answer to all questions is "+fmt.Sprint(42)) {
+		t.Error("Expected log record contains:", "The answer to all questions is "+fmt.Sprint(42), "- but it doesn't:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestWritefToFile(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	Writef(FILE, "The answer to all questions is %03d", 42)
+	ConditionalWritef(false, FILE, "The answer to all questions is %03d", 43)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if string(data) != "\nThe answer to all questions is 042\n" {
+		t.Error("Expected log record:", "The answer to all questions is 042", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestWriteKV(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	WriteKV(destination, "request done", "user", "bob", "status", 200, "path", "/a b")
+	if err := WriteKV(destination, "request done", "user"); err != ErrInvalidFields {
+		t.Error("Expected error", ErrInvalidFields, "but got:", err)
+	}
+	SetFormat(destination, JSON)
+	WriteKV(destination, "request done", "user", "bob", "status", 200)
+	Shutdown(false)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Expected two log records - but got:", buf.String())
+	}
+	if lines[0] != `request done user=bob status=200 path="/a b"` {
+		t.Error("Expected log record:", `request done user=bob status=200 path="/a b"`, "- but got:", lines[0])
+	}
+	var record jsonRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Error("Expected a JSON log record - but got:", err, lines[1])
+	} else if record.Message != "request done" || record.Fields["user"] != "bob" || record.Fields["status"] != float64(200) {
+		t.Error("Expected JSON log record with message and fields - but found:", lines[1])
+	}
+}
+
+func TestWriteFields(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	WriteFields(destination, "request done", Str("user", "bob"), Int("status", int16(200)), Uint("bytes", uint8(42)),
+		Float("ratio", float32(0.5)), Bool("cached", true), Dur("latency", 1500*time.Millisecond), Err("error", io.EOF))
+	Derive("", "request", 4711).WriteFields(destination, "request done", Any("path", "/a b"))
+	Shutdown(false)
+
+	expected := "request done user=bob status=200 bytes=42 ratio=0.5 cached=true latency=1.5s error=EOF\n" +
+		"request done request=4711 path=\"/a b\"\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "proxy:")
+	WriteString(destination, "GET /index.html 200\n")
+	WriteBytes(destination, []byte("GET /favicon.ico 404"))
+	Shutdown(false)
+
+	expected := "proxy: GET /index.html 200\nproxy: GET /favicon.ico 404\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestWriteSync(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	WriteSync(FILE, "The answer to all questions is", 42)
+	data, err := os.ReadFile(logFile)
+	Shutdown(false)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if string(data) != "\nThe answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestWriteWithStack(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	WriteWithStack(destination, "unexpected EOF")
+	Shutdown(false)
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 4 || lines[0] != "unexpected EOF" || !strings.HasPrefix(lines[1], "goroutine ") {
+		t.Error("Expected log record followed by a stack trace - but got:", buf.String())
+	} else if !strings.Contains(lines[2], "TestWriteWithStack") {
+		t.Error("Expected stack trace starting with:", "TestWriteWithStack", "- but got:", lines[2])
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	err := fmt.Errorf("load config: %w", &os.PathError{Op: "open", Path: "app.yaml", Err: syscall.EACCES})
+	WriteError(destination, err)
+	WriteError(destination, nil)
+	Shutdown(false)
+
+	expected := "ERROR load config: open app.yaml: permission denied\n" +
+		"  caused by *fs.PathError: open app.yaml: permission denied\n" +
+		"  caused by syscall.Errno: permission denied\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestStartTimer(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	timer := StartTimer("db.query")
+	time.Sleep(10 * time.Millisecond)
+	timer.Log(destination)
+	Shutdown(false)
+
+	name, elapsed, _ := strings.Cut(strings.TrimSuffix(buf.String(), "\n"), " elapsed=")
+	if d, err := time.ParseDuration(elapsed); name != "db.query" || err != nil || d < 10*time.Millisecond {
+		t.Error("Expected log record: db.query elapsed=<duration> - but got:", buf.String())
+	}
+}
+
+func TestDerive(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "[app]")
+	http := Derive("[http]", "service", "api")
+	http.Write(destination, "request served")
+	http.Derive("[auth]").Log(WARN, destination, "login failed")
+	if err := Derive("[db]", 42).Write(destination, "query failed"); err != ErrInvalidFields {
+		t.Error("Expected error", ErrInvalidFields, "but got", err)
+	}
+	Shutdown(false)
+
+	expected := "[app] [http] request served service=api\n[app] [http] [auth] WARN login failed service=api\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestChildLogKV(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	http := Derive("[http]", "service", "api")
+	http.LogKV(INFO, destination, "request failed", "status", 500)
+	if err := http.LogKV(42, destination, "request failed"); err != ErrUnknownLevel {
+		t.Error("Expected error", ErrUnknownLevel, "but got", err)
+	}
+	Shutdown(false)
+
+	if output := buf.String(); output != "[http] INFO request failed service=api status=500\n" {
+		t.Error("Expected log record:", "[http] INFO request failed service=api status=500", "- but got:", output)
+	}
+}
+
+func TestGetLogger(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	db := GetLogger("db")
+	if GetLogger("db") != db {
+		t.Error("Expected the same logger for the same name")
+	}
+	SetComponentLevel("db", WARN)
+	db.Log(INFO, destination, "query executed")
+	SetComponentLevel("db", DEBUG)
+	db.Log(DEBUG, destination, "query executed")
+	SetComponentDestinations("db", STDOUT)
+	db.Log(ERROR, destination, "query failed")
+	if err := SetComponentLevel("db", 42); err != ErrUnknownLevel {
+		t.Error("Expected error", ErrUnknownLevel, "but got", err)
+	}
+	Shutdown(false)
+
+	expected := "[db] DEBUG query executed\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestWriteCtx(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	ctx := WithContext(context.Background(), "request", 4711)
+	ctx = WithContext(ctx, "user", "bob")
+	WriteCtx(ctx, destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if output := buf.String(); output != "The answer to all questions is 42 request=4711 user=bob\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42 request=4711 user=bob", "- but got:", output)
+	}
+}
+
+func TestWriteIfError(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	WriteIfError(destination, nil, "open config failed:")
+	WriteIfError(destination, os.ErrNotExist, "open config failed:")
+	WriteUnless(true, destination, "cache disabled")
+	WriteUnless(false, destination, "cache enabled")
+	Shutdown(false)
+
+	expected := "ERROR open config failed: file does not exist\ncache enabled\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log records:", expected, "- but got:", output)
+	}
+}
+
+func TestConditionalLogToFile(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	ConditionalWrite(false, FILE, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if string(data) != "" {
+		t.Error("Expected an empty file - but it contains:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetLevel(FILE, WARN)
+	Log(INFO, FILE, "The answer to all questions is", 41)
+	Log(ERROR, FILE, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if strings.Contains(string(data), "The answer to all questions is "+fmt.Sprint(41)) {
+		t.Error("Expected log record below level threshold to be dropped - but found:", string(data))
+	} else if !strings.Contains(string(data), "ERROR The answer to all questions is "+fmt.Sprint(42)) {
+		t.Error("Expected log record contains:", "ERROR The answer to all questions is "+fmt.Sprint(42), "- but it doesn't:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	SetPrefix(FILE, "[Test]")
+	SetFormat(FILE, JSON)
+	Log(ERROR, FILE, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal("Expected to find file", logFile, "- but got:", err)
+	}
+
+	var record jsonRecord
+	if err = json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+		t.Error("Expected a JSON log record - but got:", err, string(data))
+	} else if record.Prefix != "[Test]" || record.Level != "ERROR" || record.Message != "The answer to all questions is "+fmt.Sprint(42) {
+		t.Error("Expected JSON log record with prefix, level and message - but found:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestSetDurability(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	if err := SetDurability(true, -1); err != ErrInvalidSyncRate {
+		t.Error("Expected error", ErrInvalidSyncRate, "but got", err)
+	}
+	SetDurability(true, 1)
+	Write(FILE, "The answer to all questions is", 42)
+	// the log record is synced long before the periodic flush
+	var data []byte
+	for i := 0; i < 50 && !strings.Contains(string(data), "42"); i++ {
+		time.Sleep(10 * time.Millisecond)
+		data, _ = os.ReadFile(logFile)
+	}
+	Shutdown(false)
+
+	if string(data) != "\nThe answer to all questions is 42\n" {
+		t.Error("Expected log record:", "The answer to all questions is 42", "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	stdLog := log.New(Writer(FILE), "", 0)
+	stdLog.Println("The answer to all questions is", 42)
+	Shutdown(false)
+
+	data, err := os.ReadFile(logFile)
+
+	if err != nil {
+		t.Error("Expected to find file", logFile, "- but got:", err)
+	} else if string(data) != "\nThe answer to all questions is "+fmt.Sprint(42)+"\n" {
+		t.Error("Expected log record:", "The answer to all questions is "+fmt.Sprint(42), "- but got:", string(data))
+	} else {
+		os.Remove(logFile)
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := CaptureOutput(destination); err == ErrNotSupported {
+		Shutdown(false)
+		t.Skip("capturing stdout and stderr is not supported")
+	} else if err != nil {
+		t.Fatal("Expected to capture stdout and stderr - but got:", err)
+	}
+	if err := CaptureOutput(destination); err != ErrOutputCaptured {
+		t.Error("Expected error", ErrOutputCaptured, "but got", err)
+	}
+	fmt.Println("printed by fmt")
+	fmt.Fprintln(os.Stderr, "printed to stderr")
+	Write(STDOUT, "written to STDOUT")
+	Shutdown(false)
+
+	output := buf.String()
+	if !strings.Contains(output, "[stdout] printed by fmt\n") || !strings.Contains(output, "[stderr] printed to stderr\n") {
+		t.Error("Expected captured output of stdout and stderr - but got:", output)
+	}
+	if strings.Contains(output, "written to STDOUT") {
+		t.Error("Expected log records of STDOUT not to be captured - but got:", output)
+	}
+}
+
+func TestLogCmd(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available:", err)
+	}
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := LogCmd(&exec.Cmd{Stdout: os.Stdout}, destination, ""); err != ErrCmdOutputSet {
+		t.Error("Expected error", ErrCmdOutputSet, "but got", err)
+	}
+	cmd := exec.Command("sh", "-c", "echo backup started; echo disk almost full >&2")
+	if err := LogCmd(cmd, destination, "[backup]"); err != nil {
+		t.Fatal("Expected to start the command - but got:", err)
+	}
+	cmd.Wait()
+	Shutdown(false)
+
+	output := buf.String()
+	if !strings.Contains(output, "[backup] [stdout] backup started\n") || !strings.Contains(output, "[backup] [stderr] disk almost full\n") {
+		t.Error("Expected output of stdout and stderr of the command - but got:", output)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "[app]")
+	StdLogger(destination, "[db] ").Printf("connection reset after %d retries", 3)
+	Shutdown(false)
+
+	expected := "[app] [db] connection reset after 3 retries\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+}
+
+func TestCaptureStdLog(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf, stderr bytes.Buffer
+	log.SetOutput(&stderr)
+	defer log.SetOutput(os.Stderr)
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetPrefix(destination, "[app]")
+	if err := CaptureStdLog(destination); err != nil {
+		t.Fatal("Expected to capture the log package - but got:", err)
+	}
+	log.Printf("connection reset after %d retries", 3)
 	Shutdown(false)
+	log.Print("after shutdown")
+
+	expected := "[app] connection reset after 3 retries\n"
+	if output := buf.String(); output != expected {
+		t.Error("Expected log record:", expected, "- but got:", output)
+	}
+	if !strings.HasSuffix(stderr.String(), "after shutdown\n") || log.Flags() != log.LstdFlags {
+		t.Error("Expected the log package to be restored but got", stderr.String(), log.Flags())
+	}
+}
+
+func TestFatal(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+	exitCode := 0
+	exit = func(code int) { exitCode = code }
+	defer func() { exit = os.Exit }()
 
+	Startup(1)
+	SetupLog(logFile, false)
+	Fatal(FILE, "The answer to all questions is", 42)
 	data, err := os.ReadFile(logFile)
+	Shutdown(false)
 
+	if exitCode != 1 {
+		t.Error("Expected exit code", 1, "but got", exitCode)
+	}
 	if err != nil {
 		t.Error("Expected to find file", logFile, "- but got:", err)
-	} else if string(data) != "" {
-		t.Error("Expected an empty file - but it contains:", string(data))
+	} else if !strings.Contains(string(data), "The answer to all questions is 42") {
+		t.Error("Expected log record contains:", "The answer to all questions is 42", "- but it doesn't:", string(data))
 	} else {
 		os.Remove(logFile)
 	}
 }
 
+func TestPanicw(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	defer Shutdown(false)
+	destination, _ := RegisterDestination("buffer", &buf)
+	defer func() {
+		if r := recover(); r != "out of memory" {
+			t.Error("Expected panic value", "out of memory", "but got", r)
+		}
+		if output := buf.String(); !strings.Contains(output, "out of memory") {
+			t.Error("Expected log record contains:", "out of memory", "- but it doesn't:", output)
+		}
+	}()
+	Panicw(destination, "out of memory")
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	func() {
+		defer RecoverAndLog(destination, false)
+		panic("out of memory")
+	}()
+	Shutdown(false)
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 4 || lines[0] != "FATAL panic: out of memory" || !strings.HasPrefix(lines[2], "panic(") {
+		t.Error("Expected panic record followed by a stack trace - but got:", buf.String())
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	if code := ErrorCode(Write(STDOUT, "not started")); code != "sg000" {
+		t.Error("Expected error code sg000 but got", code)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	listener.Close() // nothing listens on the address anymore
+	path := journalSocket
+	journalSocket = filepath.Join(missing, "socket")
+	defer func() { journalSocket = path }()
+
+	Startup(1)
+	err := SetupLog(filepath.Join(missing, "test.log"), false)
+	routeErr := AddRoute(Route{Level: ERROR, File: filepath.Join(missing, "error.log")})
+	journalErr := SetupJournalLog()
+	_, pipeErr := RegisterPipeDestination("pipe", filepath.Join(missing, "pipe"))
+	_, fluentErr := RegisterFluentDestination("fluent", listener.Addr().String(), "app")
+	Shutdown(false)
+
+	if !errors.Is(err, ErrOpenLogFile) || !errors.Is(err, fs.ErrNotExist) || ErrorCode(err) != "sg036" {
+		t.Error("Expected error", ErrOpenLogFile, "wrapping", fs.ErrNotExist, "but got", err)
+	}
+	if !errors.Is(routeErr, ErrOpenLogFile) || !errors.Is(routeErr, fs.ErrNotExist) {
+		t.Error("Expected error", ErrOpenLogFile, "wrapping", fs.ErrNotExist, "but got", routeErr)
+	}
+	if runtime.GOOS == "linux" && !errors.Is(journalErr, ErrConnect) {
+		t.Error("Expected error", ErrConnect, "but got", journalErr)
+	}
+	if !errors.Is(pipeErr, ErrConnect) || !errors.Is(pipeErr, fs.ErrNotExist) {
+		t.Error("Expected error", ErrConnect, "wrapping", fs.ErrNotExist, "but got", pipeErr)
+	}
+	if !errors.Is(fluentErr, ErrConnect) || ErrorCode(fluentErr) != "sg037" {
+		t.Error("Expected error", ErrConnect, "but got", fluentErr)
+	}
+	if code := ErrorCode(io.EOF); code != "" {
+		t.Error("Expected no error code but got", code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	if _, err := Stats(); err != ErrNotRunning {
+		t.Error("Expected error", ErrNotRunning, "but got", err)
+	}
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	SetRateLimit(destination, 1, 1)
+	Write(destination, "message 1")
+	Write(destination, "message 2")
+	s.sync()
+	stats, err := Stats()
+	Shutdown(false)
+
+	if err != nil {
+		t.Error("Expected no error but got", err)
+	} else if stats.Written[destination] != 1 || stats.BytesWritten != 10 || stats.RateLimited != 1 {
+		t.Error("Expected 1 log record with 10 bytes written and 1 rate limited but got", stats.Written[destination], stats.BytesWritten, stats.RateLimited)
+	}
+}
+
+func TestSetStatsSummary(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := SetStatsSummary(destination, -time.Minute); err != ErrInvalidInterval {
+		t.Error("Expected error", ErrInvalidInterval, "but got", err)
+	}
+	SetStatsSummary(destination, 50*time.Millisecond)
+	Log(ERROR, destination, "connection refused")
+	Write(destination, "retrying")
+	time.Sleep(75 * time.Millisecond)
+	SetStatsSummary(destination, 0)
+	Shutdown(false)
+
+	lines := strings.Split(buf.String(), "\n")
+	expected := "INFO log statistics written=2 written.error=1 written.buffer=2 dropped=0 rate_limited=0 queue_high_water="
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], expected) {
+		t.Error("Expected log records followed by the summary:", expected, "- but got:", buf.String())
+	}
+}
+
 func TestLogToMulti(t *testing.T) {
 	s = new(simpleLogService) // reset service instance
 	stdOut := os.Stdout
@@ -224,6 +2571,509 @@ func TestLogToMulti(t *testing.T) {
 	}
 }
 
+func TestMultipleLoggers(t *testing.T) {
+	logFile1 := "test1.log"
+	logFile2 := "test2.log"
+
+	for _, logFile := range []string{logFile1, logFile2} {
+		if _, err := os.Stat(logFile); err == nil {
+			os.Remove(logFile)
+		}
+	}
+
+	logger1, err := New(WithBufferSize(2), WithPrefix(FILE, "[Logger1]"))
+	if err != nil {
+		t.Fatal("Expected to create logger - but got:", err)
+	}
+	logger2, err := New(WithBufferSize(2), WithPrefix(FILE, "[Logger2]"))
+	if err != nil {
+		t.Fatal("Expected to create logger - but got:", err)
+	}
+	logger1.SetupLog(logFile1, false)
+	logger2.SetupLog(logFile2, false)
+	logger1.Write(FILE, "The answer to all questions is", 42)
+	logger2.Write(FILE, "The answer to all questions is", 43)
+	logger1.Shutdown(false)
+	logger2.Shutdown(false)
+
+	expected := map[string]string{
+		logFile1: "[Logger1] The answer to all questions is " + fmt.Sprint(42),
+		logFile2: "[Logger2] The answer to all questions is " + fmt.Sprint(43),
+	}
+	for logFile, record := range expected {
+		data, err := os.ReadFile(logFile)
+		if err != nil {
+			t.Error("Expected to find file", logFile, "- but got:", err)
+		} else if !strings.Contains(string(data), record) {
+			t.Error("Expected log record contains:", record, "- but it doesn't:", string(data))
+		} else {
+			os.Remove(logFile)
+		}
+	}
+}
+
+func TestSetFileLock(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "shared.log")
+	payload := strings.Repeat("x", 3000)
+	var wg sync.WaitGroup
+
+	for _, name := range []string{"a", "b"} {
+		logger, err := New()
+		if err != nil {
+			t.Fatal("Expected to create logger - but got:", err)
+		}
+		logger.SetupLog(logFile, true)
+		if err = logger.SetFileLock(true); err != nil {
+			t.Fatal("Expected to lock the log file - but got:", err)
+		}
+		wg.Add(1)
+		go func(logger *Logger, name string) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				logger.Write(FILE, name, payload)
+			}
+			logger.Shutdown(false)
+		}(logger, name)
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(logFile)
+	lines := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		lines++
+		if line != "a "+payload && line != "b "+payload {
+			t.Fatal("Expected whole log records but got:", line)
+		}
+	}
+	if lines != 2000 {
+		t.Error("Expected 2000 log records but got", lines)
+	}
+}
+
+func TestSetupJournalLog(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unixgram sockets are not supported:", err)
+	}
+	defer conn.Close()
+	path := journalSocket
+	journalSocket = socket
+	defer func() { journalSocket = path }()
+
+	Startup(1)
+	if err := Write(JOURNAL, "message 1"); err != ErrNoJournalLog {
+		t.Error("Expected error", ErrNoJournalLog, "but got", err)
+	}
+	if err := SetupJournalLog(); err != nil {
+		t.Fatal("Expected to setup the journal log - but got:", err)
+	}
+	Log(ERROR, JOURNAL, "disk full")
+	WriteKV(JOURNAL, "login failed", "user_id", 42, "reason", "bad\npassword")
+	Shutdown(false)
+
+	identifier := "SYSLOG_IDENTIFIER=" + filepath.Base(os.Args[0]) + "\n"
+	expected := []string{
+		"MESSAGE=disk full\nPRIORITY=3\n" + identifier,
+		"MESSAGE=login failed\nPRIORITY=6\n" + identifier + "USER_ID=42\nREASON\n\x0c\x00\x00\x00\x00\x00\x00\x00bad\npassword\n",
+	}
+	buf := make([]byte, 4096)
+	for _, e := range expected {
+		n, _ := conn.Read(buf)
+		if datagram := string(buf[:n]); datagram != e {
+			t.Errorf("Expected datagram: %q - but got: %q", e, datagram)
+		}
+	}
+}
+
+func TestRegisterDestination(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, err := RegisterDestination("buffer", &buf)
+	if err != nil {
+		t.Fatal("Expected to register destination - but got:", err)
+	}
+	if _, err = RegisterDestination("buffer", &buf); err != ErrDestinationExists {
+		t.Error("Expected error", ErrDestinationExists, "but got:", err)
+	}
+	SetPrefix(destination, "[Test]")
+	Write(destination, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if output := buf.String(); output != "[Test] The answer to all questions is "+fmt.Sprint(42)+"\n" {
+		t.Error("Expected log record:", "[Test] The answer to all questions is "+fmt.Sprint(42), "- but got:", output)
+	}
+}
+
+func TestLogToNetwork(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Expected to listen on a local port - but got:", err)
+	}
+	defer listener.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	Startup(1)
+	if err = SetupNetworkLog("tcp", listener.Addr().String()); err != nil {
+		t.Error("Expected to setup network log - but got:", err)
+	}
+	SetPrefix(NETWORK, "[Test]")
+	Write(NETWORK, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	if output := <-received; output != "[Test] The answer to all questions is "+fmt.Sprint(42)+"\n" {
+		t.Error("Expected log record:", "[Test] The answer to all questions is "+fmt.Sprint(42), "- but got:", output)
+	}
+}
+
+func TestLogToGELF(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Expected to listen on a local port - but got:", err)
+	}
+	defer conn.Close()
+	random := make([]byte, 10000)
+	rand.Read(random)
+	large := hex.EncodeToString(random) // compressed to more than one chunk
+
+	Startup(1)
+	if err = SetupNetworkLog("udp", conn.LocalAddr().String()); err != nil {
+		t.Error("Expected to setup network log - but got:", err)
+	}
+	SetFormat(NETWORK, GELF)
+	WriteKV(NETWORK, "login failed", "user_id", 42, "id", "a1")
+	Log(ERROR, NETWORK, large)
+	Shutdown(false)
+
+	// receive the GELF messages and reassemble the chunked ones
+	var messages []map[string]any
+	chunks := make(map[int][]byte)
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(messages) < 2 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal("Expected to receive GELF messages - but got:", err)
+		}
+		data := append([]byte(nil), buf[:n]...)
+		if data[0] == 0x1e && data[1] == 0x0f {
+			if chunks[int(data[10])] = data[12:]; len(chunks) < int(data[11]) {
+				continue
+			}
+			data = nil
+			for seq := 0; seq < len(chunks); seq++ {
+				data = append(data, chunks[seq]...)
+			}
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal("Expected a compressed GELF message - but got:", err)
+		}
+		var message map[string]any
+		if err = json.NewDecoder(zr).Decode(&message); err != nil {
+			t.Fatal("Expected a GELF message - but got:", err)
+		}
+		messages = append(messages, message)
+	}
+
+	if m := messages[0]; m["version"] != "1.1" || m["short_message"] != "login failed" || m["level"] != 6.0 || m["_user_id"] != 42.0 || m["_id_"] != "a1" {
+		t.Error("Expected GELF message with additional fields but got", m)
+	}
+	if len(chunks) < 2 {
+		t.Error("Expected a chunked GELF message but got", len(chunks), "chunks")
+	}
+	if m := messages[1]; m["short_message"] != large || m["level"] != 3.0 {
+		t.Error("Expected GELF message of level 3 but got", m["level"])
+	}
+}
+
+func TestRegisterPipeDestination(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	socket := filepath.Join(t.TempDir(), "collector.socket")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix sockets are not supported:", err)
+	}
+
+	Startup(1)
+	if _, err := RegisterPipeDestination("pipe", t.TempDir()); err != ErrNoPipe {
+		t.Error("Expected error", ErrNoPipe, "but got", err)
+	}
+	destination, err := RegisterPipeDestination("collector", socket)
+	if err != nil {
+		t.Fatal("Expected to register the pipe destination - but got:", err)
+	}
+	Write(destination, "message 1")
+	conn, _ := listener.Accept()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 100)
+	n, _ := conn.Read(buf)
+	if string(buf[:n]) != "message 1\n" {
+		t.Error("Expected log record: message 1 - but got:", string(buf[:n]))
+	}
+
+	// restart the reader
+	conn.Close()
+	listener.Close()
+	Write(destination, "message 2")
+	listener, _ = net.Listen("unix", socket)
+	defer listener.Close()
+	time.Sleep(reconnectInterval + 200*time.Millisecond)
+	Write(destination, "message 3")
+	Shutdown(false)
+
+	conn, _ = listener.Accept()
+	data, _ := io.ReadAll(conn)
+	if string(data) != "message 2\nmessage 3\n" {
+		t.Error("Expected log records: message 2 and message 3 - but got:", string(data))
+	}
+}
+
+func TestRegisterFluentDestination(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Expected to listen on a local port - but got:", err)
+	}
+	defer listener.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		data := buf[:n]
+		// acknowledge the chunk ID, which is the msgpack string following the key "chunk"
+		if i := bytes.Index(data, []byte("\xa5chunk")); i >= 0 {
+			id := data[i+6 : i+7+int(data[i+6]&0x1f)]
+			conn.Write(append([]byte("\x81\xa3ack"), id...))
+		}
+		received <- data
+	}()
+
+	Startup(1)
+	destination, err := RegisterFluentDestination("fluent", listener.Addr().String(), "app")
+	if err != nil {
+		t.Fatal("Expected to register the Fluent destination - but got:", err)
+	}
+	WriteKV(destination, "login failed", "user_id", 42)
+	if err = Shutdown(false); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+
+	data := <-received
+	for _, expected := range []string{"\x93\xa3app\x91\x92\xd7\x00", "\xa7message\xaclogin failed", "\xa7user_id\xd3\x00\x00\x00\x00\x00\x00\x00\x2a"} {
+		if !bytes.Contains(data, []byte(expected)) {
+			t.Errorf("Expected Fluent message containing %q - but got: %q", expected, data)
+		}
+	}
+	if internalErrors := InternalErrors(); len(internalErrors) != 0 {
+		t.Error("Expected the log records to be acknowledged but got", internalErrors)
+	}
+}
+
+// memoryProducer is a Producer which keeps the produced messages in memory.
+type memoryProducer struct {
+	messages []ProducerMessage
+	fail     bool
+}
+
+func (m *memoryProducer) Produce(ctx context.Context, topic string, messages []ProducerMessage) error {
+	if m.fail {
+		return errors.New("broker not available")
+	}
+	m.messages = append(m.messages, messages...)
+	return nil
+}
+
+func TestRegisterKafkaDestination(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	producer := &memoryProducer{}
+	failing := &memoryProducer{fail: true}
+	var undelivered int
+
+	Startup(1)
+	destination, err := RegisterKafkaDestination("kafka", producer, "logs", "user_id", nil)
+	if err != nil {
+		t.Fatal("Expected to register the Kafka destination - but got:", err)
+	}
+	failingDestination, _ := RegisterKafkaDestination("failing", failing, "logs", "", func(err error, messages []ProducerMessage) {
+		undelivered += len(messages)
+	})
+	WriteKV(destination|failingDestination, "login failed", "user_id", 42)
+	Write(destination, "message 2")
+	Shutdown(false)
+
+	if len(producer.messages) != 2 {
+		t.Fatal("Expected 2 produced messages but got", len(producer.messages))
+	}
+	var record map[string]any
+	json.Unmarshal(producer.messages[0].Value, &record)
+	if key := string(producer.messages[0].Key); key != "42" || record["message"] != "login failed" {
+		t.Error("Expected message with key 42 but got", key, record)
+	}
+	if key := producer.messages[1].Key; key != nil {
+		t.Error("Expected message without key but got", string(key))
+	}
+	if undelivered != 1 {
+		t.Error("Expected 1 undelivered message but got", undelivered)
+	}
+}
+
+func TestRegisterOTLPDestination(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received <- data
+	}))
+	defer server.Close()
+
+	Startup(1)
+	destination, err := RegisterOTLPDestination("otel", server.URL+"/v1/logs", "checkout")
+	if err != nil {
+		t.Fatal("Expected to register the OTLP destination - but got:", err)
+	}
+	SetPrefix(destination, "api", hostTag)
+	SetTraceExtractor(func(ctx context.Context) (string, string) {
+		return "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	})
+	WriteCtx(WithContext(context.Background(), "user_id", 42), destination, "login failed")
+	Log(WARN, destination, "disk almost full")
+	Shutdown(false)
+
+	var request struct {
+		ResourceLogs []struct {
+			ScopeLogs []struct {
+				LogRecords []otlpLogRecord
+			}
+		}
+	}
+	if err := json.Unmarshal(<-received, &request); err != nil || len(request.ResourceLogs) != 1 {
+		t.Fatal("Expected an OTLP logs request - but got:", err)
+	}
+	records := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatal("Expected 2 log records but got", len(records))
+	}
+	if records[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || records[0].SpanID != "00f067aa0ba902b7" {
+		t.Error("Expected trace context but got", records[0].TraceID, records[0].SpanID)
+	}
+	attributes := make(map[string]any)
+	for _, a := range records[0].Attributes {
+		for _, v := range a.Value {
+			attributes[a.Key] = v
+		}
+	}
+	if attributes["user_id"] != "42" || attributes["host.name"] != hostname || attributes["log.prefix"] != "api" {
+		t.Error("Expected user_id, host.name and log.prefix attributes but got", attributes)
+	}
+	if records[1].SeverityNumber != 13 || records[1].SeverityText != "WARN" || records[1].Body["stringValue"] != "disk almost full" {
+		t.Error("Expected WARN log record 'disk almost full' but got", records[1])
+	}
+}
+
+// blockingProducer is a Producer which blocks until it is released.
+type blockingProducer struct {
+	release chan struct{}
+}
+
+func (b *blockingProducer) Produce(ctx context.Context, topic string, messages []ProducerMessage) error {
+	<-b.release
+	return nil
+}
+
+func TestKafkaQueueFull(t *testing.T) {
+	var undelivered []error
+	producer := &blockingProducer{release: make(chan struct{})}
+	k := newKafkaSink(producer, "logs", "", func(err error, messages []ProducerMessage) {
+		undelivered = append(undelivered, err)
+	})
+	var err error
+	// the first batch blocks the producer, the next ones fill the queue
+	for i := 0; i < kafkaQueueSize+3 && err == nil; i++ {
+		k.batch = []ProducerMessage{{Value: []byte("message")}}
+		err = k.flush()
+	}
+	close(producer.release)
+	k.close()
+
+	if !errors.Is(err, ErrSinkQueueFull) || len(undelivered) != 1 || !errors.Is(undelivered[0], ErrSinkQueueFull) {
+		t.Error("Expected error", ErrSinkQueueFull, "but got", err, undelivered)
+	}
+}
+
+func TestOTLPQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	o := newOTLPSink(server.URL, "checkout")
+	var err error
+	// the first batch blocks the exporter, the next ones fill the queue
+	for i := 0; i < otlpQueueSize+3 && err == nil; i++ {
+		o.batch = []otlpLogRecord{{Body: map[string]any{"stringValue": "message"}}}
+		err = o.flush()
+	}
+	close(release)
+	o.close()
+
+	if !errors.Is(err, ErrSinkQueueFull) {
+		t.Error("Expected error", ErrSinkQueueFull, "but got", err)
+	}
+}
+
+func TestLogToWebhook(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+
+	received := make(chan []jsonRecord, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var records []jsonRecord
+		if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- records
+	}))
+	defer server.Close()
+
+	Startup(1)
+	SetupWebhookLog(server.URL)
+	SetFormat(WEBHOOK, JSON)
+	Log(ERROR, WEBHOOK, "The answer to all questions is", 42)
+	Shutdown(false)
+
+	records := <-received
+	if len(records) != 1 || records[0].Level != "ERROR" || records[0].Message != "The answer to all questions is "+fmt.Sprint(42) {
+		t.Error("Expected one JSON log record:", "The answer to all questions is "+fmt.Sprint(42), "- but got:", records)
+	}
+}
+
 func BenchmarkLog(b *testing.B) {
 	s = new(simpleLogService) // reset service instance
 	logFile := "test1.log"
@@ -234,6 +3084,7 @@ func BenchmarkLog(b *testing.B) {
 
 	Startup(1)
 	SetupLog(logFile, false)
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		Write(FILE, "The answer to all questions is", 42)
@@ -244,3 +3095,25 @@ func BenchmarkLog(b *testing.B) {
 		os.Remove(logFile)
 	}
 }
+
+func BenchmarkWriteString(b *testing.B) {
+	s = new(simpleLogService) // reset service instance
+	logFile := "test1.log"
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+
+	Startup(1)
+	SetupLog(logFile, false)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WriteString(FILE, "The answer to all questions is 42")
+	}
+	Shutdown(false)
+
+	if _, err := os.Stat(logFile); err == nil {
+		os.Remove(logFile)
+	}
+}
@@ -0,0 +1,72 @@
+package simplelog
+
+import (
+	"time"
+)
+
+// recordSink is a custom log destination which receives the log records as structured data instead of formatted
+// lines, e.g. to keep the structured fields of the log records in a log collector.
+type recordSink interface {
+	// writeRecord writes or buffers a log message written at time t with the prefix of the log destination.
+	writeRecord(logMsg *logMessage, prefix []string, t time.Time) error
+	// flush sends the buffered log records.
+	flush() error
+	// close sends the buffered log records and releases all resources.
+	close() error
+}
+
+// writeSink writes a log message to a record sink and counts the written log record.
+// Record sinks keep the log records which couldn't be sent and send them again, hence their errors are only
+// recorded as internal errors.
+func (s *simpleLogService) writeSink(destination int, c *customLogger, logMsg *logMessage) {
+	start := s.now()
+	err := c.sink.writeRecord(logMsg, c.prefixFor(logMsg.level), start)
+	s.stats.WriteTime += time.Since(start)
+	s.stats.count(destination, 0)
+	s.diagnose(err)
+}
+
+// addSink registers a record sink as custom log destination.
+// It returns the log destination bit of the record sink.
+func (s *simpleLogService) addSink(name string, sink recordSink) (int, error) {
+	destination, err := s.addCustomLogger(name, nil)
+	if err != nil {
+		return 0, err
+	}
+	s.customLoggers[destination].sink = sink
+	return destination, nil
+}
+
+// flushSinks sends the log records buffered by the record sinks.
+func (s *simpleLogService) flushSinks() {
+	for _, c := range s.customLoggers {
+		if c.sink != nil {
+			s.diagnose(c.sink.flush())
+		}
+	}
+}
+
+// releaseSinks sends the log records buffered by the record sinks and removes the record sinks.
+func (s *simpleLogService) releaseSinks() {
+	for destination, c := range s.customLoggers {
+		if c.sink != nil {
+			s.diagnose(c.sink.close())
+			delete(s.customLoggers, destination)
+			removeBits(&s.customDestinations, destination)
+			s.namedDestinations.Delete(c.name)
+		}
+	}
+}
+
+// registerSink registers a record sink as custom log destination of the log service.
+func (s *simpleLogService) registerSink(name string, sink recordSink) (int, error) {
+	req := &destinationRequest{name: name, sink: sink}
+	if err := s.configure(registersink, req); err != nil {
+		sink.close()
+		return 0, err
+	}
+	destination := req.destination
+	addBits(&s.customDestinations, destination)
+	s.namedDestinations.Store(name, destination)
+	return destination, nil
+}
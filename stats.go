@@ -0,0 +1,108 @@
+package simplelog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ServiceStats represents the metrics of a log service.
+type ServiceStats struct {
+	Written        map[int]uint64    // number of log records written per log destination bit
+	Routed         map[string]uint64 // number of log records written per log file of the routing rules
+	Levels         map[int]uint64    // number of log records written per level; level 0 for log records without level
+	BytesWritten   uint64            // number of bytes written to all log destinations
+	Dropped        uint64            // number of log messages dropped because the data channel was full
+	RateLimited    uint64            // number of log records dropped because the rate limit of a log destination was exceeded
+	QueueDepth     int               // number of log messages in the data and priority channel, which are not yet written
+	QueueHighWater int               // highest number of log messages in the data and priority channel since the start
+	Flushes        uint64            // number of flushes of the log records buffered by the log destinations
+	Rotations      uint64            // number of log file rotations
+	WriteTime      time.Duration     // total time spent writing log records to the log destinations
+	LastError      error             // the last error which occurred while writing log records; nil if none occurred
+}
+
+// count counts a log record written to a log destination.
+func (st *ServiceStats) count(destination int, n int) {
+	if st.Written == nil {
+		st.Written = make(map[int]uint64)
+	}
+	st.Written[destination]++
+	st.BytesWritten += uint64(n)
+}
+
+// countRoute counts a log record written to the log file of a routing rule.
+func (st *ServiceStats) countRoute(file string, n int) {
+	if st.Routed == nil {
+		st.Routed = make(map[string]uint64)
+	}
+	st.Routed[file]++
+	st.BytesWritten += uint64(n)
+}
+
+// countLevel counts a log record of a level.
+func (st *ServiceStats) countLevel(level int) {
+	if st.Levels == nil {
+		st.Levels = make(map[int]uint64)
+	}
+	st.Levels[level]++
+}
+
+// record records an error which occurred while writing log records.
+func (st *ServiceStats) record(err error) {
+	if err != nil {
+		st.LastError = err
+	}
+}
+
+// snapshot returns a copy of the metrics of the log service.
+func (s *simpleLogService) snapshot() ServiceStats {
+	stats := s.stats
+	stats.Written = make(map[int]uint64, len(s.stats.Written))
+	for destination, n := range s.stats.Written {
+		stats.Written[destination] = n
+	}
+	stats.Routed = make(map[string]uint64, len(s.stats.Routed))
+	for file, n := range s.stats.Routed {
+		stats.Routed[file] = n
+	}
+	stats.Levels = make(map[int]uint64, len(s.stats.Levels))
+	for level, n := range s.stats.Levels {
+		stats.Levels[level] = n
+	}
+	stats.Dropped = atomic.LoadUint64(&s.dropped)
+	stats.QueueDepth = len(s.dataQueue) + len(s.priorityQueue)
+	limiters := []*rateLimiter{s.stdoutLogger.limiter, s.stderrLogger.limiter, s.fileLogger.limiter, s.networkLogger.limiter, s.webhookLogger.limiter, s.ringLogger.limiter, s.journalLogger.limiter}
+	for _, c := range s.customLoggers {
+		limiters = append(limiters, c.limiter)
+	}
+	for _, limiter := range limiters {
+		if limiter != nil {
+			stats.RateLimited += limiter.dropped
+		}
+	}
+	return stats
+}
+
+// trackQueue records the number of log messages in the data and priority channel, including the received one,
+// for the high-water marks of the metrics and of the periodic summary.
+func (s *simpleLogService) trackQueue() {
+	depth := len(s.dataQueue) + len(s.priorityQueue) + 1
+	if depth > s.stats.QueueHighWater {
+		s.stats.QueueHighWater = depth
+	}
+	if depth > s.summary.highWater {
+		s.summary.highWater = depth
+	}
+}
+
+// getStats implements Stats for the log service.
+func (s *simpleLogService) getStats() (ServiceStats, error) {
+	if !s.isActive() {
+		return ServiceStats{}, ErrNotRunning
+	}
+	var stats ServiceStats
+	if err := s.configure(getstats, &stats); err != nil {
+		return ServiceStats{}, err
+	}
+	return stats, nil
+}
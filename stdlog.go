@@ -0,0 +1,48 @@
+package simplelog
+
+import (
+	"io"
+	"log"
+)
+
+// stdLogCapture is a data collection of the settings of the standard library log package, which were replaced by
+// CaptureStdLog.
+type stdLogCapture struct {
+	writer io.Writer // the io.Writer the log package writes to while it is captured; nil if not captured
+	output io.Writer // the former output of the log package
+	flags  int       // the former flags of the log package
+}
+
+// captureStdLog implements CaptureStdLog for the log service.
+func (s *simpleLogService) captureStdLog(destination int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestinations(destination) {
+		return ErrUnknownDestination
+	}
+	s.stdLogMu.Lock()
+	defer s.stdLogMu.Unlock()
+	if s.stdLog.writer == nil || log.Writer() != s.stdLog.writer {
+		s.stdLog.output = log.Writer()
+		s.stdLog.flags = log.Flags()
+	}
+	s.stdLog.writer = newDestinationWriter(s, destination)
+	log.SetFlags(0)
+	log.SetOutput(s.stdLog.writer)
+	return nil
+}
+
+// releaseStdLog restores the output and the flags of the standard library log package, if it is still captured
+// by the log service.
+// It has to be called before the log service is stopped, as a concurrent log.Printf holds the lock of the log
+// package while it waits for the log service to accept its log message.
+func (s *simpleLogService) releaseStdLog() {
+	s.stdLogMu.Lock()
+	defer s.stdLogMu.Unlock()
+	if s.stdLog.writer != nil && log.Writer() == s.stdLog.writer {
+		log.SetOutput(s.stdLog.output)
+		log.SetFlags(s.stdLog.flags)
+	}
+	s.stdLog = stdLogCapture{}
+}
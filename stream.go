@@ -0,0 +1,100 @@
+package simplelog
+
+import (
+	"net/http"
+	"strings"
+)
+
+// publish sends the last log record of the tail buffer to all subscribers.
+// If the channel of a subscriber is full, the log record is dropped for this subscriber, so that a slow
+// subscriber never blocks the log service.
+func (s *simpleLogService) publish() {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if len(s.subscribers) == 0 {
+		return
+	}
+	line := s.tail.lines(1)[0]
+	for _, sub := range s.subscribers {
+		select {
+		case sub <- line:
+		default:
+		}
+	}
+}
+
+// removeSubscriber removes a subscriber and closes its channel.
+// Nothing happens if the subscriber was already removed.
+func (s *simpleLogService) removeSubscriber(sub chan string) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for i, c := range s.subscribers {
+		if c == sub {
+			s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+			close(sub)
+			return
+		}
+	}
+}
+
+// releaseSubscribers closes the channels of all subscribers.
+func (s *simpleLogService) releaseSubscribers() {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for _, sub := range s.subscribers {
+		close(sub)
+	}
+	s.subscribers = nil
+}
+
+// subscribe implements Subscribe for the log service.
+func (s *simpleLogService) subscribe(bufferSize int) (<-chan string, func(), error) {
+	if !s.isActive() {
+		return nil, nil, ErrNotRunning
+	}
+	if bufferSize <= 0 {
+		return nil, nil, ErrInvalidBufferSize
+	}
+	sub := make(chan string, bufferSize)
+	s.subscribersMu.Lock()
+	s.subscribers = append(s.subscribers, sub)
+	s.subscribersMu.Unlock()
+	return sub, func() { s.removeSubscriber(sub) }, nil
+}
+
+// streamHandler implements StreamHandler for the log service.
+func (s *simpleLogService) streamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		records, cancel, err := s.subscribe(streamBufferSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer cancel()
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case line, ok := <-records:
+				if !ok {
+					// the log service was stopped
+					return
+				}
+				// multi-line log records, e.g. with stack traces, are sent as one event with several data lines
+				if _, err := w.Write([]byte("data: " + strings.ReplaceAll(line, "\n", "\ndata: ") + "\n\n")); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
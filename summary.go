@@ -0,0 +1,86 @@
+package simplelog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// summaryMessage is the text of the log records summarizing the metrics of the log service.
+const summaryMessage = "log statistics"
+
+// statsSummary represents the periodic summary of the metrics of the log service, which is written as log record.
+type statsSummary struct {
+	destination int           // the log destination the summaries are written to
+	interval    time.Duration // the interval between two summaries; 0 if no summaries are written
+	last        ServiceStats  // the metrics of the log service at the time of the last summary
+	highWater   int           // the highest number of queued log messages since the last summary
+}
+
+// writeSummary writes the metrics of the log service since the last summary as log record of level INFO, e.g.
+// "log statistics written=12 written.error=1 written.stdout=12 dropped=0 rate_limited=0 queue_high_water=3".
+// The log records are counted per level and per log destination; log records without level are only counted
+// in total.
+func (s *simpleLogService) writeSummary() {
+	stats := s.snapshot()
+	last := s.summary.last
+	var total uint64
+	for level, n := range stats.Levels {
+		total += n - last.Levels[level]
+	}
+	fields := []any{"written", total}
+	for level := DEBUG; level <= FATAL; level++ {
+		if n := stats.Levels[level] - last.Levels[level]; n > 0 {
+			fields = append(fields, "written."+strings.ToLower(levelNames[level]), n)
+		}
+	}
+	destinations := make([]int, 0, len(stats.Written))
+	for destination := range stats.Written {
+		destinations = append(destinations, destination)
+	}
+	sort.Ints(destinations)
+	for _, destination := range destinations {
+		if n := stats.Written[destination] - last.Written[destination]; n > 0 {
+			fields = append(fields, "written."+s.destinationName(destination), n)
+		}
+	}
+	fields = append(fields,
+		"dropped", stats.Dropped-last.Dropped,
+		"rate_limited", stats.RateLimited-last.RateLimited,
+		"queue_high_water", s.summary.highWater)
+
+	summary := logMessage{destination: s.summary.destination, level: INFO, data: []any{summaryMessage}, fields: fields}
+	s.writeRecord(&summary)
+	// the summary itself isn't counted by the next summary
+	s.summary.last = s.snapshot()
+	s.summary.highWater = 0
+}
+
+// destinationName returns the name of a log destination bit, e.g. file for FILE or the name of a custom log
+// destination.
+func (s *simpleLogService) destinationName(destination int) string {
+	for name, bit := range destinationNames {
+		if bit == destination {
+			return name
+		}
+	}
+	if c, ok := s.customLoggers[destination]; ok && c.name != "" {
+		return c.name
+	}
+	return strconv.Itoa(destination)
+}
+
+// setStatsSummary implements SetStatsSummary for the log service.
+func (s *simpleLogService) setStatsSummary(destination int, interval time.Duration) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if interval < 0 {
+		return ErrInvalidInterval
+	}
+	if interval > 0 && !s.isDestinations(destination) {
+		return ErrUnknownDestination
+	}
+	return s.configure(setstatssummary, &statsSummary{destination: destination, interval: interval})
+}
@@ -0,0 +1,47 @@
+package simplelog
+
+// writeTagged implements WriteTagged for the log service.
+func (s *simpleLogService) writeTagged(destination int, tags []string, values ...any) error {
+	return s.enqueue(logMessage{destination: destination, tags: append([]string(nil), tags...)}.withValues(values))
+}
+
+// setTagFilter implements SetTagFilter for the log service.
+func (s *simpleLogService) setTagFilter(destination int, include, exclude []string) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if !s.isDestination(destination) {
+		return ErrUnknownDestination
+	}
+	req := &tagFilterRequest{destination: destination, include: tagSet(include), exclude: tagSet(exclude)}
+	return s.configure(settagfilter, req)
+}
+
+// tagSet returns the tags as set; nil if there are no tags.
+func tagSet(tags []string) map[string]bool {
+	if len(tags) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return set
+}
+
+// matchesTags returns true, if the tags of a log message pass the tag filters of the log destination, false
+// otherwise. If include tags are set, the log message has to have at least one of them. If exclude tags are set,
+// the log message must not have any of them.
+func (ls *logSettings) matchesTags(logMsg *logMessage) bool {
+	if len(ls.includeTags) == 0 && len(ls.excludeTags) == 0 {
+		return true
+	}
+	included := len(ls.includeTags) == 0
+	for _, tag := range logMsg.tags {
+		if ls.excludeTags[tag] {
+			return false
+		}
+		included = included || ls.includeTags[tag]
+	}
+	return included
+}
@@ -0,0 +1,35 @@
+package simplelog
+
+import (
+	"time"
+)
+
+// elapsedKey is the key of the structured field carrying the duration measured by a Timer.
+const elapsedKey = "elapsed"
+
+// Timer measures the duration of an operation, e.g. of a database query, and writes it as log message.
+// A Timer is safe for concurrent use.
+type Timer struct {
+	service *simpleLogService
+	name    string    // the name of the operation, e.g. db.query
+	start   time.Time // the point in time the Timer was started
+}
+
+// Elapsed returns the duration since the Timer was started.
+func (t *Timer) Elapsed() time.Duration {
+	return time.Since(t.start)
+}
+
+// Log writes the name of the operation and the duration since the Timer was started, as structured field
+// elapsed, to a specified destination, e.g. "db.query elapsed=12.3456ms". Log can be called more than once,
+// e.g. to log intermediate durations.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The same errors as of Write are returned.
+func (t *Timer) Log(destination int) error {
+	return t.service.writeKV(destination, t.name, elapsedKey, t.Elapsed())
+}
+
+// startTimer implements StartTimer for the log service.
+func (s *simpleLogService) startTimer(name string) *Timer {
+	return &Timer{service: s, name: name, start: time.Now()}
+}
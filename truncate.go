@@ -0,0 +1,35 @@
+package simplelog
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// truncatedFormat is the format of the marker which is appended to truncated log messages.
+const truncatedFormat = "...[truncated %d bytes]"
+
+// truncate limits the text of a log message to the maximum record size set by SetMaxRecordSize.
+// The text is formatted only once and used by all log destinations; an oversized text is cut at a UTF-8 character
+// boundary and the number of cut bytes is appended.
+func (s *simpleLogService) truncate(logMsg *logMessage) {
+	text := logMsg.text()
+	if len(text) > s.maxRecordSize {
+		cut := s.maxRecordSize
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + fmt.Sprintf(truncatedFormat, len(text)-cut)
+	}
+	logMsg.line = text
+}
+
+// setMaxRecordSize implements SetMaxRecordSize for the log service.
+func (s *simpleLogService) setMaxRecordSize(size int) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	if size < 0 {
+		return ErrInvalidRecordSize
+	}
+	return s.configure(setmaxrecordsize, size)
+}
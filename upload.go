@@ -0,0 +1,106 @@
+package simplelog
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// archive upload settings
+const (
+	uploadQueueSize = 16               // maximum number of archived log files waiting to be uploaded
+	uploadRetries   = 3                // number of retries if an upload fails
+	uploadBackoff   = 1 * time.Second  // wait time before the first retry; doubled with each further retry
+	uploadTimeout   = 10 * time.Minute // maximum time to wait for an upload to complete
+)
+
+// Uploader represents an object storage, e.g. an S3, GCS or Azure Blob Storage bucket, which archived log files
+// are uploaded to. Implementations wrap the client of the respective storage service.
+type Uploader interface {
+	// Upload stores the content read from r as object with the given name.
+	Upload(ctx context.Context, name string, r io.Reader) error
+}
+
+// archiveUploader is a data collection to support the upload of archived log files.
+type archiveUploader struct {
+	uploader    Uploader      // the object storage the archived log files are uploaded to
+	removeLocal bool          // flag to indicate whether an archived log file is removed after it was uploaded
+	archives    chan string   // the paths of the archived log files to be uploaded
+	done        chan struct{} // closed when the upload goroutine has exited
+}
+
+// newArchiveUploader returns an archiveUploader for the uploader u and starts its upload goroutine.
+func newArchiveUploader(u Uploader, removeLocal bool) *archiveUploader {
+	au := &archiveUploader{
+		uploader:    u,
+		removeLocal: removeLocal,
+		archives:    make(chan string, uploadQueueSize),
+		done:        make(chan struct{}),
+	}
+	go au.run()
+	return au
+}
+
+// enqueue hands over an archived log file to the upload goroutine.
+// If too many archived log files are waiting to be uploaded, the archived log file is only kept locally.
+func (au *archiveUploader) enqueue(path string) {
+	if au == nil {
+		return
+	}
+	select {
+	case au.archives <- path:
+	default:
+	}
+}
+
+// release uploads the pending archived log files and stops the upload goroutine.
+func (au *archiveUploader) release() {
+	if au == nil {
+		return
+	}
+	close(au.archives)
+	<-au.done
+}
+
+// run uploads each received archived log file.
+// Failed uploads are retried with exponential backoff; if all retries fail, the archived log file is kept locally.
+func (au *archiveUploader) run() {
+	defer close(au.done)
+	for path := range au.archives {
+		backoff := uploadBackoff
+		for attempt := 0; attempt <= uploadRetries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+			if au.upload(path) == nil {
+				if au.removeLocal {
+					os.Remove(path)
+				}
+				break
+			}
+		}
+	}
+}
+
+// upload uploads an archived log file as object named like the archived log file.
+func (au *archiveUploader) upload(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+	return au.uploader.Upload(ctx, filepath.Base(path), file)
+}
+
+// setArchiveUploader implements SetArchiveUploader for the log service.
+func (s *simpleLogService) setArchiveUploader(u Uploader, removeLocal bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setarchiveuploader, &uploaderRequest{uploader: u, removeLocal: removeLocal})
+}
@@ -0,0 +1,35 @@
+package simplelog
+
+// setVerbosity raises the minimum level of all log destinations to DEBUG or restores their previous levels.
+func (s *simpleLogService) setVerbosity(verbose bool) {
+	if verbose == (s.verboseLevels != nil) {
+		return
+	}
+	if !verbose {
+		for destination, level := range s.verboseLevels {
+			if settings := s.settings(destination); settings != nil {
+				settings.level = level
+			}
+		}
+		s.verboseLevels = nil
+		return
+	}
+	destinations := []int{STDOUT, STDERR, FILE, NETWORK, WEBHOOK, RING, JOURNAL}
+	for destination := range s.customLoggers {
+		destinations = append(destinations, destination)
+	}
+	s.verboseLevels = make(map[int]int, len(destinations))
+	for _, destination := range destinations {
+		settings := s.settings(destination)
+		s.verboseLevels[destination] = settings.level
+		settings.level = DEBUG
+	}
+}
+
+// setVerbose implements SetVerbose for the log service.
+func (s *simpleLogService) setVerbose(verbose bool) error {
+	if !s.isActive() {
+		return ErrNotRunning
+	}
+	return s.configure(setverbose, verbose)
+}
@@ -0,0 +1,116 @@
+package simplelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// webhook log settings
+const (
+	webhookBatchSize = 100             // maximum number of log records posted at once
+	webhookRetries   = 3               // number of retries if a post fails
+	webhookBackoff   = 1 * time.Second // wait time before the first retry; doubled with each further retry
+	webhookTimeout   = 5 * time.Second // maximum time to wait for the response of the webhook
+)
+
+// instance denotes the logWriter interface implementation by the webhookLogger type.
+func (wh *webhookLogger) instance() *logger {
+	if wh.self == nil {
+		wh.self = newLogger(wh)
+	}
+	return wh.self
+}
+
+// setupWebhook sets the URL of the webhook and starts the sender goroutine which posts the log records.
+func (wh *webhookLogger) setupWebhook(url string) {
+	wh.releaseWebhookLogger()
+	wh.url = url
+	wh.batches = make(chan []json.RawMessage, 16)
+	wh.sent = make(chan struct{})
+	go postBatches(url, wh.batches, wh.sent)
+}
+
+// releaseWebhookLogger posts the pending log records and releases all webhookLogger resources.
+func (wh *webhookLogger) releaseWebhookLogger() {
+	if wh.batches == nil {
+		return
+	}
+	wh.sendBatch()
+	close(wh.batches)
+	<-wh.sent
+	wh.url = ""
+	wh.batches = nil
+	wh.self = nil
+}
+
+// Write adds a log record to the batch of log records which are posted next.
+// Log records in JSON format are added as JSON objects, all others as JSON strings.
+// Write implements the io.Writer interface.
+func (wh *webhookLogger) Write(p []byte) (int, error) {
+	record := bytes.TrimSuffix(p, []byte("\n"))
+	if json.Valid(record) {
+		wh.batch = append(wh.batch, append(json.RawMessage(nil), record...))
+	} else {
+		data, err := json.Marshal(string(record))
+		if err != nil {
+			return 0, err
+		}
+		wh.batch = append(wh.batch, data)
+	}
+	if len(wh.batch) >= webhookBatchSize {
+		wh.sendBatch()
+	}
+	return len(p), nil
+}
+
+// sendBatch hands over the collected log records to the sender goroutine.
+func (wh *webhookLogger) sendBatch() {
+	if len(wh.batch) > 0 {
+		wh.batches <- wh.batch
+		wh.batch = nil
+	}
+}
+
+// postBatches posts each received batch of log records as JSON array to the webhook URL.
+// This function is kicked off in a dedicated goroutine, so that slow or failing posts don't stall the log service.
+// Failed posts are retried with exponential backoff; if all retries fail, the batch is dropped.
+func postBatches(url string, batches <-chan []json.RawMessage, sent chan<- struct{}) {
+	defer close(sent)
+	client := &http.Client{Timeout: webhookTimeout}
+	for batch := range batches {
+		data, err := json.Marshal(batch)
+		if err != nil {
+			continue
+		}
+		postRetry(client, url, data)
+	}
+}
+
+// postRetry posts the data to the URL and retries failed posts with exponential backoff.
+// It returns true, if the data was accepted, false if all retries failed.
+func postRetry(client *http.Client, url string, data []byte) bool {
+	backoff := webhookBackoff
+	for attempt := 0; attempt <= webhookRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if postBatch(client, url, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// postBatch posts a JSON array of log records to the webhook URL.
+// It returns true, if the webhook accepted the log records, false otherwise.
+func postBatch(client *http.Client, url string, data []byte) bool {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
@@ -0,0 +1,115 @@
+package simplelog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// workerQueueSize is the number of chunks of log records which can be pending for a worker goroutine.
+const workerQueueSize = 64
+
+// chunkPool is a pool of buffers used to pass chunks of log records to worker goroutines.
+var chunkPool = sync.Pool{New: func() any { return new([]byte) }}
+
+// asyncWriter is an io.Writer which writes to the underlying writer in its own worker goroutine.
+// Thereby, a slow log destination, e.g. a terminal attached over SSH, doesn't delay the log service
+// and the other log destinations. The log records are still formatted by the log service.
+type asyncWriter struct {
+	w      io.Writer     // the underlying writer
+	chunks chan *[]byte  // the chunks of log records to be written; nil requests an acknowledgement
+	acks   chan error    // the acknowledgements of the worker goroutine
+	done   chan struct{} // closed when the worker goroutine has exited
+	err    error         // the last error of the underlying writer; only accessed by the worker goroutine
+	window *int64        // the retry window of failed writes in nanoseconds, accessed atomically; nil if not retried
+	// the failed writes, which are handled by the log service
+	failing int32      // flag to indicate whether writes failed since the last call of failures; accessed atomically
+	failMu  sync.Mutex // synchronizes the access to failErr and failed
+	failErr error      // the last error of the failed writes
+	failed  [][]byte   // the chunks of the failed writes, at most workerQueueSize, the oldest first
+}
+
+// newAsyncWriter returns an asyncWriter for the writer w and starts its worker goroutine.
+// Failed writes are retried within the retry window; nil if they aren't retried.
+func newAsyncWriter(w io.Writer, window *int64) *asyncWriter {
+	a := &asyncWriter{
+		w:      w,
+		window: window,
+		chunks: make(chan *[]byte, workerQueueSize),
+		acks:   make(chan error),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// run writes the chunks of log records to the underlying writer.
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for chunk := range a.chunks {
+		if chunk == nil {
+			a.acks <- a.err
+			a.err = nil
+			continue
+		}
+		if _, err := writeRetry(a.w, *chunk, a.window); err != nil {
+			a.err = err
+			a.fail(err, *chunk)
+		}
+		chunkPool.Put(chunk)
+	}
+}
+
+// Write passes a copy of p to the worker goroutine. Errors of the underlying writer are returned by wait.
+// Write implements the io.Writer interface.
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	chunk := chunkPool.Get().(*[]byte)
+	*chunk = append((*chunk)[:0], p...)
+	a.chunks <- chunk
+	return len(p), nil
+}
+
+// fail records a failed write of a chunk, so that the log service can handle its log records.
+// If the maximum number of recorded chunks is reached, the oldest one is dropped.
+func (a *asyncWriter) fail(err error, chunk []byte) {
+	a.failMu.Lock()
+	if len(a.failed) == workerQueueSize {
+		a.failed = a.failed[1:]
+	}
+	a.failed = append(a.failed, append([]byte(nil), chunk...))
+	a.failErr = err
+	a.failMu.Unlock()
+	atomic.StoreInt32(&a.failing, 1)
+}
+
+// failures returns the chunks and the last error of the writes which failed since the last call of failures.
+// It returns nil, if no writes failed, without blocking the caller.
+func (a *asyncWriter) failures() ([][]byte, error) {
+	if atomic.LoadInt32(&a.failing) == 0 {
+		return nil, nil
+	}
+	a.failMu.Lock()
+	defer a.failMu.Unlock()
+	atomic.StoreInt32(&a.failing, 0)
+	chunks, err := a.failed, a.failErr
+	a.failed, a.failErr = nil, nil
+	return chunks, err
+}
+
+// wait waits until all chunks passed to the worker goroutine have been written.
+// It returns the last error of the underlying writer since the last call of wait.
+func (a *asyncWriter) wait() error {
+	a.chunks <- nil
+	return <-a.acks
+}
+
+// close writes all pending chunks and stops the worker goroutine.
+func (a *asyncWriter) close() error {
+	err := a.wait()
+	close(a.chunks)
+	<-a.done
+	return err
+}
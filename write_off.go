@@ -0,0 +1,37 @@
+//go:build simplelog_off
+
+package simplelog
+
+import (
+	"fmt"
+)
+
+// With the simplelog_off build tag, Write, ConditionalWrite, Writef and ConditionalWritef are empty functions,
+// which the compiler inlines, so that performance-critical binaries can strip these log calls entirely without
+// touching the call sites. The other functions of the package aren't affected.
+
+// Write doesn't write anything, as the program is built with the simplelog_off build tag.
+func Write(destination int, values ...any) error {
+	return nil
+}
+
+// ConditionalWrite doesn't write anything, as the program is built with the simplelog_off build tag.
+func ConditionalWrite(condition bool, destination int, values ...any) error {
+	return nil
+}
+
+// Writef doesn't write anything, as the program is built with the simplelog_off build tag.
+func Writef(destination int, format string, values ...any) error {
+	if vetPrintf {
+		_ = fmt.Sprintf(format, values...)
+	}
+	return nil
+}
+
+// ConditionalWritef doesn't write anything, as the program is built with the simplelog_off build tag.
+func ConditionalWritef(condition bool, destination int, format string, values ...any) error {
+	if vetPrintf {
+		_ = fmt.Sprintf(format, values...)
+	}
+	return nil
+}
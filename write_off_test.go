@@ -0,0 +1,28 @@
+//go:build simplelog_off
+
+package simplelog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteOff(t *testing.T) {
+	s = new(simpleLogService) // reset service instance
+	var buf bytes.Buffer
+
+	Startup(1)
+	destination, _ := RegisterDestination("buffer", &buf)
+	if err := Write(destination, "message 1"); err != nil {
+		t.Error("Expected no error but got", err)
+	}
+	ConditionalWrite(true, destination, "message 2")
+	Writef(destination, "message %d", 3)
+	ConditionalWritef(true, destination, "message %d", 4)
+	Log(INFO, destination, "message 5")
+	Shutdown(false)
+
+	if output := buf.String(); output != "INFO message 5\n" {
+		t.Error("Expected log record:", "INFO message 5", "- but got:", output)
+	}
+}
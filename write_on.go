@@ -0,0 +1,45 @@
+//go:build !simplelog_off
+
+package simplelog
+
+// Write writes a log message to a specified destination.
+// The destination parameter specifies the log destination, where the data will be written to.
+// Log destinations can be combined arbitrarily, e.g. STDERR | FILE.
+// The logValues parameter consists of one or multiple values that are logged.
+// Write only passes the values to the log service; they are formatted by the log service together with the prefix
+// and the timestamp after Write has returned. Hence values referring to data which is modified afterwards, e.g.
+// pointers, slices or maps, are logged with the data at the time of formatting; log a copy to keep their current state.
+// An error is returned if the log service is not running, the destination is unknown or
+// the log message should be written to a log file, network log or webhook log which has not been setup.
+func Write(destination int, values ...any) error {
+	return s.write(destination, values...)
+}
+
+// ConditionalWrite writes or doesn't write a log message to a specified destination based on a condition.
+// The condition parameter enables (true) or disables (false) whether or not a message is written.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The logValues parameter consists of one or multiple values that are logged.
+// An error is returned if the log service is not running, the destination is unknown or
+// the log message should be written to a log file, network log or webhook log which has not been setup.
+func ConditionalWrite(condition bool, destination int, values ...any) error {
+	return s.conditionalWrite(condition, destination, values...)
+}
+
+// Writef writes a log message formatted according to a format specifier to a specified destination.
+// Thereby the format verbs of the fmt package can be used. The formatting is done by the log service,
+// hence the values are read after Writef has returned and must not be modified by the caller afterwards.
+// The destination parameter specifies the log destination, where the data will be written to.
+// The format parameter specifies the format specifier, e.g. "%s: %d".
+// The values parameter consists of one or multiple values that are formatted according to the format specifier.
+// The same errors as of Write are returned.
+func Writef(destination int, format string, values ...any) error {
+	return s.writef(destination, format, values...)
+}
+
+// ConditionalWritef writes or doesn't write a log message formatted according to a format specifier to a
+// specified destination based on a condition.
+// The condition parameter enables (true) or disables (false) whether or not a message is written.
+// See Writef for details of the other parameters and the returned errors.
+func ConditionalWritef(condition bool, destination int, format string, values ...any) error {
+	return s.conditionalWritef(condition, destination, format, values...)
+}
@@ -0,0 +1,36 @@
+package simplelog
+
+import (
+	"bytes"
+	"log"
+)
+
+// destinationWriter is an io.Writer which converts written data into log messages for a log destination.
+type destinationWriter struct {
+	service     *simpleLogService // the log service which receives the log messages
+	destination int               // the log destination bits, e.g. stdout, file, and so on.
+}
+
+// Write converts the data p into log messages and sends them to the log service.
+// Each line of p becomes a separate log message; a trailing newline is removed.
+// Write implements the io.Writer interface.
+func (w *destinationWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte("\n")), []byte("\n")) {
+		if err := w.service.enqueue(logMessage{destination: w.destination, data: []any{string(line)}}); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// newDestinationWriter instantiates a new destinationWriter.
+// The service parameter sets the log service and the destination parameter the log destination to which data will be written.
+func newDestinationWriter(service *simpleLogService, destination int) *destinationWriter {
+	return &destinationWriter{service: service, destination: destination}
+}
+
+// newStdLogger instantiates a *log.Logger which writes to a log destination by a destinationWriter.
+// As the log service places its own prefix in front of each log record, the *log.Logger writes no date or time.
+func newStdLogger(service *simpleLogService, destination int, prefix string) *log.Logger {
+	return log.New(newDestinationWriter(service, destination), prefix, 0)
+}